@@ -0,0 +1,164 @@
+package structs
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+type arithOp struct {
+	name   string
+	ints   func(x, y int64) (int64, bool)
+	uints  func(x, y uint64) (uint64, bool)
+	floats func(x, y float64) float64
+}
+
+var (
+	addOp = arithOp{
+		name: "add",
+		ints: func(x, y int64) (int64, bool) {
+			var r = x + y
+			return r, (r > x) == (y > 0)
+		},
+		uints: func(x, y uint64) (uint64, bool) {
+			var r = x + y
+			return r, r >= x
+		},
+		floats: func(x, y float64) float64 { return x + y },
+	}
+	subOp = arithOp{
+		name: "subtract",
+		ints: func(x, y int64) (int64, bool) {
+			var r = x - y
+			return r, (r < x) == (y > 0)
+		},
+		uints: func(x, y uint64) (uint64, bool) {
+			return x - y, y <= x
+		},
+		floats: func(x, y float64) float64 { return x - y },
+	}
+	mulOp = arithOp{
+		name: "multiply",
+		ints: func(x, y int64) (int64, bool) {
+			if x == 0 || y == 0 {
+				return 0, true
+			}
+			var r = x * y
+			return r, r/y == x && !(x == -1 && y == math.MinInt64) && !(y == -1 && x == math.MinInt64)
+		},
+		uints: func(x, y uint64) (uint64, bool) {
+			if x == 0 || y == 0 {
+				return 0, true
+			}
+			var r = x * y
+			return r, r/y == x
+		},
+		floats: func(x, y float64) float64 { return x * y },
+	}
+)
+
+// Add sets the fields of dst to the sum of the fields of a and b, see Sub.
+func Add(dst, a, b *Struct, fields ...string) error {
+	return arith(addOp, dst, a, b, fields)
+}
+
+// Sub sets the fields of dst to the fields of a minus the fields of b.
+//
+// Fields are matched by name, dst may be a or b. The values of a and b are converted to the type of the field in dst,
+// an error is returned if this loses precision, or if the result overflows.
+//
+// If no fields are given, all numeric fields of dst which are present in a and b are used,
+// otherwise an error is returned if any of the fields is missing or not numeric.
+// If an error is returned, dst is left unchanged.
+//
+// It will panic if any of the structs has not been made.
+func Sub(dst, a, b *Struct, fields ...string) error {
+	return arith(subOp, dst, a, b, fields)
+}
+
+// Mul sets the fields of dst to the product of the fields of a and b, see Sub.
+func Mul(dst, a, b *Struct, fields ...string) error {
+	return arith(mulOp, dst, a, b, fields)
+}
+
+func arith(op arithOp, dst, a, b *Struct, fields []string) error {
+	dst.checkMade("Cannot " + op.name + " if struct has not been made")
+	a.checkMade("Cannot " + op.name + " if struct has not been made")
+	b.checkMade("Cannot " + op.name + " if struct has not been made")
+
+	if len(fields) == 0 {
+		for i := 0; i < dst.sstruct.NumField(); i++ {
+			var field = dst.sstruct.Field(i)
+			if !isNumericKind(field.Type.Kind()) {
+				continue
+			}
+			if a.structValue.FieldByName(field.Name).IsValid() && b.structValue.FieldByName(field.Name).IsValid() {
+				fields = append(fields, field.Name)
+			}
+		}
+	}
+
+	var results = make([]reflect.Value, len(fields))
+	for i, name := range fields {
+		var result, err = arithField(op, dst, a, b, name)
+		if err != nil {
+			return fmt.Errorf("%s: %s", name, err)
+		}
+		if err := catch(func() { dst.checkPolicy(ActionWrite, name, result.Interface()) }); err != nil {
+			return err
+		}
+		results[i] = result
+	}
+	for i, name := range fields {
+		dst.SetField(name, results[i].Interface())
+	}
+	return nil
+}
+
+func arithField(op arithOp, dst, a, b *Struct, name string) (reflect.Value, error) {
+	var field, ok = dst.sstruct.FieldByName(name)
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("Field does not exist")
+	}
+	if !isNumericKind(field.Type.Kind()) {
+		return reflect.Value{}, fmt.Errorf("Cannot %s values of type %s", op.name, field.Type.String())
+	}
+	var operands [2]reflect.Value
+	for i, s := range [2]*Struct{a, b} {
+		var value = s.structValue.FieldByName(name)
+		if !value.IsValid() {
+			return reflect.Value{}, fmt.Errorf("Field does not exist in both structs")
+		}
+		if err := catch(func() { s.checkPolicy(ActionRead, name, value.Interface()) }); err != nil {
+			return reflect.Value{}, err
+		}
+		if !isNumericKind(value.Kind()) {
+			return reflect.Value{}, fmt.Errorf("Cannot %s values of type %s", op.name, value.Type().String())
+		}
+		var converted, err = convertTo(value.Interface(), field.Type, dst.tag)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		operands[i] = converted
+	}
+
+	var result = reflect.New(field.Type).Elem()
+	var x, y = operands[0], operands[1]
+	switch field.Type.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var r, ok = op.ints(x.Int(), y.Int())
+		if !ok || result.OverflowInt(r) {
+			return reflect.Value{}, fmt.Errorf("Result of %s overflows %s", op.name, field.Type.String())
+		}
+		result.SetInt(r)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var r, ok = op.uints(x.Uint(), y.Uint())
+		if !ok || result.OverflowUint(r) {
+			return reflect.Value{}, fmt.Errorf("Result of %s overflows %s", op.name, field.Type.String())
+		}
+		result.SetUint(r)
+	default:
+		result.SetFloat(op.floats(x.Float(), y.Float()))
+	}
+	return result, nil
+}
@@ -0,0 +1,208 @@
+package structs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sync"
+)
+
+// BlobLoader loads the data a blob reference points to.
+type BlobLoader func(ctx context.Context, ref *url.URL) ([]byte, error)
+
+var (
+	blobLoadersMu sync.RWMutex
+	blobLoaders   = map[string]BlobLoader{}
+)
+
+// RegisterBlobLoader registers the loader for blob references with the given URL scheme.
+//
+// No loaders are registered by default, as blob references may come from untrusted input.
+// References without a scheme use the "file" scheme, I.E. RegisterBlobLoader("file", FileBlobLoader("/srv/blobs", 10<<20)).
+func RegisterBlobLoader(scheme string, loader BlobLoader) {
+	blobLoadersMu.Lock()
+	defer blobLoadersMu.Unlock()
+	blobLoaders[scheme] = loader
+}
+
+// FileBlobLoader returns a loader which reads files from the root directory.
+//
+// The path of the reference is resolved relative to root, and cannot escape it with "..".
+// Symbolic links inside root are followed.
+// Files larger than maxSize bytes are not loaded.
+func FileBlobLoader(root string, maxSize int64) BlobLoader {
+	return func(ctx context.Context, ref *url.URL) ([]byte, error) {
+		var f, err = os.Open(filepath.Join(root, filepath.FromSlash(path.Clean("/"+ref.Path))))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return readBlob(f, ref, maxSize)
+	}
+}
+
+// HTTPBlobLoader returns a loader which fetches references with a GET request using the client,
+// or http.DefaultClient if client is nil.
+//
+// Responses larger than maxSize bytes are not loaded.
+// The loader fetches any URL it is given, restrict the client's transport if references may point to internal hosts.
+func HTTPBlobLoader(client *http.Client, maxSize int64) BlobLoader {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(ctx context.Context, ref *url.URL) ([]byte, error) {
+		var req, err = http.NewRequestWithContext(ctx, http.MethodGet, ref.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("Cannot load blob %s: %s", ref, resp.Status)
+		}
+		return readBlob(resp.Body, ref, maxSize)
+	}
+}
+
+func readBlob(r io.Reader, ref *url.URL, maxSize int64) ([]byte, error) {
+	var data, err = io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("Blob %s exceeds the maximum size of %d bytes", ref, maxSize)
+	}
+	return data, nil
+}
+
+// Blob is binary data which is either held inline, or referenced by a file path or URL.
+//
+// Referenced data is only loaded when it is first accessed.
+type Blob struct {
+	mu     sync.Mutex
+	data   []byte
+	ref    string
+	loaded bool
+}
+
+// NewBlob returns a blob holding the data inline.
+func NewBlob(data []byte) *Blob {
+	return &Blob{data: data, loaded: true}
+}
+
+// BlobRef returns a blob referencing a file path or URL, I.E. "/tmp/image.png" or "https://example.com/image.png".
+func BlobRef(ref string) *Blob {
+	return &Blob{ref: ref}
+}
+
+// Ref returns the reference of the blob, or an empty string if the data is held inline.
+func (b *Blob) Ref() string {
+	return b.ref
+}
+
+// Loaded returns whether the data of the blob is currently held in memory.
+func (b *Blob) Loaded() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.loaded
+}
+
+// Bytes returns the data of the blob, loading it with the registered loader on first access.
+func (b *Blob) Bytes(ctx context.Context) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.loaded {
+		return b.data, nil
+	}
+	var ref, err = url.Parse(b.ref)
+	if err != nil {
+		return nil, err
+	}
+	if ref.Scheme == "" {
+		ref.Scheme = "file"
+	}
+	blobLoadersMu.RLock()
+	var loader, ok = blobLoaders[ref.Scheme]
+	blobLoadersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("No blob loader registered for scheme %s", ref.Scheme)
+	}
+	if b.data, err = loader(ctx, ref); err != nil {
+		return nil, err
+	}
+	b.loaded = true
+	return b.data, nil
+}
+
+// Release drops the loaded data of a referenced blob, so it is loaded again on the next access.
+//
+// Inline blobs are not affected.
+func (b *Blob) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ref != "" {
+		b.data = nil
+		b.loaded = false
+	}
+}
+
+// Clone returns a copy of the blob which shares no memory with it.
+//
+// The copy holds the data if it is currently loaded, and is never locked.
+func (b *Blob) Clone() *Blob {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return &Blob{
+		data:   bytes.Clone(b.data),
+		ref:    b.ref,
+		loaded: b.loaded,
+	}
+}
+
+type blobJSON struct {
+	Ref  string `json:"ref,omitempty"`
+	Data []byte `json:"data,omitempty"`
+}
+
+// MarshalJSON encodes referenced blobs as their reference, without loading them.
+//
+// Inline blobs are encoded as base64.
+func (b *Blob) MarshalJSON() ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ref != "" {
+		return json.Marshal(blobJSON{Ref: b.ref})
+	}
+	return json.Marshal(blobJSON{Data: b.data})
+}
+
+func (b *Blob) UnmarshalJSON(data []byte) error {
+	var v blobJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.ref = v.Ref
+	b.data = v.Data
+	b.loaded = v.Ref == ""
+	return nil
+}
+
+var blobType = reflect.TypeOf((*Blob)(nil))
+
+// BlobField adds a field of type *Blob.
+func (s *Struct) BlobField(absolute_name, name string, required ...bool) {
+	s.AddField(absolute_name, name, blobType, required...)
+}
@@ -0,0 +1,127 @@
+package structs
+
+import (
+	"crypto"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+)
+
+type checksum struct {
+	field string
+	algo  crypto.Hash
+	over  []string
+}
+
+// ChecksumField adds a string field holding the hex encoded checksum of the listed fields.
+//
+// The hash function must be linked into the binary, I.E. by importing crypto/sha256 for crypto.SHA256.
+//
+// The checksum is recomputed by UpdateChecksums. MarshalJSON, MarshalCompact and EncodeDelta encode
+// the computed checksum without storing it in the struct.
+func (s *Struct) ChecksumField(absolute_name, name string, algo crypto.Hash, over ...string) {
+	if len(over) == 0 {
+		panic(fmt.Sprintf("Checksum field %s must be computed over at least one field", absolute_name))
+	}
+	s.StringField(absolute_name, name)
+	s.checksums = append(s.checksums, checksum{
+		field: absolute_name,
+		algo:  algo,
+		over:  over,
+	})
+}
+
+// Checksum computes the checksum for the checksum field with the given name, without storing it.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) Checksum(name string) (string, error) {
+	s.checkMade("Cannot compute checksum if struct has not been made")
+	for _, c := range s.checksums {
+		if c.field == name {
+			return s.computeChecksum(c)
+		}
+	}
+	return "", fmt.Errorf("Field %s is not a checksum field", name)
+}
+
+// UpdateChecksums recomputes and stores the values of all checksum fields.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) UpdateChecksums() error {
+	s.checkMade("Cannot update checksums if struct has not been made")
+	for _, c := range s.checksums {
+		var sum, err = s.computeChecksum(c)
+		if err != nil {
+			return err
+		}
+		s.structValue.FieldByName(c.field).SetString(sum)
+	}
+	return nil
+}
+
+// withChecksums returns a copy of v, as returned by readable or readableOrZero, with its checksum fields
+// set to their computed values. Checksum fields left out or zeroed by the policy are not set.
+//
+// The struct itself is not modified, so encoders may call it concurrently.
+func (s *Struct) withChecksums(v reflect.Value) (reflect.Value, error) {
+	if len(s.checksums) == 0 {
+		return v, nil
+	}
+	var out = reflect.New(v.Type()).Elem()
+	out.Set(v)
+	for _, c := range s.checksums {
+		var field = out.FieldByName(c.field)
+		if !field.IsValid() {
+			continue
+		}
+		if s.policy != nil && !s.policy.Allow(s.policyCtx, ActionRead, c.field, s.structValue.FieldByName(c.field).Interface()) {
+			continue
+		}
+		var sum, err = s.computeChecksum(c)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		field.SetString(sum)
+	}
+	return out, nil
+}
+
+// VerifyChecksums reports whether all checksum fields hold the checksum of their current values.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) VerifyChecksums() (bool, error) {
+	s.checkMade("Cannot verify checksums if struct has not been made")
+	for _, c := range s.checksums {
+		var sum, err = s.computeChecksum(c)
+		if err != nil {
+			return false, err
+		}
+		if s.structValue.FieldByName(c.field).String() != sum {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// computeChecksum hashes the name and string representation of every listed field, in order.
+func (s *Struct) computeChecksum(c checksum) (string, error) {
+	if !c.algo.Available() {
+		return "", fmt.Errorf("Hash function for checksum field %s is not available", c.field)
+	}
+	var h = c.algo.New()
+	for _, name := range c.over {
+		var field = s.structValue.FieldByName(name)
+		if !field.IsValid() {
+			return "", fmt.Errorf("Field %s does not exist", name)
+		}
+		var str, err = formatValue(field)
+		if err != nil {
+			return "", fmt.Errorf("%s: %s", name, err)
+		}
+		h.Write([]byte(name))
+		h.Write([]byte{0})
+		h.Write([]byte(str))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,182 @@
+package structs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// chunkHeaderSize is the size of the frame header: the chunk index, the amount of chunks, and the payload length.
+const chunkHeaderSize = 12
+
+// ErrChunksIncomplete is returned when unmarshalling chunks before all of them have been received.
+var ErrChunksIncomplete = errors.New("Not all chunks have been received")
+
+// MarshalChunked writes the JSON encoding of the struct as framed chunks of at most chunkSize bytes.
+//
+// Every frame holds the chunk index, the amount of chunks, the payload length, the payload,
+// and a CRC-32 checksum of the payload, all integers are big endian uint32s.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) MarshalChunked(w io.Writer, chunkSize int) error {
+	return s.MarshalChunkedFrom(w, chunkSize, 0)
+}
+
+// MarshalChunkedFrom writes the chunks starting at the given index,
+// to resume a transfer after the receiver has reported its ChunkReader.Next index.
+//
+// The struct must not have changed since the previous chunks were written.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) MarshalChunkedFrom(w io.Writer, chunkSize, start int) error {
+	if chunkSize <= 0 {
+		return fmt.Errorf("Invalid chunk size %d", chunkSize)
+	}
+	var data, err = s.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	var total = (len(data) + chunkSize - 1) / chunkSize
+	if total == 0 {
+		total = 1
+	}
+	var frame = make([]byte, 0, chunkHeaderSize+chunkSize+4)
+	for i := start; i < total; i++ {
+		var end = (i + 1) * chunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		var chunk = data[i*chunkSize : end]
+		frame = binary.BigEndian.AppendUint32(frame[:0], uint32(i))
+		frame = binary.BigEndian.AppendUint32(frame, uint32(total))
+		frame = binary.BigEndian.AppendUint32(frame, uint32(len(chunk)))
+		frame = append(frame, chunk...)
+		frame = binary.BigEndian.AppendUint32(frame, crc32.ChecksumIEEE(chunk))
+		if _, err = w.Write(frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ChunkReader collects the chunks written by MarshalChunked.
+//
+// Chunks may arrive over several readers, I.E. after a connection was lost,
+// progress is kept so the sender can resume from Next.
+type ChunkReader struct {
+	// The maximum payload size of a single chunk, defaults to 16 MiB.
+	MaxChunkSize int
+
+	// The maximum amount of chunks in a transfer, defaults to 4096.
+	MaxChunks int
+
+	// The maximum payload size of all chunks together, defaults to 64 MiB.
+	MaxSize int
+
+	chunks   [][]byte
+	received int
+	size     int
+}
+
+// ReadFrom reads frames until the reader is exhausted.
+//
+// A frame which is cut off is discarded, and io.ErrUnexpectedEOF is returned.
+// Frames with an invalid checksum are rejected.
+func (c *ChunkReader) ReadFrom(r io.Reader) (int64, error) {
+	var n int64
+	var header [chunkHeaderSize]byte
+	for {
+		var read, err = io.ReadFull(r, header[:])
+		n += int64(read)
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+
+		var index = int(binary.BigEndian.Uint32(header[0:]))
+		var total = int(binary.BigEndian.Uint32(header[4:]))
+		var size = int(binary.BigEndian.Uint32(header[8:]))
+		var maxChunkSize, maxChunks, maxSize = c.MaxChunkSize, c.MaxChunks, c.MaxSize
+		if maxChunkSize <= 0 {
+			maxChunkSize = 16 << 20
+		}
+		if maxChunks <= 0 {
+			maxChunks = 4096
+		}
+		if maxSize <= 0 {
+			maxSize = 64 << 20
+		}
+		if size > maxChunkSize {
+			return n, fmt.Errorf("Chunk %d of %d bytes exceeds the maximum of %d bytes", index, size, maxChunkSize)
+		}
+		if total > maxChunks {
+			return n, fmt.Errorf("Transfer of %d chunks exceeds the maximum of %d chunks", total, maxChunks)
+		}
+		if c.chunks == nil {
+			c.chunks = make([][]byte, total)
+		}
+		if total != len(c.chunks) || index >= total {
+			return n, fmt.Errorf("Chunk %d of %d does not belong to a transfer of %d chunks", index, total, len(c.chunks))
+		}
+
+		var payload = make([]byte, size+4)
+		read, err = io.ReadFull(r, payload)
+		n += int64(read)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return n, err
+		}
+		var chunk = payload[:size]
+		if crc32.ChecksumIEEE(chunk) != binary.BigEndian.Uint32(payload[size:]) {
+			return n, fmt.Errorf("Checksum mismatch in chunk %d", index)
+		}
+		if c.chunks[index] == nil {
+			if c.size+size > maxSize {
+				return n, fmt.Errorf("Transfer exceeds the maximum size of %d bytes", maxSize)
+			}
+			c.chunks[index] = chunk
+			c.received++
+			c.size += size
+		}
+	}
+}
+
+// Next returns the index of the first chunk which has not been received.
+func (c *ChunkReader) Next() int {
+	for i, chunk := range c.chunks {
+		if chunk == nil {
+			return i
+		}
+	}
+	return len(c.chunks)
+}
+
+// Done reports whether all chunks have been received.
+func (c *ChunkReader) Done() bool {
+	return c.chunks != nil && c.received == len(c.chunks)
+}
+
+// Unmarshal decodes the received chunks into the struct.
+//
+// It will panic if the struct has not been made.
+func (c *ChunkReader) Unmarshal(s *Struct) error {
+	s.checkMade("Cannot unmarshal if struct has not been made")
+	if !c.Done() {
+		return ErrChunksIncomplete
+	}
+	var size int
+	for _, chunk := range c.chunks {
+		size += len(chunk)
+	}
+	var data = make([]byte, 0, size)
+	for _, chunk := range c.chunks {
+		data = append(data, chunk...)
+	}
+	return s.UnmarshalJSON(data)
+}
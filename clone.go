@@ -0,0 +1,71 @@
+package structs
+
+import "reflect"
+
+// deepCopyValue returns a copy of the value which shares no memory with it.
+//
+// Pointers which were already copied are mapped to their copy, so cycles and shared pointers are preserved.
+// Blobs are copied with Blob.Clone. Unexported fields of other nested structs are copied as they are,
+// so memory they point to, I.E. the words of a big.Int, is shared with the copy.
+func deepCopyValue(v reflect.Value, seen map[reflect.Value]reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		if copied, ok := seen[v]; ok {
+			return copied
+		}
+		if v.Type() == blobType {
+			var copied = reflect.ValueOf(v.Interface().(*Blob).Clone())
+			seen[v] = copied
+			return copied
+		}
+		var ptr = reflect.New(v.Type().Elem())
+		seen[v] = ptr
+		ptr.Elem().Set(deepCopyValue(v.Elem(), seen))
+		return ptr
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		var copied = reflect.New(v.Type()).Elem()
+		copied.Set(deepCopyValue(v.Elem(), seen))
+		return copied
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		var copied = reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(deepCopyValue(v.Index(i), seen))
+		}
+		return copied
+	case reflect.Array:
+		var copied = reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(deepCopyValue(v.Index(i), seen))
+		}
+		return copied
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		var copied = reflect.MakeMapWithSize(v.Type(), v.Len())
+		var iter = v.MapRange()
+		for iter.Next() {
+			copied.SetMapIndex(deepCopyValue(iter.Key(), seen), deepCopyValue(iter.Value(), seen))
+		}
+		return copied
+	case reflect.Struct:
+		var copied = reflect.New(v.Type()).Elem()
+		copied.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				copied.Field(i).Set(deepCopyValue(v.Field(i), seen))
+			}
+		}
+		return copied
+	}
+	return v
+}
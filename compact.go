@@ -0,0 +1,416 @@
+package structs
+
+import (
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+var errShortCompact = errors.New("Unexpected end of compact data")
+
+// MarshalCompact encodes the struct in a compact binary format, meant for constrained devices.
+//
+// The data starts with a presence bitmap holding a bit for every field, only fields which are not zero are encoded.
+// Integers are encoded as varints, floats in their IEEE 754 bits, and strings, slices and maps are prefixed with their length.
+// Types implementing encoding.TextMarshaler are encoded as their text, and types implementing json.Marshaler as their JSON.
+// Nested structs have their own bitmap, an error is returned for structs with unexported fields which implement neither.
+//
+// The format carries no field names or types, so both sides must use the same struct definition.
+//
+// It will panic if the struct has not been made.
+func MarshalCompact(s *Struct) ([]byte, error) {
+	s.checkMade("Cannot marshal if struct has not been made")
+	var v, err = s.withChecksums(s.readableOrZero())
+	if err != nil {
+		return nil, err
+	}
+	return appendCompact(nil, v)
+}
+
+// UnmarshalCompact decodes data written by MarshalCompact into the struct.
+//
+// Fields which are not present in the data are set to their zero value.
+// Changed fields are set through SetField, if any field cannot be set an error is returned and the struct is left unchanged.
+//
+// It will panic if the struct has not been made.
+func UnmarshalCompact(s *Struct, data []byte) error {
+	s.checkMade("Cannot unmarshal if struct has not been made")
+	var value = reflect.New(s.sstruct).Elem()
+	if err := decodeCompact(data, value); err != nil {
+		return err
+	}
+	return s.setDecoded(value)
+}
+
+// decodeCompact decodes the data into the zero struct value v, the data must not hold any trailing bytes.
+func decodeCompact(data []byte, v reflect.Value) error {
+	var rest, err = readCompact(data, v)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return fmt.Errorf("Unexpected %d trailing bytes in compact data", len(rest))
+	}
+	return nil
+}
+
+// isCompactText reports whether values of the type are encoded as their text.
+func isCompactText(typ reflect.Type) bool {
+	return typ.Kind() != reflect.Ptr && typ.Implements(textMarshalerType) && reflect.PtrTo(typ).Implements(textUnmarshalerType)
+}
+
+// isCompactJSON reports whether values of the type are encoded as their JSON.
+//
+// The marshaler may be implemented on the pointer, as it is for Blob.
+func isCompactJSON(typ reflect.Type) bool {
+	return typ.Kind() != reflect.Ptr && reflect.PtrTo(typ).Implements(jsonMarshalerType) && reflect.PtrTo(typ).Implements(jsonUnmarshalerType)
+}
+
+// checkCompactStruct returns an error if the struct has unexported fields, which cannot be encoded or decoded.
+func checkCompactStruct(typ reflect.Type) error {
+	for i := 0; i < typ.NumField(); i++ {
+		if !typ.Field(i).IsExported() {
+			return fmt.Errorf("Cannot encode value of type %s with unexported fields", typ.String())
+		}
+	}
+	return nil
+}
+
+func appendCompact(b []byte, v reflect.Value) ([]byte, error) {
+	var err error
+	if isCompactText(v.Type()) {
+		var text []byte
+		if text, err = v.Interface().(encoding.TextMarshaler).MarshalText(); err != nil {
+			return nil, err
+		}
+		b = binary.AppendUvarint(b, uint64(len(text)))
+		return append(b, text...), nil
+	}
+	if isCompactJSON(v.Type()) {
+		if !v.CanAddr() {
+			var addressable = reflect.New(v.Type()).Elem()
+			addressable.Set(v)
+			v = addressable
+		}
+		var data []byte
+		if data, err = v.Addr().Interface().(json.Marshaler).MarshalJSON(); err != nil {
+			return nil, err
+		}
+		b = binary.AppendUvarint(b, uint64(len(data)))
+		return append(b, data...), nil
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return append(b, 1), nil
+		}
+		return append(b, 0), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return binary.AppendVarint(b, v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return binary.AppendUvarint(b, v.Uint()), nil
+	case reflect.Float32:
+		return binary.LittleEndian.AppendUint32(b, math.Float32bits(float32(v.Float()))), nil
+	case reflect.Float64:
+		return binary.LittleEndian.AppendUint64(b, math.Float64bits(v.Float())), nil
+	case reflect.String:
+		b = binary.AppendUvarint(b, uint64(v.Len()))
+		return append(b, v.String()...), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b = binary.AppendUvarint(b, uint64(v.Len()))
+			return append(b, v.Bytes()...), nil
+		}
+		fallthrough
+	case reflect.Array:
+		b = binary.AppendUvarint(b, uint64(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			if b, err = appendCompact(b, v.Index(i)); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case reflect.Map:
+		b = binary.AppendUvarint(b, uint64(v.Len()))
+		var iter = v.MapRange()
+		for iter.Next() {
+			if b, err = appendCompact(b, iter.Key()); err != nil {
+				return nil, err
+			}
+			if b, err = appendCompact(b, iter.Value()); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case reflect.Ptr:
+		if v.IsNil() {
+			return append(b, 0), nil
+		}
+		return appendCompact(append(b, 1), v.Elem())
+	case reflect.Struct:
+		if err = checkCompactStruct(v.Type()); err != nil {
+			return nil, err
+		}
+		var bitmap = make([]byte, (v.NumField()+7)/8)
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Field(i).IsZero() {
+				bitmap[i/8] |= 1 << (i % 8)
+			}
+		}
+		b = append(b, bitmap...)
+		for i := 0; i < v.NumField(); i++ {
+			if bitmap[i/8]&(1<<(i%8)) == 0 {
+				continue
+			}
+			if b, err = appendCompact(b, v.Field(i)); err != nil {
+				return nil, fmt.Errorf("%s: %s", v.Type().Field(i).Name, err)
+			}
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("Cannot encode value of type %s", v.Type().String())
+}
+
+func readCompactLength(b []byte) (int, []byte, error) {
+	var n, size = binary.Uvarint(b)
+	if size <= 0 {
+		return 0, nil, errShortCompact
+	}
+	b = b[size:]
+	if n > uint64(len(b)) {
+		return 0, nil, errShortCompact
+	}
+	return int(n), b, nil
+}
+
+func readCompact(b []byte, v reflect.Value) ([]byte, error) {
+	var err error
+	if isCompactText(v.Type()) {
+		var n int
+		if n, b, err = readCompactLength(b); err != nil {
+			return nil, err
+		}
+		if err = v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText(b[:n]); err != nil {
+			return nil, err
+		}
+		return b[n:], nil
+	}
+	if isCompactJSON(v.Type()) {
+		var n int
+		if n, b, err = readCompactLength(b); err != nil {
+			return nil, err
+		}
+		if err = v.Addr().Interface().(json.Unmarshaler).UnmarshalJSON(b[:n]); err != nil {
+			return nil, err
+		}
+		return b[n:], nil
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		if len(b) < 1 {
+			return nil, errShortCompact
+		}
+		v.SetBool(b[0] != 0)
+		return b[1:], nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i, size = binary.Varint(b)
+		if size <= 0 {
+			return nil, errShortCompact
+		}
+		if v.OverflowInt(i) {
+			return nil, fmt.Errorf("Value %d overflows %s", i, v.Type().String())
+		}
+		v.SetInt(i)
+		return b[size:], nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var i, size = binary.Uvarint(b)
+		if size <= 0 {
+			return nil, errShortCompact
+		}
+		if v.OverflowUint(i) {
+			return nil, fmt.Errorf("Value %d overflows %s", i, v.Type().String())
+		}
+		v.SetUint(i)
+		return b[size:], nil
+	case reflect.Float32:
+		if len(b) < 4 {
+			return nil, errShortCompact
+		}
+		v.SetFloat(float64(math.Float32frombits(binary.LittleEndian.Uint32(b))))
+		return b[4:], nil
+	case reflect.Float64:
+		if len(b) < 8 {
+			return nil, errShortCompact
+		}
+		v.SetFloat(math.Float64frombits(binary.LittleEndian.Uint64(b)))
+		return b[8:], nil
+	case reflect.String:
+		var n int
+		if n, b, err = readCompactLength(b); err != nil {
+			return nil, err
+		}
+		v.SetString(string(b[:n]))
+		return b[n:], nil
+	case reflect.Slice:
+		var n, size = binary.Uvarint(b)
+		if size <= 0 || n > uint64(len(b)-size) {
+			// Every element takes at least one byte, so the length cannot exceed the remaining data.
+			return nil, errShortCompact
+		}
+		b = b[size:]
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes(append([]byte(nil), b[:n]...))
+			return b[n:], nil
+		}
+		v.Set(reflect.MakeSlice(v.Type(), int(n), int(n)))
+		for i := 0; i < int(n); i++ {
+			if b, err = readCompact(b, v.Index(i)); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case reflect.Array:
+		var n, size = binary.Uvarint(b)
+		if size <= 0 {
+			return nil, errShortCompact
+		}
+		if n != uint64(v.Len()) {
+			return nil, fmt.Errorf("Expected %d elements for %s, got %d", v.Len(), v.Type().String(), n)
+		}
+		b = b[size:]
+		for i := 0; i < v.Len(); i++ {
+			if b, err = readCompact(b, v.Index(i)); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case reflect.Map:
+		var n, size = binary.Uvarint(b)
+		if size <= 0 || n > uint64(len(b)-size) {
+			return nil, errShortCompact
+		}
+		b = b[size:]
+		v.Set(reflect.MakeMapWithSize(v.Type(), int(n)))
+		for i := 0; i < int(n); i++ {
+			var key = reflect.New(v.Type().Key()).Elem()
+			if b, err = readCompact(b, key); err != nil {
+				return nil, err
+			}
+			var elem = reflect.New(v.Type().Elem()).Elem()
+			if b, err = readCompact(b, elem); err != nil {
+				return nil, err
+			}
+			v.SetMapIndex(key, elem)
+		}
+		return b, nil
+	case reflect.Ptr:
+		if len(b) < 1 {
+			return nil, errShortCompact
+		}
+		if b[0] == 0 {
+			v.SetZero()
+			return b[1:], nil
+		}
+		v.Set(reflect.New(v.Type().Elem()))
+		return readCompact(b[1:], v.Elem())
+	case reflect.Struct:
+		if err = checkCompactStruct(v.Type()); err != nil {
+			return nil, err
+		}
+		var size = (v.NumField() + 7) / 8
+		if len(b) < size {
+			return nil, errShortCompact
+		}
+		var bitmap = b[:size]
+		b = b[size:]
+		for i := 0; i < v.NumField(); i++ {
+			if bitmap[i/8]&(1<<(i%8)) == 0 {
+				continue
+			}
+			if b, err = readCompact(b, v.Field(i)); err != nil {
+				return nil, fmt.Errorf("%s: %s", v.Type().Field(i).Name, err)
+			}
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("Cannot decode value of type %s", v.Type().String())
+}
+
+// EncodeDelta encodes the fields which differ between prev and curr, in the format of MarshalCompact.
+//
+// The data starts with a bitmap holding a bit for every changed field, followed by the new values of those fields.
+// Unlike MarshalCompact, changed fields are also encoded when their new value is zero.
+//
+// It will panic if either struct has not been made, or the structs are of different types.
+func EncodeDelta(prev, curr *Struct) ([]byte, error) {
+	prev.checkMade("Cannot encode delta if struct has not been made")
+	curr.checkMade("Cannot encode delta if struct has not been made")
+	if prev.sstruct != curr.sstruct {
+		panic("Cannot encode delta between structs of different types")
+	}
+	var prevValue, err = prev.withChecksums(prev.readableOrZero())
+	if err != nil {
+		return nil, err
+	}
+	currValue, err := curr.withChecksums(curr.readableOrZero())
+	if err != nil {
+		return nil, err
+	}
+
+	var n = curr.sstruct.NumField()
+	var bitmap = make([]byte, (n+7)/8)
+	for i := 0; i < n; i++ {
+		if !reflect.DeepEqual(prevValue.Field(i).Interface(), currValue.Field(i).Interface()) {
+			bitmap[i/8] |= 1 << (i % 8)
+		}
+	}
+
+	var b = append([]byte(nil), bitmap...)
+	for i := 0; i < n; i++ {
+		if bitmap[i/8]&(1<<(i%8)) == 0 {
+			continue
+		}
+		if b, err = appendCompact(b, currValue.Field(i)); err != nil {
+			return nil, fmt.Errorf("%s: %s", curr.sstruct.Field(i).Name, err)
+		}
+	}
+	return b, nil
+}
+
+// ApplyDelta sets the fields held by a delta written by EncodeDelta.
+//
+// The delta is decoded fully before any field is set, and the fields are set through SetField,
+// so the struct is left unchanged if the delta is invalid or any field cannot be set.
+//
+// It will panic if the struct has not been made.
+func ApplyDelta(prev *Struct, delta []byte) error {
+	prev.checkMade("Cannot apply delta if struct has not been made")
+	var n = prev.sstruct.NumField()
+	var size = (n + 7) / 8
+	if len(delta) < size {
+		return errShortCompact
+	}
+	var bitmap = delta[:size]
+	var b = delta[size:]
+
+	var value = prev.decodeTarget()
+	var err error
+	for i := 0; i < n; i++ {
+		if bitmap[i/8]&(1<<(i%8)) == 0 {
+			continue
+		}
+		var field = reflect.New(prev.sstruct.Field(i).Type).Elem()
+		if b, err = readCompact(b, field); err != nil {
+			return fmt.Errorf("%s: %s", prev.sstruct.Field(i).Name, err)
+		}
+		value.Field(i).Set(field)
+	}
+	if len(b) > 0 {
+		return fmt.Errorf("Unexpected %d trailing bytes in delta", len(b))
+	}
+	return prev.setDecoded(value)
+}
@@ -0,0 +1,185 @@
+// Package compression compresses the encoded form of a structs.Struct,
+// and detects the algorithm from the magic number of the compressed data when decoding.
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Nigel2392/go-structs"
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Algorithm is a compression algorithm.
+type Algorithm int
+
+const (
+	// None leaves the data uncompressed.
+	None Algorithm = iota
+	Gzip
+	Zstd
+	// Snappy uses the snappy framing format, so the data starts with a stream identifier.
+	Snappy
+)
+
+func (a Algorithm) String() string {
+	switch a {
+	case None:
+		return "none"
+	case Gzip:
+		return "gzip"
+	case Zstd:
+		return "zstd"
+	case Snappy:
+		return "snappy"
+	}
+	return fmt.Sprintf("Algorithm(%d)", int(a))
+}
+
+// DefaultMaxSize is the maximum size of decompressed data if no maximum is given, 64 MiB.
+const DefaultMaxSize = 64 << 20
+
+var (
+	gzipMagic   = []byte{0x1f, 0x8b}
+	zstdMagic   = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	snappyMagic = []byte{0xff, 0x06, 0x00, 0x00, 0x73, 0x4e, 0x61, 0x50, 0x70, 0x59}
+)
+
+// ErrUnknownAlgorithm is returned when compressing with an algorithm which is not supported.
+var ErrUnknownAlgorithm = errors.New("Unknown compression algorithm")
+
+// Format is an encoding of a struct.
+type Format struct {
+	Marshal   func(s *structs.Struct) ([]byte, error)
+	Unmarshal func(s *structs.Struct, data []byte) error
+}
+
+var (
+	// JSON encodes the struct with MarshalJSON.
+	JSON = Format{
+		Marshal:   (*structs.Struct).MarshalJSON,
+		Unmarshal: (*structs.Struct).UnmarshalJSON,
+	}
+
+	// Compact encodes the struct with structs.MarshalCompact.
+	Compact = Format{
+		Marshal:   structs.MarshalCompact,
+		Unmarshal: structs.UnmarshalCompact,
+	}
+)
+
+// Detect returns the algorithm the data was compressed with, based on its magic number.
+//
+// Data without a known magic number is reported as None. Uncompressed data which happens to start with a magic number
+// is reported as compressed: JSON never does, but compact data may, so compact data should always be compressed.
+func Detect(data []byte) Algorithm {
+	switch {
+	case bytes.HasPrefix(data, gzipMagic):
+		return Gzip
+	case bytes.HasPrefix(data, zstdMagic):
+		return Zstd
+	case bytes.HasPrefix(data, snappyMagic):
+		return Snappy
+	}
+	return None
+}
+
+// Compress compresses the data with the algorithm, the data is returned as-is for None.
+func Compress(data []byte, algo Algorithm) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	var err error
+	switch algo {
+	case None:
+		return data, nil
+	case Gzip:
+		w = gzip.NewWriter(&buf)
+	case Zstd:
+		if w, err = zstd.NewWriter(&buf); err != nil {
+			return nil, err
+		}
+	case Snappy:
+		w = snappy.NewBufferedWriter(&buf)
+	default:
+		return nil, ErrUnknownAlgorithm
+	}
+	if _, err = w.Write(data); err != nil {
+		w.Close()
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress decompresses the data, detecting the algorithm with Detect.
+//
+// Data without a known magic number is returned as-is.
+// An error is returned if the decompressed data is larger than maxSize bytes,
+// the memory used by the zstd decoder is limited to the same size.
+// If maxSize is 0 or less, DefaultMaxSize is used.
+func Decompress(data []byte, maxSize int64) ([]byte, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	var payload = bytes.NewReader(data)
+	var r io.Reader
+	var err error
+	switch Detect(data) {
+	case None:
+		r = payload
+	case Gzip:
+		var gr *gzip.Reader
+		if gr, err = gzip.NewReader(payload); err != nil {
+			return nil, err
+		}
+		defer gr.Close()
+		r = gr
+	case Zstd:
+		var zr *zstd.Decoder
+		if zr, err = zstd.NewReader(payload, zstd.WithDecoderMaxMemory(uint64(maxSize))); err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	case Snappy:
+		r = snappy.NewReader(payload)
+	}
+	decompressed, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(decompressed)) > maxSize {
+		return nil, fmt.Errorf("Decompressed data exceeds the maximum size of %d bytes", maxSize)
+	}
+	return decompressed, nil
+}
+
+// MarshalCompressed encodes the struct in the format, and compresses the result with the algorithm.
+//
+// It will panic if the struct has not been made.
+func MarshalCompressed(s *structs.Struct, format Format, algo Algorithm) ([]byte, error) {
+	var data, err = format.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return Compress(data, algo)
+}
+
+// UnmarshalCompressed decompresses the data, detecting the algorithm from its magic number, and decodes it in the format.
+//
+// The decompressed data may be at most maxSize bytes, see Decompress.
+//
+// It will panic if the struct has not been made.
+func UnmarshalCompressed(s *structs.Struct, format Format, data []byte, maxSize int64) error {
+	var decompressed, err = Decompress(data, maxSize)
+	if err != nil {
+		return err
+	}
+	return format.Unmarshal(s, decompressed)
+}
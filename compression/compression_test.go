@@ -0,0 +1,101 @@
+package compression_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Nigel2392/go-structs"
+	"github.com/Nigel2392/go-structs/compression"
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestRoundTrip(t *testing.T) {
+	var s = structs.New("json")
+	s.StringField("Body", "body")
+	s.IntField("Size", "size")
+	s.Make()
+	s.SetField("Body", strings.Repeat("payload ", 512))
+	s.SetField("Size", 4096)
+
+	for _, algo := range []compression.Algorithm{compression.None, compression.Gzip, compression.Zstd, compression.Snappy} {
+		for _, format := range []compression.Format{compression.JSON, compression.Compact} {
+			var data, err = compression.MarshalCompressed(s, format, algo)
+			if err != nil {
+				t.Fatalf("%s: %s", algo, err)
+			}
+			if detected := compression.Detect(data); detected != algo {
+				t.Errorf("Expected %s to be detected, got %s", algo, detected)
+			}
+
+			var decoded = s.DeepCopy()
+			decoded.Make()
+			if err = compression.UnmarshalCompressed(decoded, format, data, 0); err != nil {
+				t.Fatalf("%s: %s", algo, err)
+			}
+			if !reflect.DeepEqual(decoded.Interface(), s.Interface()) {
+				t.Errorf("%s: values differ after round trip", algo)
+			}
+		}
+	}
+}
+
+func TestDecompressLimits(t *testing.T) {
+	var payload = []byte(strings.Repeat("a", 4096))
+	for _, algo := range []compression.Algorithm{compression.None, compression.Gzip, compression.Zstd, compression.Snappy} {
+		var data, err = compression.Compress(payload, algo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = compression.Decompress(data, 1024); err == nil {
+			t.Errorf("%s: Expected error decompressing more than the maximum size", algo)
+		}
+		decompressed, err := compression.Decompress(data, 4096)
+		if err != nil || !bytes.Equal(decompressed, payload) {
+			t.Errorf("%s: Expected payload of the maximum size to decompress, got %v", algo, err)
+		}
+	}
+
+	if _, err := compression.Compress(payload, compression.Algorithm(9)); err != compression.ErrUnknownAlgorithm {
+		t.Errorf("Expected %v, got %v", compression.ErrUnknownAlgorithm, err)
+	}
+}
+
+func TestDetectForeignData(t *testing.T) {
+	var payload = []byte(`{"body":"compressed elsewhere"}`)
+
+	var buf bytes.Buffer
+	var w = gzip.NewWriter(&buf)
+	w.Write(payload)
+	w.Close()
+	zw, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = map[compression.Algorithm][]byte{
+		compression.Gzip:   buf.Bytes(),
+		compression.Zstd:   zw.EncodeAll(payload, nil),
+		compression.Snappy: snappyFramed(payload),
+		compression.None:   payload,
+	}
+	for algo, data := range tests {
+		if detected := compression.Detect(data); detected != algo {
+			t.Errorf("Expected %s to be detected, got %s", algo, detected)
+		}
+		decompressed, err := compression.Decompress(data, 0)
+		if err != nil || !bytes.Equal(decompressed, payload) {
+			t.Errorf("%s: Expected %q, got %q %v", algo, payload, decompressed, err)
+		}
+	}
+}
+
+func snappyFramed(data []byte) []byte {
+	var buf bytes.Buffer
+	var w = snappy.NewBufferedWriter(&buf)
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
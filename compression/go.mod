@@ -0,0 +1,8 @@
+module github.com/Nigel2392/go-structs/compression
+
+go 1.22
+
+require (
+	github.com/Nigel2392/go-structs v0.1.0
+	github.com/klauspost/compress v1.18.0
+)
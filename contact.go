@@ -0,0 +1,152 @@
+package structs
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strings"
+)
+
+// Normalizer is implemented by field types which validate and normalize their value when it is set.
+//
+// SetField and SetFieldByIndex call Normalize after the value has been set.
+type Normalizer interface {
+	Normalize() error
+}
+
+// Email is an email address, normalized to lowercase without surrounding whitespace.
+type Email string
+
+// ParseEmail validates and normalizes the email address.
+//
+// Display names are not allowed, I.E. "Nigel <nigel@example.com>" is rejected, as is an empty address.
+func ParseEmail(s string) (Email, error) {
+	if strings.TrimSpace(s) == "" {
+		return "", fmt.Errorf("Invalid email address %q", s)
+	}
+	var e = Email(s)
+	return e, e.Normalize()
+}
+
+// Normalize validates the email address, and lowercases and trims it in place.
+//
+// An empty address is left empty, use `structs:"required"` to reject it.
+func (e *Email) Normalize() error {
+	var s = strings.ToLower(strings.TrimSpace(string(*e)))
+	if s == "" {
+		*e = ""
+		return nil
+	}
+	var addr, err = mail.ParseAddress(s)
+	if err != nil || addr.Address != s || addr.Name != "" {
+		return fmt.Errorf("Invalid email address %q", string(*e))
+	}
+	*e = Email(s)
+	return nil
+}
+
+func (e *Email) UnmarshalText(text []byte) error {
+	var email = Email(text)
+	if err := email.Normalize(); err != nil {
+		return err
+	}
+	*e = email
+	return nil
+}
+
+// Phone is a phone number in E.164 format, I.E. "+31612345678".
+type Phone string
+
+// ParsePhone validates the phone number and normalizes it to E.164.
+//
+// Spaces, dashes, dots and parentheses are removed, and a leading "00" is replaced with "+".
+//
+// National numbers, starting with a single 0 or without any prefix, are prefixed with the country calling code.
+// If the country calling code is empty, only international numbers are accepted.
+func ParsePhone(s, countryCode string) (Phone, error) {
+	var digits = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')', '\t':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(s))
+
+	switch {
+	case strings.HasPrefix(digits, "+"):
+	case strings.HasPrefix(digits, "00"):
+		digits = "+" + digits[2:]
+	case countryCode != "":
+		digits = "+" + strings.TrimPrefix(countryCode, "+") + strings.TrimPrefix(digits, "0")
+	default:
+		return "", fmt.Errorf("Phone number %q is not in international format", s)
+	}
+
+	var number = digits[1:]
+	if len(number) < 8 || len(number) > 15 || number[0] == '0' {
+		return "", fmt.Errorf("Invalid phone number %q", s)
+	}
+	for _, r := range number {
+		if r < '0' || r > '9' {
+			return "", fmt.Errorf("Invalid phone number %q", s)
+		}
+	}
+	return Phone(digits), nil
+}
+
+// Normalize validates the phone number, and normalizes it to E.164 in place.
+//
+// The number must be in international format, see ParsePhone.
+// An empty number is left empty, use `structs:"required"` to reject it.
+func (p *Phone) Normalize() error {
+	if strings.TrimSpace(string(*p)) == "" {
+		*p = ""
+		return nil
+	}
+	var phone, err = ParsePhone(string(*p), "")
+	if err != nil {
+		return err
+	}
+	*p = phone
+	return nil
+}
+
+func (p *Phone) UnmarshalText(text []byte) error {
+	var phone = Phone(text)
+	if err := phone.Normalize(); err != nil {
+		return err
+	}
+	*p = phone
+	return nil
+}
+
+var (
+	emailType = reflect.TypeOf(Email(""))
+	phoneType = reflect.TypeOf(Phone(""))
+)
+
+// EmailField adds a field of type Email, which is normalized when it is set.
+func (s *Struct) EmailField(absolute_name, name string, required ...bool) {
+	s.AddField(absolute_name, name, emailType, required...)
+}
+
+// PhoneField adds a field of type Phone, which is normalized to E.164 when it is set.
+func (s *Struct) PhoneField(absolute_name, name string, required ...bool) {
+	s.AddField(absolute_name, name, phoneType, required...)
+}
+
+var normalizerType = reflect.TypeOf((*Normalizer)(nil)).Elem()
+
+// normalize calls Normalize on the field if its type implements Normalizer.
+//
+// The previous value is restored if normalization fails.
+func normalize(field, previous reflect.Value) error {
+	if !field.CanAddr() || !field.Addr().Type().Implements(normalizerType) {
+		return nil
+	}
+	if err := field.Addr().Interface().(Normalizer).Normalize(); err != nil {
+		field.Set(previous)
+		return err
+	}
+	return nil
+}
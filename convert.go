@@ -0,0 +1,134 @@
+package structs
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	durationType        = reflect.TypeOf(time.Duration(0))
+)
+
+// encName returns the encoding name of the field for the given tag.
+//
+// Options after a comma are stripped, and the field name is used if the tag is empty.
+func encName(field reflect.StructField, tag string) string {
+	var name = field.Tag.Get(tag)
+	if idx := strings.Index(name, ","); idx >= 0 {
+		name = name[:idx]
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// isTextType reports whether values of the type are converted to and from a single string.
+func isTextType(typ reflect.Type) bool {
+	if typ.Implements(textMarshalerType) || reflect.PtrTo(typ).Implements(textUnmarshalerType) {
+		return true
+	}
+	return typ.Kind() != reflect.Struct
+}
+
+// formatValue converts the value to a string.
+//
+// Text marshalers are used when implemented, scalars are formatted with strconv,
+// and all other values are encoded as JSON.
+func formatValue(v reflect.Value) (string, error) {
+	if v.Type().Implements(textMarshalerType) {
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return "", nil
+		}
+		var b, err = v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String(), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	case reflect.Ptr:
+		if v.IsNil() {
+			return "", nil
+		}
+		return formatValue(v.Elem())
+	}
+	var b, err = json.Marshal(v.Interface())
+	return string(b), err
+}
+
+// parseValue parses the string into a new value of the given type.
+//
+// It is the inverse of formatValue.
+func parseValue(s string, typ reflect.Type) (reflect.Value, error) {
+	var v = reflect.New(typ).Elem()
+	if reflect.PtrTo(typ).Implements(textUnmarshalerType) {
+		var err = v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+		return v, err
+	}
+	if typ == durationType {
+		var d, err = time.ParseDuration(s)
+		v.SetInt(int64(d))
+		return v, err
+	}
+	switch typ.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		var b, err = strconv.ParseBool(s)
+		if err != nil {
+			return v, err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i, err = strconv.ParseInt(s, 10, typ.Bits())
+		if err != nil {
+			return v, err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var i, err = strconv.ParseUint(s, 10, typ.Bits())
+		if err != nil {
+			return v, err
+		}
+		v.SetUint(i)
+	case reflect.Float32, reflect.Float64:
+		var f, err = strconv.ParseFloat(s, typ.Bits())
+		if err != nil {
+			return v, err
+		}
+		v.SetFloat(f)
+	case reflect.Ptr:
+		if s == "" {
+			return v, nil
+		}
+		var elem, err = parseValue(s, typ.Elem())
+		if err != nil {
+			return v, err
+		}
+		v.Set(reflect.New(typ.Elem()))
+		v.Elem().Set(elem)
+	default:
+		if err := json.Unmarshal([]byte(s), v.Addr().Interface()); err != nil {
+			return v, fmt.Errorf("Cannot parse %q into type %s: %s", s, typ.String(), err)
+		}
+	}
+	return v, nil
+}
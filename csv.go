@@ -0,0 +1,199 @@
+package structs
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	int64Type   = reflect.TypeOf(int64(0))
+	float64Type = reflect.TypeOf(float64(0))
+	boolType    = reflect.TypeOf(false)
+	stringType  = reflect.TypeOf("")
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+type csvOptions struct {
+	tag     string
+	comma   rune
+	samples int
+}
+
+// CSVOption configures FromCSV and NewCSVDecoder.
+type CSVOption func(*csvOptions)
+
+// CSVTag sets the tag holding the column names, defaults to "csv".
+func CSVTag(tag string) CSVOption {
+	return func(o *csvOptions) {
+		o.tag = tag
+	}
+}
+
+// CSVComma sets the field delimiter, defaults to ','.
+func CSVComma(comma rune) CSVOption {
+	return func(o *csvOptions) {
+		o.comma = comma
+	}
+}
+
+// CSVSampleSize sets the amount of rows FromCSV reads to infer the column types, defaults to 100.
+func CSVSampleSize(n int) CSVOption {
+	return func(o *csvOptions) {
+		o.samples = n
+	}
+}
+
+func newCSVReader(r io.Reader, opts []CSVOption) (*csv.Reader, csvOptions) {
+	var o = csvOptions{tag: "csv", comma: ',', samples: 100}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var reader = csv.NewReader(r)
+	reader.Comma = o.comma
+	reader.ReuseRecord = true
+	return reader, o
+}
+
+// FromCSV creates a struct with a field for every column in the header row
+//
+// The field names are the column names converted to exported Go identifiers, the column names are used as encoding names.
+// The types are inferred from the sampled rows, empty cells are ignored:
+// columns holding only integers become int64, numbers become float64, true or false becomes bool,
+// RFC 3339 timestamps become time.Time, and all other columns become string.
+//
+// The sampled rows are consumed, use NewCSVDecoder on a fresh reader to decode the rows.
+func FromCSV(r io.Reader, opts ...CSVOption) (*Struct, error) {
+	var reader, o = newCSVReader(r, opts)
+	var header, err = reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	header = append([]string(nil), header...)
+
+	var types = make([]reflect.Type, len(header))
+	for i := 0; i < o.samples; i++ {
+		var record, err = reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		for j, cell := range record {
+			if j < len(types) && cell != "" {
+				types[j] = widenCSVType(types[j], cell)
+			}
+		}
+	}
+
+	var s = New(o.tag)
+	var names = make(map[string]bool, len(header))
+	for i, column := range header {
+		var typ = types[i]
+		if typ == nil {
+			typ = stringType
+		}
+		s.AddStructField(reflect.StructField{
+			Name: identifier(column, names),
+			Type: typ,
+			Tag:  formatTag([]tagPair{{key: o.tag, value: column}}),
+		})
+	}
+	return s, nil
+}
+
+// widenCSVType returns the narrowest type holding both the previous type and the cell.
+func widenCSVType(previous reflect.Type, cell string) reflect.Type {
+	var typ = csvCellType(cell)
+	switch {
+	case previous == nil || previous == typ:
+		return typ
+	case previous == int64Type && typ == float64Type, previous == float64Type && typ == int64Type:
+		return float64Type
+	}
+	return stringType
+}
+
+func csvCellType(cell string) reflect.Type {
+	if _, err := strconv.ParseInt(cell, 10, 64); err == nil {
+		return int64Type
+	}
+	if _, err := strconv.ParseFloat(cell, 64); err == nil {
+		return float64Type
+	}
+	if strings.EqualFold(cell, "true") || strings.EqualFold(cell, "false") {
+		return boolType
+	}
+	if _, err := time.Parse(time.RFC3339, cell); err == nil {
+		return timeType
+	}
+	return stringType
+}
+
+// CSVDecoder decodes the rows of a CSV file into a struct.
+type CSVDecoder struct {
+	s       *Struct
+	reader  *csv.Reader
+	columns []int
+	row     int
+}
+
+// NewCSVDecoder reads the header row, and maps the columns to the fields of the struct by their encoding name.
+//
+// Columns without a matching field are skipped.
+//
+// It will panic if the struct has not been made.
+func NewCSVDecoder(r io.Reader, s *Struct, opts ...CSVOption) (*CSVDecoder, error) {
+	s.checkMade("Cannot decode if struct has not been made")
+	var reader, _ = newCSVReader(r, opts)
+	var header, err = reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	var fields = make(map[string]int, s.sstruct.NumField())
+	for i := 0; i < s.sstruct.NumField(); i++ {
+		fields[encName(s.sstruct.Field(i), s.tag)] = i
+	}
+	var columns = make([]int, len(header))
+	for i, column := range header {
+		var index, ok = fields[column]
+		if !ok {
+			index = -1
+		}
+		columns[i] = index
+	}
+	return &CSVDecoder{s: s, reader: reader, columns: columns, row: 1}, nil
+}
+
+// Decode reads the next row into the struct.
+//
+// Empty cells set the field to its zero value. Changed fields are set through SetField,
+// if any field cannot be parsed or set an error is returned and the struct is left unchanged.
+// It returns io.EOF when there are no more rows.
+func (d *CSVDecoder) Decode() error {
+	var record, err = d.reader.Read()
+	if err != nil {
+		return err
+	}
+	d.row++
+
+	var value = reflect.New(d.s.sstruct).Elem()
+	for i, cell := range record {
+		if i >= len(d.columns) || d.columns[i] < 0 || cell == "" {
+			continue
+		}
+		var field = value.Field(d.columns[i])
+		var parsed, err = parseValue(cell, field.Type())
+		if err != nil {
+			return fmt.Errorf("Row %d, column %d: %s", d.row, i+1, err)
+		}
+		field.Set(parsed)
+	}
+	return d.s.setDecoded(value)
+}
@@ -0,0 +1,131 @@
+package structs
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type sqlDialect struct {
+	quote   func(name string) string
+	boolean string
+	small   string
+	integer string
+	big     string
+	ubig    string
+	real    string
+	double  string
+	text    string
+	bytes   string
+	time    string
+	json    string
+}
+
+var sqlDialects = map[string]sqlDialect{
+	"postgres": {
+		quote:   func(name string) string { return `"` + strings.ReplaceAll(name, `"`, `""`) + `"` },
+		boolean: "BOOLEAN", small: "SMALLINT", integer: "INTEGER", big: "BIGINT", ubig: "NUMERIC(20, 0)",
+		real: "REAL", double: "DOUBLE PRECISION", text: "TEXT", bytes: "BYTEA", time: "TIMESTAMPTZ", json: "JSONB",
+	},
+	"mysql": {
+		quote:   func(name string) string { return "`" + strings.ReplaceAll(name, "`", "``") + "`" },
+		boolean: "BOOLEAN", small: "SMALLINT", integer: "INT", big: "BIGINT", ubig: "BIGINT UNSIGNED",
+		real: "FLOAT", double: "DOUBLE", text: "TEXT", bytes: "LONGBLOB", time: "DATETIME(6)", json: "JSON",
+	},
+	"sqlite": {
+		quote:   func(name string) string { return `"` + strings.ReplaceAll(name, `"`, `""`) + `"` },
+		boolean: "BOOLEAN", small: "INTEGER", integer: "INTEGER", big: "INTEGER", ubig: "INTEGER",
+		real: "REAL", double: "REAL", text: "TEXT", bytes: "BLOB", time: "DATETIME", json: "TEXT",
+	},
+}
+
+// DDL returns a CREATE TABLE statement for the fields of the struct, for the postgres, mysql or sqlite dialect
+//
+// Column names are taken from the db tag, or else the field names in snake case, fields tagged `db:"-"` are skipped.
+// Fields marked with `structs:"required"` are NOT NULL.
+//
+// Integers and floats map to the smallest matching column type, time.Time to a timestamp, []byte to a binary column,
+// and strings and types implementing encoding.TextMarshaler to text.
+// Slices, maps, structs and interface{} are stored as JSON. Pointers map to the type they point to.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) DDL(dialect, tableName string) (string, error) {
+	s.checkMade("Cannot create DDL if struct has not been made")
+	var d, ok = sqlDialects[dialect]
+	if !ok {
+		return "", fmt.Errorf("Unknown SQL dialect %s", dialect)
+	}
+
+	var columns = make([]string, 0, s.sstruct.NumField())
+	for i := 0; i < s.sstruct.NumField(); i++ {
+		var field = s.sstruct.Field(i)
+		var column, ok = sqlColumn(field)
+		if !ok {
+			continue
+		}
+		var columnType, err = d.columnType(field.Type)
+		if err != nil {
+			return "", fmt.Errorf("%s: %s", field.Name, err)
+		}
+		var definition = d.quote(column) + " " + columnType
+		if IsRequired(field) {
+			definition += " NOT NULL"
+		}
+		columns = append(columns, definition)
+	}
+	return fmt.Sprintf("CREATE TABLE %s (\n\t%s\n);", d.quote(tableName), strings.Join(columns, ",\n\t")), nil
+}
+
+// sqlColumn returns the column name of the field, from the db tag or else the field name in snake case.
+//
+// It returns false if the field is tagged `db:"-"`.
+func sqlColumn(field reflect.StructField) (string, bool) {
+	var column, _, _ = strings.Cut(field.Tag.Get("db"), ",")
+	if column == "-" {
+		return "", false
+	}
+	if column == "" {
+		column = snakeCase(field.Name)
+	}
+	return column, true
+}
+
+func (d sqlDialect) columnType(typ reflect.Type) (string, error) {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch {
+	case typ == timeType:
+		return d.time, nil
+	case typ == durationType:
+		return d.big, nil
+	case typ.Implements(textMarshalerType) || reflect.PtrTo(typ).Implements(textMarshalerType):
+		return d.text, nil
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		return d.boolean, nil
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		return d.small, nil
+	case reflect.Int32, reflect.Uint16:
+		return d.integer, nil
+	case reflect.Int, reflect.Int64, reflect.Uint32:
+		return d.big, nil
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		return d.ubig, nil
+	case reflect.Float32:
+		return d.real, nil
+	case reflect.Float64:
+		return d.double, nil
+	case reflect.String:
+		return d.text, nil
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return d.bytes, nil
+		}
+		return d.json, nil
+	case reflect.Array, reflect.Map, reflect.Struct, reflect.Interface:
+		return d.json, nil
+	}
+	return "", fmt.Errorf("Cannot store type %s in a column", typ.String())
+}
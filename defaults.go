@@ -0,0 +1,98 @@
+package structs
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// structsOptions returns the options in the structs tag of the field, and the value of the default option.
+//
+// Options are separated by commas. A default value containing commas must be quoted with single quotes,
+// in which a single quote is written as two, I.E. `structs:"default='[1,2]',required"`.
+func structsOptions(field reflect.StructField) (options []string, defaultValue string, hasDefault bool, err error) {
+	var tag = field.Tag.Get("structs")
+	for tag != "" {
+		if value, ok := strings.CutPrefix(tag, "default='"); ok {
+			var b strings.Builder
+			for {
+				var end = strings.IndexByte(value, '\'')
+				if end < 0 {
+					return options, "", false, fmt.Errorf("Unterminated quote in default value of field %s", field.Name)
+				}
+				b.WriteString(value[:end])
+				value = value[end+1:]
+				if !strings.HasPrefix(value, "'") {
+					break
+				}
+				b.WriteByte('\'')
+				value = value[1:]
+			}
+			if value != "" && value[0] != ',' {
+				return options, "", false, fmt.Errorf("Unexpected %q after the default value of field %s", value, field.Name)
+			}
+			defaultValue, hasDefault = b.String(), true
+			tag = strings.TrimPrefix(value, ",")
+			continue
+		}
+		var option string
+		option, tag, _ = strings.Cut(tag, ",")
+		if value, ok := strings.CutPrefix(option, "default="); ok {
+			defaultValue, hasDefault = value, true
+			continue
+		}
+		options = append(options, option)
+	}
+	return options, defaultValue, hasDefault, nil
+}
+
+// hasStructsOption reports whether the structs tag of the field holds the option.
+func hasStructsOption(field reflect.StructField, option string) bool {
+	var options, _, _, _ = structsOptions(field)
+	for _, o := range options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultValue returns the default value of the field, set with the "default=" option in the structs tag,
+// I.E. `structs:"required,default=42"`.
+//
+// A default value containing commas must be quoted with single quotes, I.E. `structs:"default='[1,2]',required"`.
+// The field has no default value if the quoted value is malformed, ApplyDefaults returns an error in that case.
+func DefaultValue(field reflect.StructField) (string, bool) {
+	var _, value, ok, err = structsOptions(field)
+	return value, ok && err == nil
+}
+
+// ApplyDefaults sets every field which holds its zero value and has a default value to its default value
+//
+// Default values are parsed like values from key/value stores: scalars with strconv, types implementing
+// encoding.TextUnmarshaler with UnmarshalText, and all other types as JSON.
+//
+// The defaults are set through SetField. If a default value cannot be parsed or set, an error is returned
+// and the struct is left unchanged.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) ApplyDefaults() error {
+	s.checkMade("Cannot apply defaults if struct has not been made")
+	var v = s.decodeTarget()
+	for i := 0; i < s.sstruct.NumField(); i++ {
+		var field = s.sstruct.Field(i)
+		var _, def, ok, err = structsOptions(field)
+		if err != nil {
+			return err
+		}
+		if !ok || !s.structValue.Field(i).IsZero() {
+			continue
+		}
+		value, err := parseValue(def, field.Type)
+		if err != nil {
+			return fmt.Errorf("Invalid default value for field %s: %s", field.Name, err)
+		}
+		v.Field(i).Set(value)
+	}
+	return s.setDecoded(v)
+}
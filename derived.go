@@ -0,0 +1,167 @@
+package structs
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Transformer derives the value of a field from the value of its source field.
+type Transformer func(source string) string
+
+type derived struct {
+	field     string
+	source    string
+	transform Transformer
+}
+
+// DerivedField adds a string field whose value is derived from the source string field.
+//
+// The value is recomputed whenever the source field is set through SetField or SetFieldByIndex.
+// Decoders like UnmarshalJSON, SetFromMap and ApplyMergePatch ignore values for the derived field.
+// Make will panic if the source field does not exist, or is not of a string kind.
+//
+// See Slug and NormalizedKey for built-in transformers.
+func (s *Struct) DerivedField(absolute_name, name, source string, transform Transformer) {
+	if transform == nil {
+		panic(fmt.Sprintf("Derived field %s must have a transformer", absolute_name))
+	}
+	s.StringField(absolute_name, name)
+	s.derived = append(s.derived, derived{
+		field:     absolute_name,
+		source:    source,
+		transform: transform,
+	})
+}
+
+// checkDerived panics if the source of a derived field does not exist, or is not of a string kind.
+func (s *Struct) checkDerived() {
+	for _, d := range s.derived {
+		var field, ok = s.sstruct.FieldByName(d.source)
+		if !ok {
+			panic(fmt.Sprintf("Source field %s of derived field %s does not exist", d.source, d.field))
+		}
+		if field.Type.Kind() != reflect.String {
+			panic(fmt.Sprintf("Source field %s of derived field %s must be a string, got %s", d.source, d.field, field.Type.String()))
+		}
+	}
+}
+
+// isDerived reports whether the field is derived from another field.
+func (s *Struct) isDerived(name string) bool {
+	for _, d := range s.derived {
+		if d.field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// updateDerived recomputes all fields derived from the source field.
+//
+// The source is a string, as checked by checkDerived when the struct was made.
+func (s *Struct) updateDerived(source string) {
+	for _, d := range s.derived {
+		if d.source != source {
+			continue
+		}
+		var src = s.structValue.FieldByName(d.source)
+		s.structValue.FieldByName(d.field).SetString(d.transform(src.String()))
+	}
+}
+
+// SlugOptions configures the Slug transformer.
+type SlugOptions struct {
+	// The separator placed between words, defaults to "-".
+	Separator string
+
+	// Keep the case of the source, instead of lowercasing it.
+	KeepCase bool
+
+	// Transliterations applied before any other processing, I.E. {'ø': "o"}.
+	//
+	// These take precedence over the built-in transliterations of common latin characters.
+	Transliterate map[rune]string
+
+	// The maximum length of the slug in bytes, 0 means no limit.
+	MaxLength int
+}
+
+// Slug returns a transformer which turns the source into a URL slug, I.E. "Héllo, World!" becomes "hello-world".
+func Slug(opts SlugOptions) Transformer {
+	if opts.Separator == "" {
+		opts.Separator = "-"
+	}
+	return func(source string) string {
+		var words = splitWords(transliterate(source, opts.Transliterate))
+		var slug = strings.Join(words, opts.Separator)
+		if !opts.KeepCase {
+			slug = strings.ToLower(slug)
+		}
+		if opts.MaxLength > 0 && len(slug) > opts.MaxLength {
+			// Cut on a rune boundary, letters without a transliteration are kept as multi-byte characters.
+			var n = opts.MaxLength
+			for n > 0 && !utf8.RuneStart(slug[n]) {
+				n--
+			}
+			// Drop a separator which was cut in half, as well as whole separators at the end.
+			for i := len(opts.Separator) - 1; i > 0; i-- {
+				if strings.HasSuffix(slug[:n], opts.Separator[:i]) && strings.HasPrefix(slug[n-i:], opts.Separator) {
+					n -= i
+					break
+				}
+			}
+			slug = slug[:n]
+			for strings.HasSuffix(slug, opts.Separator) {
+				slug = strings.TrimSuffix(slug, opts.Separator)
+			}
+		}
+		return slug
+	}
+}
+
+// NormalizedKey is a transformer producing a search key:
+// the source is transliterated, lowercased, and stripped of punctuation, with words separated by a single space.
+func NormalizedKey(source string) string {
+	return strings.ToLower(strings.Join(splitWords(transliterate(source, nil)), " "))
+}
+
+// splitWords splits the string into runs of letters and digits.
+func splitWords(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func transliterate(s string, custom map[rune]string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if t, ok := custom[r]; ok {
+			b.WriteString(t)
+		} else if t, ok := transliterations[r]; ok {
+			b.WriteString(t)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+var transliterations = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'æ': "ae",
+	'À': "A", 'Á': "A", 'Â': "A", 'Ã': "A", 'Ä': "A", 'Å': "A", 'Æ': "AE",
+	'ç': "c", 'Ç': "C", 'č': "c", 'Č': "C", 'ć': "c", 'Ć': "C",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ě': "e", 'ę': "e",
+	'È': "E", 'É': "E", 'Ê': "E", 'Ë': "E", 'Ě': "E", 'Ę': "E",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'Ì': "I", 'Í': "I", 'Î': "I", 'Ï': "I",
+	'ñ': "n", 'Ñ': "N", 'ń': "n", 'Ń': "N", 'ň': "n", 'Ň': "N",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'œ': "oe",
+	'Ò': "O", 'Ó': "O", 'Ô': "O", 'Õ': "O", 'Ö': "O", 'Ø': "O", 'Œ': "OE",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ů': "u", 'Ù': "U", 'Ú': "U", 'Û': "U", 'Ü': "U", 'Ů': "U",
+	'ý': "y", 'ÿ': "y", 'Ý': "Y", 'Ÿ': "Y",
+	'ß': "ss", 'ð': "d", 'Ð': "D", 'þ': "th", 'Þ': "TH",
+	'ł': "l", 'Ł': "L", 'ř': "r", 'Ř': "R", 'š': "s", 'Š': "S", 'ś': "s", 'Ś': "S",
+	'ž': "z", 'Ž': "Z", 'ź': "z", 'Ź': "Z", 'ż': "z", 'Ż': "Z", 'ď': "d", 'Ď': "D", 'ť': "t", 'Ť': "T",
+}
@@ -0,0 +1,137 @@
+package structs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// LoadEnv sets the fields of the struct from a .env file.
+//
+// Keys are the upper cased encoding names of the fields, nested structs are separated by an underscore.
+//
+// If a prefix is given, only keys starting with the prefix are considered, I.E. a prefix of "APP" matches "APP_NAME".
+//
+// It will panic if the struct has not been made.
+func (s *Struct) LoadEnv(r io.Reader, prefix string) error {
+	s.checkMade("Cannot load .env if struct has not been made")
+	var env, err = parseEnv(r)
+	if err != nil {
+		return err
+	}
+	var v = s.decodeTarget()
+	err = fromKV(v, s.tag, strings.TrimSuffix(prefix, "_"), "_", func(path string) (string, bool) {
+		var value, ok = env[strings.ToUpper(path)]
+		return value, ok
+	})
+	if err != nil {
+		return err
+	}
+	return s.setDecoded(v)
+}
+
+// SaveEnv writes the fields of the struct to w in the .env format.
+//
+// Keys are written in sorted order, see LoadEnv for how keys are named.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) SaveEnv(w io.Writer, prefix string) error {
+	s.checkMade("Cannot save .env if struct has not been made")
+	var kv = make(map[string]string)
+	if err := toKV(s.readable(), s.tag, strings.TrimSuffix(prefix, "_"), "_", kv); err != nil {
+		return err
+	}
+	for _, key := range sortedKeys(kv) {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", strings.ToUpper(key), quoteEnv(kv[key])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	var keys = make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func parseEnv(r io.Reader) (map[string]string, error) {
+	var env = make(map[string]string)
+	var scanner = bufio.NewScanner(r)
+	var lineNo int
+	for scanner.Scan() {
+		lineNo++
+		var line = strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		var key, value, ok = strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("Line %d: missing '=' in %q", lineNo, line)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		switch {
+		case strings.HasPrefix(value, `"`):
+			var unquoted, err = unquoteEnv(value)
+			if err != nil {
+				return nil, fmt.Errorf("Line %d: %s", lineNo, err)
+			}
+			value = unquoted
+		case strings.HasPrefix(value, "'"):
+			var end = strings.Index(value[1:], "'")
+			if end < 0 {
+				return nil, fmt.Errorf("Line %d: unterminated quote in %q", lineNo, line)
+			}
+			value = value[1 : end+1]
+		default:
+			if idx := strings.Index(value, " #"); idx >= 0 {
+				value = strings.TrimSpace(value[:idx])
+			}
+		}
+		env[key] = value
+	}
+	return env, scanner.Err()
+}
+
+func unquoteEnv(value string) (string, error) {
+	var b strings.Builder
+	for i := 1; i < len(value); i++ {
+		switch value[i] {
+		case '"':
+			return b.String(), nil
+		case '\\':
+			i++
+			if i >= len(value) {
+				break
+			}
+			switch value[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(value[i])
+			}
+		default:
+			b.WriteByte(value[i])
+		}
+	}
+	return "", fmt.Errorf("unterminated quote in %q", value)
+}
+
+func quoteEnv(value string) string {
+	if !strings.ContainsAny(value, " \t\r\n#\"'\\=$") {
+		return value
+	}
+	var r = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + r.Replace(value) + `"`
+}
@@ -0,0 +1,108 @@
+package edi_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Nigel2392/go-structs"
+	"github.com/Nigel2392/go-structs/edi"
+)
+
+func newOrder() *structs.Struct {
+	var s = structs.New("json")
+	s.AddFieldWithTags("Purpose", reflect.TypeOf(""), map[string]string{"edi": "BEG01"})
+	s.AddFieldWithTags("Number", reflect.TypeOf(""), map[string]string{"edi": "BEG03"})
+	s.AddFieldWithTags("Date", reflect.TypeOf(time.Time{}), map[string]string{"edi": "BEG05"})
+	s.AddFieldWithTags("Quantity", reflect.TypeOf(0), map[string]string{"edi": "PO102"})
+	s.Make()
+	return s
+}
+
+func TestMappingRoundTrip(t *testing.T) {
+	var s = newOrder()
+	s.SetField("Purpose", "00")
+	s.SetField("Number", "PO-1001")
+	s.SetField("Date", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	s.SetField("Quantity", 12)
+
+	var mapping, err = edi.NewMapping(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	segments, err := mapping.Encode(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err = edi.WriteSegments(&buf, edi.DefaultDelimiters, segments...); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "BEG*00**PO-1001**20240301~") {
+		t.Errorf("Unexpected segments %q", buf.String())
+	}
+
+	read, _, err := edi.ReadSegments(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded = newOrder()
+	if err = mapping.Decode(read, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded.Interface(), s.Interface()) {
+		t.Errorf("Expected %v, got %v", s.Interface(), decoded.Interface())
+	}
+}
+
+func TestMappingMissingField(t *testing.T) {
+	var mapping, err = edi.NewMapping(newOrder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var other = structs.New("json")
+	other.StringField("Purpose", "purpose")
+	other.Make()
+	other.SetField("Purpose", "unchanged")
+
+	var segments = []edi.Segment{{"BEG", "00", "", "PO-1001"}}
+	if err = mapping.Decode(segments, other); err == nil {
+		t.Error("Expected error decoding into a struct without the bound fields")
+	}
+	if other.GetField("Purpose") != "unchanged" {
+		t.Errorf("Expected struct to be left unchanged, got %v", other.Interface())
+	}
+	if _, err = mapping.Encode(other); err == nil {
+		t.Error("Expected error encoding a struct without the bound fields")
+	}
+}
+
+func TestWriteSegmentsDelimiters(t *testing.T) {
+	var mapping, err = edi.NewMapping(newOrder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, number := range []string{"PO*1001", "PO~1001", "PO:1001"} {
+		var s = newOrder()
+		s.SetField("Number", number)
+		segments, err := mapping.Encode(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err = edi.WriteSegments(&buf, edi.DefaultDelimiters, segments...); err == nil {
+			t.Errorf("Expected error for element %q", number)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("Expected nothing to be written, got %q", buf.String())
+		}
+	}
+
+	var isa = edi.Segment{"ISA", "00", "", "00", "", "ZZ", "SENDER", "ZZ", "RECEIVER", "240301", "1200", "U", "00401", "000000001", "0", "P", ":"}
+	var buf bytes.Buffer
+	if err = edi.WriteSegments(&buf, edi.DefaultDelimiters, isa); err != nil {
+		t.Errorf("Expected the component separator to be allowed in ISA16, got %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+package edi
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nigel2392/go-structs"
+)
+
+type binding struct {
+	field   string
+	segment string
+	element int
+}
+
+// Mapping binds the fields of a struct to segment elements.
+type Mapping struct {
+	bindings []binding
+	order    []string
+}
+
+// NewMapping creates a mapping from the `edi` tags of the struct's fields.
+//
+// Segments are encoded in the order in which they are first referenced by a field.
+//
+// It will panic if the struct has not been made.
+func NewMapping(s *structs.Struct) (*Mapping, error) {
+	var m = &Mapping{
+		bindings: make([]binding, 0),
+		order:    make([]string, 0),
+	}
+	for i := 0; i < s.NumField(); i++ {
+		var field = s.Field(i)
+		var tag = field.Tag.Get("edi")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		if len(tag) < 4 {
+			return nil, fmt.Errorf("Field %s: invalid edi tag %q", field.Name, tag)
+		}
+		var segment, position = tag[:len(tag)-2], tag[len(tag)-2:]
+		var element, err = strconv.Atoi(position)
+		if err != nil || element <= 0 {
+			return nil, fmt.Errorf("Field %s: invalid element position in edi tag %q", field.Name, tag)
+		}
+		m.bindings = append(m.bindings, binding{
+			field:   field.Name,
+			segment: segment,
+			element: element,
+		})
+		var seen bool
+		for _, id := range m.order {
+			if id == segment {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			m.order = append(m.order, segment)
+		}
+	}
+	return m, nil
+}
+
+// Decode sets the bound fields of the struct from the first occurrence of their segments.
+//
+// Segments which are not present leave their fields untouched.
+// An error is returned if a bound field does not exist in the struct.
+// The fields are set through Struct.DecodeWith, so the struct is left unchanged if decoding fails.
+func (m *Mapping) Decode(segments []Segment, s *structs.Struct) error {
+	var byID = make(map[string]Segment)
+	for _, segment := range segments {
+		if _, ok := byID[segment.ID()]; !ok {
+			byID[segment.ID()] = segment
+		}
+	}
+	return s.DecodeWith(func(v reflect.Value) error {
+		for _, b := range m.bindings {
+			var segment, ok = byID[b.segment]
+			if !ok {
+				continue
+			}
+			var field = v.FieldByName(b.field)
+			if !field.IsValid() {
+				return fmt.Errorf("Field %s does not exist", b.field)
+			}
+			if err := parseElement(segment.Element(b.element), field); err != nil {
+				return fmt.Errorf("%s%02d: %s", b.segment, b.element, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Encode returns the segments for the bound fields of the struct.
+func (m *Mapping) Encode(s *structs.Struct) ([]Segment, error) {
+	var bySegment = make(map[string]Segment)
+	for _, b := range m.bindings {
+		var segment = bySegment[b.segment]
+		if segment == nil {
+			segment = Segment{b.segment}
+		}
+		for len(segment) <= b.element {
+			segment = append(segment, "")
+		}
+		var field, err = s.ReadField(b.field)
+		if err != nil {
+			return nil, err
+		}
+		value, err := formatElement(field)
+		if err != nil {
+			return nil, fmt.Errorf("%s%02d: %s", b.segment, b.element, err)
+		}
+		segment[b.element] = value
+		bySegment[b.segment] = segment
+	}
+	var segments = make([]Segment, 0, len(m.order))
+	for _, id := range m.order {
+		segments = append(segments, bySegment[id])
+	}
+	return segments, nil
+}
+
+// EncodeTransaction returns a transaction set of the given type containing the struct's segments.
+func (m *Mapping) EncodeTransaction(s *structs.Struct, typ, control string) (Transaction, error) {
+	var segments, err = m.Encode(s)
+	return Transaction{
+		Type:     typ,
+		Control:  control,
+		Segments: segments,
+	}, err
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// X12 dates are written as CCYYMMDD.
+const dateLayout = "20060102"
+
+func formatElement(v reflect.Value) (string, error) {
+	if v.Type() == timeType {
+		var t = v.Interface().(time.Time)
+		if t.IsZero() {
+			return "", nil
+		}
+		return t.Format(dateLayout), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("Cannot encode value of type %s", v.Type().String())
+}
+
+func parseElement(s string, v reflect.Value) error {
+	s = strings.TrimSpace(s)
+	if v.Type() == timeType {
+		if s == "" {
+			v.Set(reflect.Zero(timeType))
+			return nil
+		}
+		var t, err = time.Parse(dateLayout, s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if s == "" && v.Kind() != reflect.String {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i, err = strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var i, err = strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(i)
+	case reflect.Float32, reflect.Float64:
+		var f, err = strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("Cannot decode into value of type %s", v.Type().String())
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+// Package edi maps structs.Struct fields to the elements of EDI X12 segments.
+//
+// Fields are bound with an `edi` tag naming the segment and the 1-based element position,
+// I.E. `edi:"BEG03"` binds the field to the third element of the BEG segment.
+//
+// Only simple transaction sets are supported, where every bound segment occurs once.
+package edi
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Delimiters used to separate elements, segments and components.
+type Delimiters struct {
+	Element   byte
+	Segment   byte
+	Component byte
+}
+
+// The delimiters which are most commonly used in X12 documents.
+var DefaultDelimiters = Delimiters{
+	Element:   '*',
+	Segment:   '~',
+	Component: ':',
+}
+
+// The length of the fixed-width ISA segment, including the segment terminator.
+const isaLength = 106
+
+// Segment is a single segment, the first element holds the segment ID.
+type Segment []string
+
+// ID returns the segment ID, I.E. "ST".
+func (s Segment) ID() string {
+	if len(s) == 0 {
+		return ""
+	}
+	return s[0]
+}
+
+// Element returns the element at the 1-based position, or an empty string if it does not exist.
+func (s Segment) Element(pos int) string {
+	if pos <= 0 || pos >= len(s) {
+		return ""
+	}
+	return s[pos]
+}
+
+// ReadSegments reads all segments from r.
+//
+// If the data starts with an ISA segment, the delimiters are detected from it,
+// otherwise the default delimiters are used.
+func ReadSegments(r io.Reader) ([]Segment, Delimiters, error) {
+	var data, err = io.ReadAll(r)
+	if err != nil {
+		return nil, DefaultDelimiters, err
+	}
+	data = bytes.TrimSpace(data)
+	var delims = DefaultDelimiters
+	if bytes.HasPrefix(data, []byte("ISA")) {
+		if len(data) < isaLength {
+			return nil, delims, fmt.Errorf("ISA segment is too short")
+		}
+		delims = Delimiters{
+			Element:   data[3],
+			Component: data[isaLength-2],
+			Segment:   data[isaLength-1],
+		}
+	}
+	var segments = make([]Segment, 0)
+	var scanner = bufio.NewScanner(bytes.NewReader(data))
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexByte(data, delims.Segment); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	})
+	for scanner.Scan() {
+		var text = strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		segments = append(segments, Segment(strings.Split(text, string(delims.Element))))
+	}
+	return segments, delims, scanner.Err()
+}
+
+// WriteSegments writes the segments to w, each followed by the segment terminator and a newline.
+//
+// Trailing empty elements are omitted.
+//
+// X12 has no escaping, so an error is returned before anything is written if an element contains one of the delimiters.
+// The component separator in the sixteenth element of an ISA segment is allowed.
+func WriteSegments(w io.Writer, delims Delimiters, segments ...Segment) error {
+	var special = string([]byte{delims.Element, delims.Segment, delims.Component})
+	for _, segment := range segments {
+		for i, element := range segment {
+			if segment.ID() == "ISA" && i == 16 && element == string(delims.Component) {
+				continue
+			}
+			if strings.ContainsAny(element, special) {
+				return fmt.Errorf("Element %s%02d contains a delimiter: %q", segment.ID(), i, element)
+			}
+		}
+	}
+	for _, segment := range segments {
+		var end = len(segment)
+		for end > 1 && segment[end-1] == "" {
+			end--
+		}
+		var line = strings.Join(segment[:end], string(delims.Element)) + string(delims.Segment) + "\n"
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Transaction is a single transaction set, without its ST and SE segments.
+type Transaction struct {
+	Type     string // The transaction set identifier code, I.E. "850"
+	Control  string // The transaction set control number
+	Segments []Segment
+}
+
+// Transactions returns all transaction sets in the segments, envelope segments are skipped.
+func Transactions(segments []Segment) ([]Transaction, error) {
+	var transactions = make([]Transaction, 0)
+	var current *Transaction
+	for _, segment := range segments {
+		switch segment.ID() {
+		case "ST":
+			if current != nil {
+				return nil, fmt.Errorf("Transaction %s is missing its SE segment", current.Control)
+			}
+			current = &Transaction{
+				Type:     segment.Element(1),
+				Control:  segment.Element(2),
+				Segments: make([]Segment, 0),
+			}
+		case "SE":
+			if current == nil {
+				return nil, fmt.Errorf("SE segment without ST segment")
+			}
+			if count := fmt.Sprint(len(current.Segments) + 2); segment.Element(1) != count {
+				return nil, fmt.Errorf("Transaction %s has %s segments, SE reports %s", current.Control, count, segment.Element(1))
+			}
+			transactions = append(transactions, *current)
+			current = nil
+		default:
+			if current != nil {
+				current.Segments = append(current.Segments, segment)
+			}
+		}
+	}
+	if current != nil {
+		return nil, fmt.Errorf("Transaction %s is missing its SE segment", current.Control)
+	}
+	return transactions, nil
+}
+
+// Wrap returns the segments of the transaction, wrapped in its ST and SE segments.
+func (t Transaction) Wrap() []Segment {
+	var segments = make([]Segment, 0, len(t.Segments)+2)
+	segments = append(segments, Segment{"ST", t.Type, t.Control})
+	segments = append(segments, t.Segments...)
+	segments = append(segments, Segment{"SE", fmt.Sprint(len(t.Segments) + 2), t.Control})
+	return segments
+}
@@ -0,0 +1,36 @@
+package structs
+
+import "reflect"
+
+// Equal reports whether both structs have the same fields holding deeply equal values, skipping the ignored fields.
+//
+// Fields are matched by name, so the order of the fields does not matter, ignored fields do not have to exist
+// in both structs.
+//
+// It will panic if either struct has not been made.
+func (s *Struct) Equal(other *Struct, ignore ...string) bool {
+	s.checkMade("Cannot compare if struct has not been made")
+	other.checkMade("Cannot compare if struct has not been made")
+	var ignored = make(map[string]bool, len(ignore))
+	for _, name := range ignore {
+		ignored[name] = true
+	}
+	var count int
+	for i := 0; i < s.sstruct.NumField(); i++ {
+		var name = s.sstruct.Field(i).Name
+		if ignored[name] {
+			continue
+		}
+		var theirs = other.structValue.FieldByName(name)
+		if !theirs.IsValid() || !reflect.DeepEqual(s.structValue.Field(i).Interface(), theirs.Interface()) {
+			return false
+		}
+		count++
+	}
+	for i := 0; i < other.sstruct.NumField(); i++ {
+		if !ignored[other.sstruct.Field(i).Name] {
+			count--
+		}
+	}
+	return count == 0
+}
@@ -0,0 +1,257 @@
+package structs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Alignment of a value within a fixed-width field
+type Alignment int
+
+const (
+	AlignLeft Alignment = iota
+	AlignRight
+)
+
+// FixedWidthField describes the position of a field in a fixed-width record.
+type FixedWidthField struct {
+	Name  string    // The name of the struct field
+	Start int       // The offset of the first character in the record
+	Width int       // The amount of characters
+	Align Alignment // How the value is aligned within the width
+	Pad   rune      // The character used for padding
+}
+
+// FixedWidthCodec encodes and decodes structs as fixed-width (flat-file) records.
+//
+// Positions are counted in characters, not bytes.
+type FixedWidthCodec struct {
+	Fields []FixedWidthField
+}
+
+// NewFixedWidthCodec creates a codec from the `fixed` tags of the struct's fields.
+//
+// The tag has the format `fixed:"start:end"`, where end is exclusive, with the optional options
+// "left", "right" and "pad=c" following a comma, I.E. `fixed:"10:18,right,pad=0"`.
+//
+// Numeric fields are right aligned by default, all other fields are left aligned.
+//
+// Padding defaults to a space. Fields without a `fixed` tag are skipped.
+// An error is returned if a start is negative, if the ranges of two fields overlap,
+// or if the padding of a numeric field can be part of the number at its padded side, I.E. "0" on a left aligned number.
+func NewFixedWidthCodec(s *Struct) (*FixedWidthCodec, error) {
+	var codec = &FixedWidthCodec{Fields: make([]FixedWidthField, 0)}
+	for _, field := range s.fieldsByName {
+		var tag, ok = field.Tag.Lookup("fixed")
+		if !ok || tag == "-" {
+			continue
+		}
+		var parts = strings.Split(tag, ",")
+		var start, end, found = strings.Cut(parts[0], ":")
+		if !found {
+			return nil, fmt.Errorf("Field %s: invalid fixed tag %q", field.Name, tag)
+		}
+		var f = FixedWidthField{
+			Name: field.Name,
+			Pad:  ' ',
+		}
+		var err error
+		if f.Start, err = strconv.Atoi(start); err != nil || f.Start < 0 {
+			return nil, fmt.Errorf("Field %s: invalid start in fixed tag %q", field.Name, tag)
+		}
+		var stop int
+		if stop, err = strconv.Atoi(end); err != nil || stop <= f.Start {
+			return nil, fmt.Errorf("Field %s: invalid end in fixed tag %q", field.Name, tag)
+		}
+		f.Width = stop - f.Start
+		if isNumericKind(field.Type.Kind()) {
+			f.Align = AlignRight
+		}
+		for _, option := range parts[1:] {
+			switch {
+			case option == "left":
+				f.Align = AlignLeft
+			case option == "right":
+				f.Align = AlignRight
+			case strings.HasPrefix(option, "pad="):
+				var pad, size = utf8.DecodeRuneInString(strings.TrimPrefix(option, "pad="))
+				if size == 0 {
+					return nil, fmt.Errorf("Field %s: invalid padding in fixed tag %q", field.Name, tag)
+				}
+				f.Pad = pad
+			default:
+				return nil, fmt.Errorf("Field %s: unknown option %q in fixed tag", field.Name, option)
+			}
+		}
+		if isNumericKind(field.Type.Kind()) && numericPadAmbiguous(f) {
+			return nil, fmt.Errorf("Field %s: padding %q can be part of the number", field.Name, f.Pad)
+		}
+		for _, other := range codec.Fields {
+			if f.Start < other.Start+other.Width && other.Start < f.Start+f.Width {
+				return nil, fmt.Errorf("Field %s: range %d:%d overlaps field %s", field.Name, f.Start, f.Start+f.Width, other.Name)
+			}
+		}
+		codec.Fields = append(codec.Fields, f)
+	}
+	return codec, nil
+}
+
+// Width returns the length of a record in characters.
+func (c *FixedWidthCodec) Width() int {
+	var width int
+	for _, f := range c.Fields {
+		if f.Start+f.Width > width {
+			width = f.Start + f.Width
+		}
+	}
+	return width
+}
+
+// EncodeRecord encodes the struct as a single record, without a line ending.
+//
+// It returns an error if a value does not fit in the width of its field,
+// if a value starts or ends with the padding character on its padded side, as it could not be decoded,
+// or if the installed policy does not allow a field to be read.
+//
+// It will panic if the struct has not been made.
+func (c *FixedWidthCodec) EncodeRecord(s *Struct) (string, error) {
+	s.checkMade("Cannot encode if struct has not been made")
+	var record = []rune(strings.Repeat(" ", c.Width()))
+	for _, f := range c.Fields {
+		var field = s.structValue.FieldByName(f.Name)
+		if !field.IsValid() {
+			return "", fmt.Errorf("Field %s does not exist", f.Name)
+		}
+		if err := catch(func() { s.checkPolicy(ActionRead, f.Name, field.Interface()) }); err != nil {
+			return "", err
+		}
+		var str, err = formatValue(field)
+		if err != nil {
+			return "", fmt.Errorf("%s: %s", f.Name, err)
+		}
+		var value = []rune(str)
+		if len(value) > f.Width {
+			return "", fmt.Errorf("%s: value %q does not fit in %d characters", f.Name, str, f.Width)
+		}
+		if padAmbiguous(f, field.Kind(), value) {
+			return "", fmt.Errorf("%s: value %q cannot be told apart from its padding %q", f.Name, str, f.Pad)
+		}
+		var padding = []rune(strings.Repeat(string(f.Pad), f.Width-len(value)))
+		if f.Align == AlignRight {
+			value = append(padding, value...)
+		} else {
+			value = append(value, padding...)
+		}
+		copy(record[f.Start:], value)
+	}
+	return string(record), nil
+}
+
+// DecodeRecord decodes a single record into the struct.
+//
+// Records which are shorter than the codec's width are treated as if they were padded.
+// Changed fields are set through SetField, if any field cannot be parsed or set an error is returned
+// and the struct is left unchanged.
+//
+// It will panic if the struct has not been made.
+func (c *FixedWidthCodec) DecodeRecord(record string, s *Struct) error {
+	s.checkMade("Cannot decode if struct has not been made")
+	var v = s.decodeTarget()
+	var runes = []rune(record)
+	for _, f := range c.Fields {
+		var field = v.FieldByName(f.Name)
+		if !field.IsValid() {
+			return fmt.Errorf("Field %s does not exist", f.Name)
+		}
+		var str string
+		if f.Start < len(runes) {
+			var end = f.Start + f.Width
+			if end > len(runes) {
+				end = len(runes)
+			}
+			str = string(runes[f.Start:end])
+		}
+		if f.Align == AlignRight {
+			str = strings.TrimLeft(str, string(f.Pad))
+		} else {
+			str = strings.TrimRight(str, string(f.Pad))
+		}
+		if f.Pad != ' ' && field.Kind() != reflect.String {
+			str = strings.TrimSpace(str)
+		}
+		if str == "" && field.Kind() != reflect.String {
+			field.Set(reflect.Zero(field.Type()))
+			continue
+		}
+		var value, err = parseValue(str, field.Type())
+		if err != nil {
+			return fmt.Errorf("%s: %s", f.Name, err)
+		}
+		field.Set(value)
+	}
+	return s.setDecoded(v)
+}
+
+// numericPadAmbiguous reports whether the padding can be a character of a number at its padded side.
+//
+// Zeros are allowed on the left of a number, as formatted numbers never have leading zeros which matter.
+func numericPadAmbiguous(f FixedWidthField) bool {
+	if f.Align == AlignRight {
+		return strings.ContainsRune("123456789+-", f.Pad)
+	}
+	return strings.ContainsRune("0123456789", f.Pad)
+}
+
+// padAmbiguous reports whether the padded side of the value is the padding character,
+// which DecodeRecord would strip along with the padding.
+func padAmbiguous(f FixedWidthField, kind reflect.Kind, value []rune) bool {
+	if len(value) == 0 {
+		return false
+	}
+	if f.Align == AlignRight {
+		return value[0] == f.Pad && !(f.Pad == '0' && isNumericKind(kind))
+	}
+	return value[len(value)-1] == f.Pad
+}
+
+// Encode writes every struct as a record to w, each followed by a newline.
+func (c *FixedWidthCodec) Encode(w io.Writer, records ...*Struct) error {
+	for _, s := range records {
+		var record, err = c.EncodeRecord(s)
+		if err != nil {
+			return err
+		}
+		if _, err = io.WriteString(w, record+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Decode reads records from r, decoding each into the struct before calling fn.
+//
+// The same struct is re-used for every record, use DeepCopy to keep a record.
+func (c *FixedWidthCodec) Decode(r io.Reader, s *Struct, fn func(s *Struct) error) error {
+	var scanner = bufio.NewScanner(r)
+	var lineNo int
+	for scanner.Scan() {
+		lineNo++
+		var line = strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		s.Make()
+		if err := c.DecodeRecord(line, s); err != nil {
+			return fmt.Errorf("Line %d: %s", lineNo, err)
+		}
+		if err := fn(s); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
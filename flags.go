@@ -0,0 +1,206 @@
+package structs
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Flags is a bitset of named flags, I.E. a permission mask.
+//
+// The names of the flags are taken from the `flags` tag of the field, the value only holds the bits.
+// The zero Flags is an empty set.
+type Flags uint64
+
+// Bits returns the bitset as an integer.
+func (f Flags) Bits() uint64 {
+	return uint64(f)
+}
+
+var flagsType = reflect.TypeOf(Flags(0))
+
+// flagNames returns the flag names from the `flags` tag of the field,
+// and whether the field is marshaled as an array of names.
+func flagNames(field reflect.StructField) (names []string, asStrings bool) {
+	var list, mode, _ = strings.Cut(field.Tag.Get("flags"), ";")
+	if list != "" {
+		names = strings.Split(list, ",")
+	}
+	return names, mode == "strings"
+}
+
+// flagBit returns the bit of the flag in the field.
+func flagBit(field reflect.StructField, flag string) (Flags, error) {
+	var names, _ = flagNames(field)
+	for i, name := range names {
+		if name == flag {
+			return 1 << uint(i), nil
+		}
+	}
+	return 0, fmt.Errorf("Unknown flag %q", flag)
+}
+
+// FlagsField adds a field of type Flags with the given flag names, at most 64 flags are allowed.
+//
+// If asStrings is true, the flags are marshaled as an array of names instead of an integer by MarshalJSON.
+// All other encoders use the integer.
+func (s *Struct) FlagsField(absolute_name, name string, names []string, asStrings bool, required ...bool) {
+	if len(names) == 0 || len(names) > 64 {
+		panic(fmt.Sprintf("Flags field %s must have between 1 and 64 flags", absolute_name))
+	}
+	for _, n := range names {
+		if n == "" || strings.ContainsAny(n, ",;") {
+			panic(fmt.Sprintf("Invalid flag name %q for field %s", n, absolute_name))
+		}
+	}
+	if name == "" {
+		name = absolute_name
+	}
+	var tag = fmt.Sprintf(`%s:"%s" flags:"%s"`, s.tag, name, strings.Join(names, ","))
+	if asStrings {
+		tag = fmt.Sprintf(`%s:"%s" flags:"%s;strings"`, s.tag, name, strings.Join(names, ","))
+	}
+	if len(required) > 0 && required[0] {
+		tag += ` structs:"required"`
+	}
+	s.AddStructField(reflect.StructField{
+		Name: absolute_name,
+		Tag:  reflect.StructTag(tag),
+		Type: flagsType,
+	})
+}
+
+func (s *Struct) flagsField(name string) (reflect.StructField, error) {
+	s.checkMade("Cannot access flags if struct has not been made")
+	var field, ok = s.sstruct.FieldByName(name)
+	if !ok || field.Type != flagsType {
+		return field, fmt.Errorf("Field %s is not a flags field", name)
+	}
+	return field, nil
+}
+
+// SetFlag sets or clears the flag of the flags field.
+//
+// The field is set through SetField, an error is returned if the policy does not allow it.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) SetFlag(name, flag string, on bool) error {
+	var field, err = s.flagsField(name)
+	if err != nil {
+		return err
+	}
+	bit, err := flagBit(field, flag)
+	if err != nil {
+		return err
+	}
+	var flags = s.structValue.FieldByIndex(field.Index).Interface().(Flags)
+	if on {
+		flags |= bit
+	} else {
+		flags &^= bit
+	}
+	return catch(func() { s.SetField(name, flags) })
+}
+
+// HasFlag reports whether the flag of the flags field is set.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) HasFlag(name, flag string) (bool, error) {
+	var field, err = s.flagsField(name)
+	if err != nil {
+		return false, err
+	}
+	bit, err := flagBit(field, flag)
+	if err != nil {
+		return false, err
+	}
+	var value reflect.Value
+	if value, err = s.ReadField(name); err != nil {
+		return false, err
+	}
+	return value.Interface().(Flags)&bit != 0, nil
+}
+
+// FlagNames returns the names of the flags of the flags field which are set, in declaration order.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) FlagNames(name string) ([]string, error) {
+	var field, err = s.flagsField(name)
+	if err != nil {
+		return nil, err
+	}
+	var value reflect.Value
+	if value, err = s.ReadField(name); err != nil {
+		return nil, err
+	}
+	var names, _ = flagNames(field)
+	return flagsToNames(value.Interface().(Flags), names), nil
+}
+
+func flagsToNames(flags Flags, names []string) []string {
+	var set = make([]string, 0)
+	for i, name := range names {
+		if flags&(1<<uint(i)) != 0 {
+			set = append(set, name)
+		}
+	}
+	return set
+}
+
+// flagsJSONType returns the struct type with every flags field which is marshaled as names replaced by a []string,
+// or nil if there are no such fields.
+func flagsJSONType(typ reflect.Type) reflect.Type {
+	var fields = make([]reflect.StructField, typ.NumField())
+	var changed bool
+	for i := range fields {
+		fields[i] = typ.Field(i)
+		if _, asStrings := flagNames(fields[i]); fields[i].Type == flagsType && asStrings {
+			fields[i].Type = reflect.TypeOf([]string(nil))
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return reflect.StructOf(fields)
+}
+
+// flagsAsNames converts the value to the type returned by flagsJSONType.
+func flagsAsNames(v reflect.Value) reflect.Value {
+	var typ = flagsJSONType(v.Type())
+	if typ == nil {
+		return v
+	}
+	var named = reflect.New(typ).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		var field = v.Type().Field(i)
+		if typ.Field(i).Type == field.Type {
+			named.Field(i).Set(v.Field(i))
+			continue
+		}
+		var names, _ = flagNames(field)
+		named.Field(i).Set(reflect.ValueOf(flagsToNames(v.Field(i).Interface().(Flags), names)))
+	}
+	return named
+}
+
+// flagsFromNames sets the fields of v from named, which must be of the type returned by flagsJSONType.
+func flagsFromNames(named, v reflect.Value) error {
+	for i := 0; i < v.NumField(); i++ {
+		var field = v.Type().Field(i)
+		if named.Type().Field(i).Type == field.Type {
+			v.Field(i).Set(named.Field(i))
+			continue
+		}
+		var flags Flags
+		for _, name := range named.Field(i).Interface().([]string) {
+			var bit, err = flagBit(field, name)
+			if err != nil {
+				return fmt.Errorf("%s: %s", field.Name, err)
+			}
+			flags |= bit
+		}
+		v.Field(i).Set(reflect.ValueOf(flags))
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package structs
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+
+// FromMap creates a struct with a field for every key in the map, I.E. a decoded JSON object
+//
+// The field names are the keys converted to exported Go identifiers, the keys are used as encoding names.
+// The types are inferred from the values: nested maps become nested structs, which can be retrieved with Nested,
+// []interface{} becomes a slice of the common element type, and nil becomes interface{}.
+//
+// Only the schema is inferred, the struct still has to be made and filled, I.E. with json.Unmarshal.
+func FromMap(m map[string]interface{}, tag string) *Struct {
+	var s = New(tag)
+	var keys = make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var names = make(map[string]bool, len(keys))
+	for _, key := range keys {
+		var name = identifier(key, names)
+		var field = reflect.StructField{
+			Name: name,
+			Tag:  formatTag([]tagPair{{key: tag, value: key}}),
+		}
+		if nested, ok := m[key].(map[string]interface{}); ok {
+			var child = FromMap(nested, tag)
+			child.Make()
+			if s.nested == nil {
+				s.nested = make(map[string]*Struct)
+			}
+			s.nested[name] = child
+			field.Type = child.sstruct
+		} else {
+			field.Type = inferType(m[key], tag)
+		}
+		s.AddStructField(field)
+	}
+	return s
+}
+
+// inferType returns the type to hold the decoded value.
+func inferType(v interface{}, tag string) reflect.Type {
+	switch v := v.(type) {
+	case nil:
+		return interfaceType
+	case map[string]interface{}:
+		var s = FromMap(v, tag)
+		s.Make()
+		return s.sstruct
+	case []interface{}:
+		return reflect.SliceOf(inferElemType(v, tag))
+	default:
+		return reflect.TypeOf(v)
+	}
+}
+
+// inferElemType returns the type shared by all non-nil elements, or interface{} if they differ.
+//
+// If all elements are maps, the element type is a struct holding the keys of all maps.
+func inferElemType(values []interface{}, tag string) reflect.Type {
+	var merged map[string]interface{}
+	var typ reflect.Type
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		if m, ok := v.(map[string]interface{}); ok && (merged != nil || typ == nil) {
+			if merged == nil {
+				merged = make(map[string]interface{}, len(m))
+			}
+			for key, value := range m {
+				if merged[key] == nil {
+					merged[key] = value
+				}
+			}
+			continue
+		}
+		if merged != nil {
+			return interfaceType
+		}
+		var t = inferType(v, tag)
+		if typ != nil && typ != t {
+			return interfaceType
+		}
+		typ = t
+	}
+	if merged != nil {
+		return inferType(merged, tag)
+	}
+	if typ == nil {
+		return interfaceType
+	}
+	return typ
+}
+
+// identifier converts the key to a unique exported Go identifier, I.E. "first_name" becomes "FirstName".
+func identifier(key string, used map[string]bool) string {
+	var b strings.Builder
+	for _, word := range splitWords(transliterate(key, nil)) {
+		var r, size = utf8.DecodeRuneInString(word)
+		b.WriteRune(unicode.ToUpper(r))
+		b.WriteString(word[size:])
+	}
+	var name = b.String()
+	if r, _ := utf8.DecodeRuneInString(name); !unicode.IsUpper(r) {
+		name = "Field" + name
+	}
+	var unique = name
+	for i := 2; used[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	used[unique] = true
+	return unique
+}
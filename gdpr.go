@@ -0,0 +1,101 @@
+package structs
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// IsPersonalData returns whether the field holds personal data.
+//
+// Fields are marked as personal data with the pii tag, I.E. `pii:""`,
+// or `pii:"pseudonymize"` to have ErasePersonalData replace string values with a pseudonym instead of zeroing them.
+func IsPersonalData(field reflect.StructField) bool {
+	var _, ok = field.Tag.Lookup("pii")
+	return ok
+}
+
+// PersonalDataExport is the portable bundle produced by ExportPersonalData.
+type PersonalDataExport struct {
+	ExportedAt time.Time                  `json:"exported_at"`
+	Data       map[string]json.RawMessage `json:"data"`
+}
+
+// ErasureReceipt records which fields were erased by ErasePersonalData.
+//
+// It holds no personal data, so it can be kept as proof of the erasure.
+type ErasureReceipt struct {
+	ErasedAt      time.Time `json:"erased_at"`
+	Erased        []string  `json:"erased"`
+	Pseudonymized []string  `json:"pseudonymized"`
+}
+
+// ExportPersonalData returns the values of all fields holding personal data as a JSON bundle,
+// keyed by the encoding names of the fields.
+//
+// Fields which the installed policy does not allow to be read are left out.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) ExportPersonalData() ([]byte, error) {
+	s.checkMade("Cannot export personal data if struct has not been made")
+	var export = PersonalDataExport{
+		ExportedAt: time.Now().UTC(),
+		Data:       make(map[string]json.RawMessage),
+	}
+	var v = s.readable()
+	for i := 0; i < v.NumField(); i++ {
+		var field = v.Type().Field(i)
+		if !IsPersonalData(field) {
+			continue
+		}
+		var value, err = json.Marshal(v.Field(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		export.Data[encName(field, s.tag)] = value
+	}
+	return json.Marshal(export)
+}
+
+// ErasePersonalData erases the values of all fields holding personal data.
+//
+// String fields tagged `pii:"pseudonymize"` are replaced with a pseudonym: the hex encoded HMAC-SHA256 of the value,
+// keyed with the given key, so records of the same subject can still be related without revealing the value.
+// Empty strings are left empty. All other personal data fields are set to their zero value.
+//
+// Erasure is not subject to the installed policy.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) ErasePersonalData(key []byte) *ErasureReceipt {
+	s.checkMade("Cannot erase personal data if struct has not been made")
+	var now = time.Now()
+	var receipt = &ErasureReceipt{
+		ErasedAt:      now.UTC(),
+		Erased:        make([]string, 0),
+		Pseudonymized: make([]string, 0),
+	}
+	for i := 0; i < s.sstruct.NumField(); i++ {
+		var field = s.sstruct.Field(i)
+		if !IsPersonalData(field) {
+			continue
+		}
+		var value = s.structValue.Field(i)
+		if field.Tag.Get("pii") == "pseudonymize" && value.Kind() == reflect.String {
+			if value.Len() > 0 {
+				var mac = hmac.New(sha256.New, key)
+				mac.Write([]byte(value.String()))
+				value.SetString(hex.EncodeToString(mac.Sum(nil)))
+			}
+			receipt.Pseudonymized = append(receipt.Pseudonymized, field.Name)
+		} else {
+			value.Set(reflect.Zero(field.Type))
+			receipt.Erased = append(receipt.Erased, field.Name)
+		}
+		s.touch(field.Name, now)
+		s.updateDerived(field.Name)
+	}
+	return receipt
+}
@@ -0,0 +1,166 @@
+package structs
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// The mean radius of the earth in meters, used for distance calculations.
+const EarthRadius = 6371008.8
+
+// GeoPoint is a WGS 84 coordinate.
+//
+// It is marshaled to and from a GeoJSON Point, and validated when it is set through SetField.
+type GeoPoint struct {
+	Lat float64
+	Lon float64
+}
+
+// Validate returns an error if the latitude or longitude is out of range.
+func (p GeoPoint) Validate() error {
+	if math.IsNaN(p.Lat) || p.Lat < -90 || p.Lat > 90 {
+		return fmt.Errorf("Latitude %v is out of range [-90, 90]", p.Lat)
+	}
+	if math.IsNaN(p.Lon) || p.Lon < -180 || p.Lon > 180 {
+		return fmt.Errorf("Longitude %v is out of range [-180, 180]", p.Lon)
+	}
+	return nil
+}
+
+// Normalize validates the point, so invalid coordinates are rejected when the field is set.
+func (p *GeoPoint) Normalize() error {
+	return p.Validate()
+}
+
+// Distance returns the great-circle distance to the other point in meters, using the haversine formula.
+func (p GeoPoint) Distance(other GeoPoint) float64 {
+	var lat1, lat2 = p.Lat * math.Pi / 180, other.Lat * math.Pi / 180
+	var dLat = lat2 - lat1
+	var dLon = (other.Lon - p.Lon) * math.Pi / 180
+	var a = math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+type geoJSONPoint struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+// MarshalJSON encodes the point as a GeoJSON Point, I.E. {"type": "Point", "coordinates": [lon, lat]}.
+func (p GeoPoint) MarshalJSON() ([]byte, error) {
+	return json.Marshal(geoJSONPoint{Type: "Point", Coordinates: []float64{p.Lon, p.Lat}})
+}
+
+// UnmarshalJSON decodes a GeoJSON Point, and validates its coordinates.
+func (p *GeoPoint) UnmarshalJSON(data []byte) error {
+	var v geoJSONPoint
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Type != "Point" {
+		return fmt.Errorf("Expected GeoJSON type Point, got %q", v.Type)
+	}
+	if len(v.Coordinates) < 2 {
+		return fmt.Errorf("GeoJSON Point must have at least 2 coordinates")
+	}
+	var point = GeoPoint{Lat: v.Coordinates[1], Lon: v.Coordinates[0]}
+	if err := point.Validate(); err != nil {
+		return err
+	}
+	*p = point
+	return nil
+}
+
+// BoundingBox is the area between the south-west and north-east corners.
+//
+// It is marshaled to and from a GeoJSON bounding box, I.E. [west, south, east, north],
+// and validated when it is set through SetField.
+type BoundingBox struct {
+	SouthWest GeoPoint
+	NorthEast GeoPoint
+}
+
+// Validate returns an error if a corner is invalid, or the south edge lies north of the north edge.
+//
+// A west edge east of the east edge is allowed, this means the box crosses the antimeridian.
+func (b BoundingBox) Validate() error {
+	if err := b.SouthWest.Validate(); err != nil {
+		return err
+	}
+	if err := b.NorthEast.Validate(); err != nil {
+		return err
+	}
+	if b.SouthWest.Lat > b.NorthEast.Lat {
+		return fmt.Errorf("South edge %v lies north of north edge %v", b.SouthWest.Lat, b.NorthEast.Lat)
+	}
+	return nil
+}
+
+// Normalize validates the box, so invalid boxes are rejected when the field is set.
+func (b *BoundingBox) Normalize() error {
+	return b.Validate()
+}
+
+// Contains reports whether the point lies within the bounding box, edges included.
+func (b BoundingBox) Contains(p GeoPoint) bool {
+	if p.Lat < b.SouthWest.Lat || p.Lat > b.NorthEast.Lat {
+		return false
+	}
+	if b.SouthWest.Lon <= b.NorthEast.Lon {
+		return p.Lon >= b.SouthWest.Lon && p.Lon <= b.NorthEast.Lon
+	}
+	return p.Lon >= b.SouthWest.Lon || p.Lon <= b.NorthEast.Lon
+}
+
+// MarshalJSON encodes the box as a GeoJSON bounding box.
+func (b BoundingBox) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]float64{b.SouthWest.Lon, b.SouthWest.Lat, b.NorthEast.Lon, b.NorthEast.Lat})
+}
+
+// UnmarshalJSON decodes a GeoJSON bounding box, and validates it.
+func (b *BoundingBox) UnmarshalJSON(data []byte) error {
+	var v []float64
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if len(v) != 4 {
+		return fmt.Errorf("GeoJSON bounding box must have 4 values, got %d", len(v))
+	}
+	var box = BoundingBox{
+		SouthWest: GeoPoint{Lat: v[1], Lon: v[0]},
+		NorthEast: GeoPoint{Lat: v[3], Lon: v[2]},
+	}
+	if err := box.Validate(); err != nil {
+		return err
+	}
+	*b = box
+	return nil
+}
+
+var (
+	geoPointType    = reflect.TypeOf(GeoPoint{})
+	boundingBoxType = reflect.TypeOf(BoundingBox{})
+)
+
+// GeoPointField adds a field of type GeoPoint.
+func (s *Struct) GeoPointField(absolute_name, name string, required ...bool) {
+	s.AddField(absolute_name, name, geoPointType, required...)
+}
+
+// BoundingBoxField adds a field of type BoundingBox.
+func (s *Struct) BoundingBoxField(absolute_name, name string, required ...bool) {
+	s.AddField(absolute_name, name, boundingBoxType, required...)
+}
+
+// ValidateGeo is a validator for GeoPoint and BoundingBox values, to be used in a ValidatorMap.
+func ValidateGeo(value interface{}) error {
+	switch v := value.(type) {
+	case GeoPoint:
+		return v.Validate()
+	case BoundingBox:
+		return v.Validate()
+	}
+	return fmt.Errorf("Cannot validate value of type %T as a geo value", value)
+}
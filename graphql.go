@@ -0,0 +1,99 @@
+package structs
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// GraphQLType returns GraphQL SDL for an object type describing the fields of the struct
+//
+// Fields are named after the encoding names in the struct's tag, with characters which are not allowed replaced by '_',
+// fields tagged "-" are skipped. Fields marked with `structs:"required"` are non-null.
+//
+// Integers map to Int, floats to Float, slices to lists of non-null elements, and nested structs to object types
+// named after the type and the field, I.E. UserAddress, which are written before the type itself.
+// time.Time, maps, and types implementing encoding.TextMarshaler are Strings. interface{}, Money, Quantity, GeoPoint,
+// BoundingBox, Blob and other types implementing json.Marshaler are Strings holding their JSON.
+// Flags fields are Ints, or lists of Strings holding the names if they were added with asStrings.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) GraphQLType(name string) string {
+	s.checkMade("Cannot create GraphQL type if struct has not been made")
+	var b strings.Builder
+	writeGraphQLType(&b, name, s.sstruct, s.tag, make(map[reflect.Type]string))
+	return b.String()
+}
+
+func writeGraphQLType(b *strings.Builder, name string, typ reflect.Type, tag string, seen map[reflect.Type]string) {
+	seen[typ] = name
+	defer delete(seen, typ)
+
+	var fields strings.Builder
+	for i := 0; i < typ.NumField(); i++ {
+		var field = typ.Field(i)
+		if field.PkgPath != "" || field.Tag.Get(tag) == "-" {
+			continue
+		}
+		var fieldType = graphQLType(b, name+field.Name, field.Type, tag, seen)
+		if _, asStrings := flagNames(field); field.Type == flagsType && asStrings {
+			fieldType = "[String!]"
+		}
+		if IsRequired(field) && !strings.HasSuffix(fieldType, "!") {
+			fieldType += "!"
+		}
+		fmt.Fprintf(&fields, "  %s: %s\n", graphQLName(encName(field, tag)), fieldType)
+	}
+	fmt.Fprintf(b, "type %s {\n%s}\n", name, fields.String())
+}
+
+// graphQLType returns the nullable type of a value, nested object types are written to b.
+func graphQLType(b *strings.Builder, name string, typ reflect.Type, tag string, seen map[reflect.Type]string) string {
+	switch {
+	case typ == timeType:
+		return "String"
+	case typ.Kind() != reflect.Ptr && reflect.PtrTo(typ).Implements(jsonMarshalerType):
+		return "String"
+	case typ.Implements(textMarshalerType) || reflect.PtrTo(typ).Implements(textMarshalerType):
+		return "String"
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "Boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return "Int"
+	case reflect.Float32, reflect.Float64:
+		return "Float"
+	case reflect.Ptr:
+		return strings.TrimSuffix(graphQLType(b, name, typ.Elem(), tag, seen), "!")
+	case reflect.Slice, reflect.Array:
+		if typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8 {
+			return "String"
+		}
+		var elem = graphQLType(b, name, typ.Elem(), tag, seen)
+		if typ.Elem().Kind() != reflect.Ptr && typ.Elem().Kind() != reflect.Interface {
+			elem += "!"
+		}
+		return "[" + elem + "]"
+	case reflect.Struct:
+		if existing, ok := seen[typ]; ok {
+			return existing
+		}
+		writeGraphQLType(b, name, typ, tag, seen)
+		return name
+	}
+	return "String"
+}
+
+// graphQLName replaces characters which are not allowed in GraphQL names with '_'.
+func graphQLName(name string) string {
+	var b = []byte(name)
+	for i, c := range b {
+		var letter = c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+		if !letter && !(i > 0 && c >= '0' && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,79 @@
+package structs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+type halLink struct {
+	Href string `json:"href"`
+}
+
+// WithLinks sets the HAL links of the struct, keyed by relation, I.E. "self".
+//
+// Links are templates, placeholders like {ID} are replaced with the value of the field of that name,
+// when the struct is marshalled with MarshalHAL.
+//
+// It returns the struct, so calls can be chained.
+func (s *Struct) WithLinks(links map[string]string) *Struct {
+	s.links = make(map[string]string, len(links))
+	for rel, href := range links {
+		s.links[rel] = href
+	}
+	return s
+}
+
+// Links returns the resolved HAL links of the struct.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) Links() (map[string]string, error) {
+	s.checkMade("Cannot resolve links if struct has not been made")
+	var links = make(map[string]string, len(s.links))
+	for rel, href := range s.links {
+		var resolved, err = s.expandTemplate(href, func(value string) (string, error) {
+			return url.PathEscape(value), nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("link %s: %s", rel, err)
+		}
+		links[rel] = resolved
+	}
+	return links, nil
+}
+
+// MarshalHAL encodes the struct as a HAL resource.
+//
+// The fields are encoded as with MarshalJSON, the links set with WithLinks are added under "_links".
+//
+// It will panic if the struct has not been made.
+func MarshalHAL(s *Struct) ([]byte, error) {
+	s.checkMade("Cannot marshal if struct has not been made")
+	var data, err = s.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	if len(s.links) == 0 {
+		return data, nil
+	}
+	links, err := s.Links()
+	if err != nil {
+		return nil, err
+	}
+	var halLinks = make(map[string]halLink, len(links))
+	for rel, href := range links {
+		halLinks[rel] = halLink{Href: href}
+	}
+	linkData, err := json.Marshal(halLinks)
+	if err != nil {
+		return nil, err
+	}
+
+	var out = make([]byte, 0, len(data)+len(linkData)+12)
+	out = append(out, `{"_links":`...)
+	out = append(out, linkData...)
+	if len(data) > 2 {
+		out = append(out, ',')
+	}
+	return append(out, data[1:]...), nil
+}
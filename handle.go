@@ -0,0 +1,65 @@
+package structs
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Handle is a typed accessor for a field of a struct, created by Field.
+//
+// The field is looked up and its type is checked once, so Get and Set do not need to resolve the field by name.
+// A handle stays valid until the struct is re-made with different fields.
+type Handle[T any] struct {
+	s     *Struct
+	typ   reflect.Type
+	field reflect.StructField
+}
+
+// Field returns a handle for the field, which must have exactly the type T.
+func Field[T any](s *Struct, name string) (Handle[T], error) {
+	var field, err = s.tryField("create handle for", name)
+	if err != nil {
+		return Handle[T]{}, err
+	}
+	var typ = reflect.TypeOf((*T)(nil)).Elem()
+	if field.Type() != typ {
+		return Handle[T]{}, fmt.Errorf("Field %s has type %s, not %s", name, field.Type().String(), typ.String())
+	}
+	var structField, _ = s.sstruct.FieldByName(name)
+	return Handle[T]{s: s, typ: s.sstruct, field: structField}, nil
+}
+
+func (h Handle[T]) value() reflect.Value {
+	if h.s.sstruct != h.typ {
+		panic(fmt.Sprintf("Handle for field %s is stale, the struct has been re-made with different fields", h.field.Name))
+	}
+	return h.s.structValue.FieldByIndex(h.field.Index)
+}
+
+// Get returns the value of the field.
+//
+// It will panic if the installed policy does not allow the field to be read, or if the handle is stale.
+func (h Handle[T]) Get() T {
+	var field = h.value()
+	var value = field.Interface().(T)
+	h.s.checkPolicy(ActionRead, h.field.Name, value)
+	return value
+}
+
+// Set sets the value of the field like SetField, returning an error if the installed policy does not allow it,
+// or if the value cannot be normalized.
+//
+// It will panic if the handle is stale.
+func (h Handle[T]) Set(value T) error {
+	var field = h.value()
+	if err := catch(func() { h.s.checkPolicy(ActionWrite, h.field.Name, value) }); err != nil {
+		return err
+	}
+	if err := assign(field, h.field.Tag, value); err != nil {
+		return fmt.Errorf("Cannot set field %s: %s", h.field.Name, err)
+	}
+	h.s.touch(h.field.Name, time.Now())
+	h.s.updateDerived(h.field.Name)
+	return nil
+}
@@ -0,0 +1,241 @@
+package structs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Hash writes a canonical encoding of the field values to h, and returns the resulting sum
+//
+// The encoding does not depend on the declaration order of the fields, or on the iteration order of maps:
+// fields of the struct and of nested structs are sorted by name, and map entries by their encoded key.
+// Values are encoded without their type, so an int and an int64 holding the same number hash the same.
+// time.Time is hashed as an instant regardless of its location, encoding.TextMarshaler types by their text,
+// and json.Marshaler types by their JSON.
+//
+// An error is returned for functions, channels, unsafe pointers and structs with unexported fields
+// which implement neither marshaler, or if a value cannot be marshalled.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) Hash(h hash.Hash) ([]byte, error) {
+	s.checkMade("Cannot hash if struct has not been made")
+	var b bytes.Buffer
+	if err := hashValue(&b, s.structValue); err != nil {
+		return nil, err
+	}
+	h.Reset()
+	h.Write(b.Bytes())
+	return h.Sum(nil), nil
+}
+
+// Value kinds written before every value, so different values cannot have the same encoding.
+const (
+	hashNil byte = iota
+	hashBool
+	hashInt
+	hashUint
+	hashFloat
+	hashComplex
+	hashString
+	hashList
+	hashMap
+	hashStruct
+	hashTime
+	hashText
+	hashJSON
+)
+
+func hashBytes(b *bytes.Buffer, kind byte, data []byte) {
+	b.WriteByte(kind)
+	var length [binary.MaxVarintLen64]byte
+	b.Write(length[:binary.PutUvarint(length[:], uint64(len(data)))])
+	b.Write(data)
+}
+
+func hashUint64(b *bytes.Buffer, kind byte, v uint64) {
+	var data [8]byte
+	binary.BigEndian.PutUint64(data[:], v)
+	hashBytes(b, kind, data[:])
+}
+
+func hashFloat64(f float64) uint64 {
+	switch {
+	case f == 0:
+		return 0 // -0 and +0 are equal
+	case math.IsNaN(f):
+		return math.Float64bits(math.NaN())
+	}
+	return math.Float64bits(f)
+}
+
+func hashValue(b *bytes.Buffer, v reflect.Value) error {
+	switch {
+	case v.Type() == timeType:
+		var t = v.Interface().(time.Time)
+		var data [12]byte
+		binary.BigEndian.PutUint64(data[:8], uint64(t.Unix()))
+		binary.BigEndian.PutUint32(data[8:], uint32(t.Nanosecond()))
+		hashBytes(b, hashTime, data[:])
+		return nil
+	case v.Kind() != reflect.Ptr && v.Kind() != reflect.Interface && v.Type().Implements(textMarshalerType):
+		var text, err = v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return err
+		}
+		hashBytes(b, hashText, text)
+		return nil
+	case v.Kind() != reflect.Ptr && v.Kind() != reflect.Interface && reflect.PtrTo(v.Type()).Implements(jsonMarshalerType):
+		if !v.CanAddr() {
+			var addressable = reflect.New(v.Type()).Elem()
+			addressable.Set(v)
+			v = addressable
+		}
+		var data, err = v.Addr().Interface().(json.Marshaler).MarshalJSON()
+		if err != nil {
+			return err
+		}
+		hashBytes(b, hashJSON, data)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			hashBytes(b, hashNil, nil)
+			return nil
+		}
+		return hashValue(b, v.Elem())
+	case reflect.Bool:
+		var data = []byte{0}
+		if v.Bool() {
+			data[0] = 1
+		}
+		hashBytes(b, hashBool, data)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		hashUint64(b, hashInt, uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		hashUint64(b, hashUint, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		hashUint64(b, hashFloat, hashFloat64(v.Float()))
+	case reflect.Complex64, reflect.Complex128:
+		var data [16]byte
+		binary.BigEndian.PutUint64(data[:8], hashFloat64(real(v.Complex())))
+		binary.BigEndian.PutUint64(data[8:], hashFloat64(imag(v.Complex())))
+		hashBytes(b, hashComplex, data[:])
+	case reflect.String:
+		hashBytes(b, hashString, []byte(v.String()))
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			hashBytes(b, hashString, v.Bytes())
+			return nil
+		}
+		var list bytes.Buffer
+		for i := 0; i < v.Len(); i++ {
+			if err := hashValue(&list, v.Index(i)); err != nil {
+				return fmt.Errorf("[%d]: %s", i, err)
+			}
+		}
+		hashBytes(b, hashList, list.Bytes())
+	case reflect.Map:
+		var entries = make([][]byte, 0, v.Len())
+		var iter = v.MapRange()
+		for iter.Next() {
+			var entry bytes.Buffer
+			if err := hashValue(&entry, iter.Key()); err != nil {
+				return err
+			}
+			if err := hashValue(&entry, iter.Value()); err != nil {
+				return fmt.Errorf("%v: %s", iter.Key().Interface(), err)
+			}
+			entries = append(entries, entry.Bytes())
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return bytes.Compare(entries[i], entries[j]) < 0
+		})
+		hashBytes(b, hashMap, bytes.Join(entries, nil))
+	case reflect.Struct:
+		var indices = make([]int, 0, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				return fmt.Errorf("Cannot hash value of type %s with unexported fields", v.Type().String())
+			}
+			indices = append(indices, i)
+		}
+		sort.Slice(indices, func(i, j int) bool {
+			return v.Type().Field(indices[i]).Name < v.Type().Field(indices[j]).Name
+		})
+		var fields bytes.Buffer
+		for _, i := range indices {
+			var name = v.Type().Field(i).Name
+			hashBytes(&fields, hashString, []byte(name))
+			if err := hashValue(&fields, v.Field(i)); err != nil {
+				return fmt.Errorf("%s: %s", name, err)
+			}
+		}
+		hashBytes(b, hashStruct, fields.Bytes())
+	default:
+		return fmt.Errorf("Cannot hash value of type %s", v.Type().String())
+	}
+	return nil
+}
+
+// SchemaHash returns a hex encoded SHA-256 fingerprint of the schema: the struct's tag,
+// and the names, types, tags and order of the fields. Values are not included.
+//
+// Structs with the same fingerprint have the same type once made.
+func (s *Struct) SchemaHash() string {
+	var b bytes.Buffer
+	hashBytes(&b, hashString, []byte(s.tag))
+	for _, field := range s.fieldsByName {
+		var fields bytes.Buffer
+		hashBytes(&fields, hashString, []byte(field.Name))
+		hashBytes(&fields, hashString, []byte(typeIdentity(field.Type)))
+		hashBytes(&fields, hashString, []byte(field.Tag))
+		var anonymous = []byte{0}
+		if field.Anonymous {
+			anonymous[0] = 1
+		}
+		hashBytes(&fields, hashBool, anonymous)
+		hashBytes(&b, hashStruct, fields.Bytes())
+	}
+	var sum = sha256.Sum256(b.Bytes())
+	return hex.EncodeToString(sum[:])
+}
+
+// typeIdentity returns the type as a string, with the full package path of named types.
+func typeIdentity(typ reflect.Type) string {
+	if typ.Name() != "" && typ.PkgPath() != "" {
+		return typ.PkgPath() + "." + typ.Name()
+	}
+	switch typ.Kind() {
+	case reflect.Ptr:
+		return "*" + typeIdentity(typ.Elem())
+	case reflect.Slice:
+		return "[]" + typeIdentity(typ.Elem())
+	case reflect.Array:
+		return fmt.Sprintf("[%d]%s", typ.Len(), typeIdentity(typ.Elem()))
+	case reflect.Map:
+		return "map[" + typeIdentity(typ.Key()) + "]" + typeIdentity(typ.Elem())
+	case reflect.Struct:
+		var b strings.Builder
+		b.WriteString("struct {")
+		for i := 0; i < typ.NumField(); i++ {
+			var field = typ.Field(i)
+			fmt.Fprintf(&b, " %s %s %q;", field.Name, typeIdentity(field.Type), field.Tag)
+		}
+		b.WriteString(" }")
+		return b.String()
+	}
+	return typ.String()
+}
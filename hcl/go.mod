@@ -0,0 +1,19 @@
+module github.com/Nigel2392/go-structs/hcl
+
+go 1.23.0
+
+require (
+	github.com/Nigel2392/go-structs v0.1.0
+	github.com/hashicorp/hcl/v2 v2.22.0
+	github.com/zclconf/go-cty v1.16.3
+)
+
+require (
+	github.com/agext/levenshtein v1.2.1 // indirect
+	github.com/apparentlymart/go-textseg/v15 v15.0.0 // indirect
+	github.com/mitchellh/go-wordwrap v1.0.1 // indirect
+	golang.org/x/mod v0.17.0 // indirect
+	golang.org/x/sync v0.14.0 // indirect
+	golang.org/x/text v0.25.0 // indirect
+	golang.org/x/tools v0.21.1-0.20240508182429-e35e4ccd0d2d // indirect
+)
@@ -0,0 +1,240 @@
+// Package hcl decodes HCL (Terraform-style) configuration into a structs.Struct.
+//
+// Attributes are decoded into fields with the same encoding name,
+// blocks are decoded into nested struct fields, or slices of structs when a block may be repeated.
+//
+// Block labels are decoded into the fields of the nested struct which are marked with `structs:"label"`, in order.
+package hcl
+
+import (
+	"encoding"
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/Nigel2392/go-structs"
+	hcl2 "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/gocty"
+)
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// Decode parses the HCL source and decodes it into the struct.
+//
+// The evaluation context may be nil, in which case variables and functions are not available.
+//
+// The returned error is of type hcl.Diagnostics when the source could not be parsed or decoded.
+// The fields are set through Struct.DecodeWith, so the struct is left unchanged if decoding fails.
+//
+// It will panic if the struct has not been made.
+func Decode(s *structs.Struct, filename string, src []byte, ctx *hcl2.EvalContext) error {
+	var file, diags = hclsyntax.ParseConfig(src, filename, hcl2.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return diags
+	}
+	return s.DecodeWith(func(value reflect.Value) error {
+		var diags = decodeBody(file.Body.(*hclsyntax.Body), value, s.Tag(), ctx)
+		if diags.HasErrors() {
+			return diags
+		}
+		return nil
+	})
+}
+
+// DecodeFile reads the file and decodes it into the struct, see Decode.
+func DecodeFile(s *structs.Struct, filename string, ctx *hcl2.EvalContext) error {
+	var src, err = os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	return Decode(s, filename, src, ctx)
+}
+
+func isLabel(field reflect.StructField) bool {
+	return structs.HasOption(field, "label")
+}
+
+// blockType returns the struct type the field decodes blocks into, if any.
+func blockType(typ reflect.Type) (reflect.Type, bool) {
+	switch typ.Kind() {
+	case reflect.Ptr, reflect.Slice:
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct || reflect.PtrTo(typ).Implements(textUnmarshalerType) {
+		return nil, false
+	}
+	return typ, true
+}
+
+func decodeBody(body *hclsyntax.Body, v reflect.Value, tag string, ctx *hcl2.EvalContext) hcl2.Diagnostics {
+	var diags hcl2.Diagnostics
+	var seenAttrs = make(map[string]bool)
+	var seenBlocks = make(map[string]bool)
+	for i := 0; i < v.NumField(); i++ {
+		var field = v.Type().Field(i)
+		if !field.IsExported() || field.Tag.Get(tag) == "-" || isLabel(field) {
+			continue
+		}
+		var name = structs.EncodingName(field, tag)
+		var required = structs.IsRequired(field)
+
+		if elemType, ok := blockType(field.Type); ok {
+			seenBlocks[name] = true
+			var blocks = make([]*hclsyntax.Block, 0)
+			for _, block := range body.Blocks {
+				if block.Type == name {
+					blocks = append(blocks, block)
+				}
+			}
+			diags = append(diags, decodeBlocks(blocks, name, required, body.SrcRange, v.Field(i), elemType, tag, ctx)...)
+			continue
+		}
+
+		seenAttrs[name] = true
+		var attr, ok = body.Attributes[name]
+		if !ok {
+			if required {
+				diags = append(diags, &hcl2.Diagnostic{
+					Severity: hcl2.DiagError,
+					Summary:  "Missing required argument",
+					Detail:   fmt.Sprintf("The argument %q is required, but no definition was found.", name),
+					Subject:  body.MissingItemRange().Ptr(),
+				})
+			}
+			continue
+		}
+		var value, valDiags = attr.Expr.Value(ctx)
+		diags = append(diags, valDiags...)
+		if valDiags.HasErrors() {
+			continue
+		}
+		if err := setValue(v.Field(i), value); err != nil {
+			diags = append(diags, &hcl2.Diagnostic{
+				Severity: hcl2.DiagError,
+				Summary:  "Unsuitable value type",
+				Detail:   fmt.Sprintf("Unsuitable value for %q: %s.", name, err),
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+		}
+	}
+
+	for name, attr := range body.Attributes {
+		if !seenAttrs[name] {
+			diags = append(diags, &hcl2.Diagnostic{
+				Severity: hcl2.DiagError,
+				Summary:  "Unsupported argument",
+				Detail:   fmt.Sprintf("An argument named %q is not expected here.", name),
+				Subject:  attr.NameRange.Ptr(),
+			})
+		}
+	}
+	for _, block := range body.Blocks {
+		if !seenBlocks[block.Type] {
+			diags = append(diags, &hcl2.Diagnostic{
+				Severity: hcl2.DiagError,
+				Summary:  "Unsupported block type",
+				Detail:   fmt.Sprintf("Blocks of type %q are not expected here.", block.Type),
+				Subject:  block.TypeRange.Ptr(),
+			})
+		}
+	}
+	return diags
+}
+
+func decodeBlocks(blocks []*hclsyntax.Block, name string, required bool, rng hcl2.Range, field reflect.Value, elemType reflect.Type, tag string, ctx *hcl2.EvalContext) hcl2.Diagnostics {
+	if len(blocks) == 0 {
+		if required {
+			return hcl2.Diagnostics{{
+				Severity: hcl2.DiagError,
+				Summary:  "Missing required block",
+				Detail:   fmt.Sprintf("A block of type %q is required here.", name),
+				Subject:  rng.Ptr(),
+			}}
+		}
+		return nil
+	}
+
+	if field.Kind() != reflect.Slice && len(blocks) > 1 {
+		return hcl2.Diagnostics{{
+			Severity: hcl2.DiagError,
+			Summary:  "Duplicate block",
+			Detail:   fmt.Sprintf("Only one block of type %q is allowed.", name),
+			Subject:  blocks[1].TypeRange.Ptr(),
+		}}
+	}
+
+	var diags hcl2.Diagnostics
+	var slice = reflect.MakeSlice(reflect.SliceOf(elemType), 0, len(blocks))
+	for _, block := range blocks {
+		var elem = reflect.New(elemType).Elem()
+		diags = append(diags, decodeLabels(block, elem)...)
+		diags = append(diags, decodeBody(block.Body, elem, tag, ctx)...)
+		slice = reflect.Append(slice, elem)
+	}
+
+	switch field.Kind() {
+	case reflect.Slice:
+		if field.Type().Elem().Kind() == reflect.Ptr {
+			var ptrs = reflect.MakeSlice(field.Type(), slice.Len(), slice.Len())
+			for i := 0; i < slice.Len(); i++ {
+				ptrs.Index(i).Set(slice.Index(i).Addr())
+			}
+			slice = ptrs
+		}
+		field.Set(slice)
+	case reflect.Ptr:
+		field.Set(slice.Index(0).Addr())
+	default:
+		field.Set(slice.Index(0))
+	}
+	return diags
+}
+
+func decodeLabels(block *hclsyntax.Block, v reflect.Value) hcl2.Diagnostics {
+	var labels = make([]int, 0)
+	for i := 0; i < v.NumField(); i++ {
+		if isLabel(v.Type().Field(i)) {
+			labels = append(labels, i)
+		}
+	}
+	if len(labels) != len(block.Labels) {
+		return hcl2.Diagnostics{{
+			Severity: hcl2.DiagError,
+			Summary:  "Wrong number of block labels",
+			Detail:   fmt.Sprintf("Blocks of type %q expect %d labels, got %d.", block.Type, len(labels), len(block.Labels)),
+			Subject:  block.TypeRange.Ptr(),
+		}}
+	}
+	for i, idx := range labels {
+		if err := setValue(v.Field(idx), cty.StringVal(block.Labels[i])); err != nil {
+			return hcl2.Diagnostics{{
+				Severity: hcl2.DiagError,
+				Summary:  "Unsuitable block label",
+				Detail:   err.Error(),
+				Subject:  block.LabelRanges[i].Ptr(),
+			}}
+		}
+	}
+	return nil
+}
+
+func setValue(field reflect.Value, value cty.Value) error {
+	if reflect.PtrTo(field.Type()).Implements(textUnmarshalerType) {
+		var str, err = convert.Convert(value, cty.String)
+		if err != nil {
+			return err
+		}
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(str.AsString()))
+	}
+	var typ, err = gocty.ImpliedType(field.Addr().Interface())
+	if err != nil {
+		return err
+	}
+	if value, err = convert.Convert(value, typ); err != nil {
+		return err
+	}
+	return gocty.FromCtyValue(value, field.Addr().Interface())
+}
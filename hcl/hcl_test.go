@@ -0,0 +1,86 @@
+package hcl_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Nigel2392/go-structs"
+	"github.com/Nigel2392/go-structs/hcl"
+	hcl2 "github.com/hashicorp/hcl/v2"
+)
+
+type listener struct {
+	Protocol string `hcl:"protocol" structs:"label"`
+	Port     int    `hcl:"port" structs:"required"`
+	TLS      bool   `hcl:"tls"`
+}
+
+type database struct {
+	Host string `hcl:"host"`
+}
+
+func newConfig() *structs.Struct {
+	var s = structs.New("hcl")
+	s.StringField("Name", "name", true)
+	s.AddField("Tags", "tags", reflect.TypeOf([]string{}))
+	s.AddField("Listeners", "listener", reflect.TypeOf([]listener{}))
+	s.AddField("Database", "database", reflect.TypeOf(&database{}))
+	s.Make()
+	return s
+}
+
+func TestDecode(t *testing.T) {
+	var s = newConfig()
+	var src = `
+name = "gateway"
+tags = ["edge", "public"]
+
+listener "http" {
+  port = 80
+}
+
+listener "https" {
+  port = 443
+  tls  = true
+}
+
+database {
+  host = "db.internal"
+}
+`
+	if err := hcl.Decode(s, "config.hcl", []byte(src), nil); err != nil {
+		t.Fatal(err)
+	}
+	if s.GetField("Name") != "gateway" || !reflect.DeepEqual(s.GetField("Tags"), []string{"edge", "public"}) {
+		t.Errorf("Unexpected attributes %v", s.Interface())
+	}
+	var listeners = []listener{{Protocol: "http", Port: 80}, {Protocol: "https", Port: 443, TLS: true}}
+	if !reflect.DeepEqual(s.GetField("Listeners"), listeners) {
+		t.Errorf("Expected %v, got %v", listeners, s.GetField("Listeners"))
+	}
+	if db := s.GetField("Database").(*database); db == nil || db.Host != "db.internal" {
+		t.Errorf("Unexpected database block %v", db)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	var tests = map[string]string{
+		"missing required argument": `tags = []`,
+		"unsupported argument":      `name = "a"` + "\n" + `port = 1`,
+		"unsupported block":         `name = "a"` + "\n" + `cache {}`,
+		"missing label":             `name = "a"` + "\n" + `listener { port = 1 }`,
+		"duplicate block":           `name = "a"` + "\n" + `database {}` + "\n" + `database {}`,
+		"unsuitable value":          `name = "a"` + "\n" + `listener "http" { port = "eighty" }`,
+	}
+	for name, src := range tests {
+		var s = newConfig()
+		s.SetField("Name", "unchanged")
+		var err = hcl.Decode(s, "config.hcl", []byte(src), nil)
+		if _, ok := err.(hcl2.Diagnostics); !ok {
+			t.Errorf("%s: Expected diagnostics, got %v", name, err)
+		}
+		if s.GetField("Name") != "unchanged" {
+			t.Errorf("%s: Expected struct to be left unchanged, got %v", name, s.Interface())
+		}
+	}
+}
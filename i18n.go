@@ -0,0 +1,84 @@
+package structs
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// I18nString holds translations of a text, keyed by language tag, I.E. {"en": "Hello", "nl": "Hallo"}.
+type I18nString map[string]string
+
+// Get returns the translation for the first language which is available.
+//
+// Every language is tried as is, and then as its base language, I.E. "en-US" falls back to "en".
+//
+// An empty string is returned if none of the languages are available.
+func (t I18nString) Get(lang string, fallbacks ...string) string {
+	for _, l := range append([]string{lang}, fallbacks...) {
+		if v, ok := t[l]; ok {
+			return v
+		}
+		if base, _, ok := strings.Cut(l, "-"); ok {
+			if v, ok := t[base]; ok {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+var i18nStringType = reflect.TypeOf(I18nString(nil))
+
+// I18nStringField adds a field of type I18nString.
+//
+// The field is marshaled as the full map of translations by MarshalJSON,
+// and as a single resolved string by MarshalJSONLocalized.
+func (s *Struct) I18nStringField(absolute_name, name string, required ...bool) {
+	s.AddField(absolute_name, name, i18nStringType, required...)
+}
+
+// GetLocalized returns the translation of the I18nString field, see I18nString.Get.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) GetLocalized(name, lang string, fallbacks ...string) (string, error) {
+	s.checkMade("Cannot get localized field if struct has not been made")
+	var field = s.structValue.FieldByName(name)
+	if !field.IsValid() || field.Type() != i18nStringType {
+		return "", fmt.Errorf("Field %s is not an I18nString field", name)
+	}
+	if err := catch(func() { s.checkPolicy(ActionRead, name, field.Interface()) }); err != nil {
+		return "", err
+	}
+	return field.Interface().(I18nString).Get(lang, fallbacks...), nil
+}
+
+// MarshalJSONLocalized marshals the struct like MarshalJSON,
+// but every I18nString field is replaced by its translation for the requested language.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) MarshalJSONLocalized(lang string, fallbacks ...string) ([]byte, error) {
+	s.checkMade("Cannot marshal if struct has not been made")
+	var v, err = s.jsonValue()
+	if err != nil {
+		return nil, err
+	}
+	var fields = make([]reflect.StructField, v.NumField())
+	for i := range fields {
+		fields[i] = v.Type().Field(i)
+		if fields[i].Type == i18nStringType {
+			fields[i].Type = reflect.TypeOf("")
+		}
+	}
+	var localized = reflect.New(reflect.StructOf(fields)).Elem()
+	for i := range fields {
+		var value = v.Field(i)
+		if value.Type() == i18nStringType {
+			localized.Field(i).SetString(value.Interface().(I18nString).Get(lang, fallbacks...))
+			continue
+		}
+		localized.Field(i).Set(value)
+	}
+	return json.Marshal(localized.Interface())
+}
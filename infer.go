@@ -0,0 +1,202 @@
+package structs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// InferRules configures how InferSchema resolves fields which hold different types across documents.
+type InferRules struct {
+	// Promote fields holding both integers and floats to float64.
+	// If false, such fields are treated as conflicting.
+	WidenNumbers bool
+
+	// Use string for fields holding conflicting types, instead of interface{}.
+	//
+	// Note that encoding/json cannot decode numbers or booleans into a string,
+	// so the documents have to be converted before they can be decoded into such a field.
+	ConflictAsString bool
+
+	// The fraction of documents in which a field may be missing or null before it becomes a pointer.
+	// With the default of 0, a field which is ever missing or null becomes a pointer.
+	NullThreshold float64
+}
+
+// DefaultInferRules returns the rules used by InferSchema: numbers are widened, conflicts become interface{},
+// and fields which are ever missing or null become pointers.
+func DefaultInferRules() InferRules {
+	return InferRules{WidenNumbers: true}
+}
+
+// InferDecision reports the type chosen for a field, and why.
+type InferDecision struct {
+	Path     string         // The path of the field, with nested keys separated by dots, and [] for array elements.
+	Type     string         // The chosen type.
+	Observed map[string]int // The amount of values seen per JSON type, with integers and floats counted separately.
+	Reason   string
+}
+
+type inferObservation struct {
+	present  int
+	kinds    map[string]int
+	objects  []map[string]interface{}
+	elements []interface{}
+}
+
+func (o *inferObservation) observe(value interface{}) {
+	o.present++
+	var kind string
+	switch v := value.(type) {
+	case nil:
+		kind = "null"
+	case bool:
+		kind = "boolean"
+	case string:
+		kind = "string"
+	case json.Number:
+		kind = "float"
+		if !strings.ContainsAny(v.String(), ".eE") {
+			kind = "integer"
+		}
+	case map[string]interface{}:
+		kind = "object"
+		o.objects = append(o.objects, v)
+	case []interface{}:
+		kind = "array"
+		o.elements = append(o.elements, v...)
+	}
+	o.kinds[kind]++
+}
+
+// InferSchema creates a struct from JSON objects, using DefaultInferRules.
+//
+// See InferRules.Infer.
+func InferSchema(docs ...[]byte) (*Struct, []InferDecision, error) {
+	return DefaultInferRules().Infer(docs...)
+}
+
+// Infer creates a struct with a field for every key in any of the JSON objects, and reports the decision made per field.
+//
+// Fields are named and tagged as with FromMap. Nested objects become nested structs which can be retrieved with Nested,
+// arrays become slices of the type inferred from all their elements.
+func (r InferRules) Infer(docs ...[]byte) (*Struct, []InferDecision, error) {
+	var objects = make([]map[string]interface{}, len(docs))
+	for i, doc := range docs {
+		var decoder = json.NewDecoder(bytes.NewReader(doc))
+		decoder.UseNumber()
+		if err := decoder.Decode(&objects[i]); err != nil {
+			return nil, nil, fmt.Errorf("Document %d: %s", i, err)
+		}
+	}
+	var decisions []InferDecision
+	var s = r.object("", objects, &decisions)
+	return s, decisions, nil
+}
+
+func (r InferRules) object(path string, objects []map[string]interface{}, decisions *[]InferDecision) *Struct {
+	var observations = make(map[string]*inferObservation)
+	for _, object := range objects {
+		for key, value := range object {
+			var o, ok = observations[key]
+			if !ok {
+				o = &inferObservation{kinds: make(map[string]int)}
+				observations[key] = o
+			}
+			o.observe(value)
+		}
+	}
+	var keys = make([]string, 0, len(observations))
+	for key := range observations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var s = New("json")
+	var used = make(map[string]bool, len(keys))
+	for _, key := range keys {
+		var name = identifier(key, used)
+		var typ, nested = r.resolve(path+key, observations[key], len(objects), decisions)
+		s.AddStructField(reflect.StructField{
+			Name: name,
+			Type: typ,
+			Tag:  formatTag([]tagPair{{key: "json", value: key}}),
+		})
+		if nested != nil {
+			if s.nested == nil {
+				s.nested = make(map[string]*Struct)
+			}
+			s.nested[name] = nested
+		}
+	}
+	return s
+}
+
+// resolve chooses the type for the values observed in total documents, and records the decision.
+func (r InferRules) resolve(path string, o *inferObservation, total int, decisions *[]InferDecision) (reflect.Type, *Struct) {
+	var kinds = make([]string, 0, len(o.kinds))
+	for kind := range o.kinds {
+		if kind != "null" {
+			kinds = append(kinds, kind)
+		}
+	}
+	sort.Strings(kinds)
+
+	var decision = InferDecision{Path: path, Observed: o.kinds}
+	var typ reflect.Type
+	var nested *Struct
+	switch {
+	case len(kinds) == 0:
+		typ = interfaceType
+		decision.Reason = "only null values"
+	case len(kinds) == 1:
+		decision.Reason = "single type " + kinds[0]
+		switch kinds[0] {
+		case "boolean":
+			typ = boolType
+		case "string":
+			typ = stringType
+		case "integer":
+			typ = int64Type
+		case "float":
+			typ = float64Type
+		case "object":
+			nested = r.object(path+".", o.objects, decisions)
+			nested.Make()
+			typ = nested.sstruct
+		case "array":
+			var elements = &inferObservation{kinds: make(map[string]int)}
+			for _, element := range o.elements {
+				elements.observe(element)
+			}
+			var elem, _ = r.resolve(path+"[]", elements, len(o.elements), decisions)
+			typ = reflect.SliceOf(elem)
+		}
+	case r.WidenNumbers && len(kinds) == 2 && kinds[0] == "float" && kinds[1] == "integer":
+		typ = float64Type
+		decision.Reason = "promoted integer and float to float64"
+	case r.ConflictAsString:
+		typ = stringType
+		decision.Reason = "conflicting types " + strings.Join(kinds, ", ") + " stored as string"
+	default:
+		typ = interfaceType
+		decision.Reason = "conflicting types " + strings.Join(kinds, ", ") + " stored as interface{}"
+	}
+
+	var missing = total - o.present + o.kinds["null"]
+	switch typ.Kind() {
+	case reflect.Interface, reflect.Slice, reflect.Map:
+		// These types can already hold null.
+	default:
+		if total > 0 && float64(missing)/float64(total) > r.NullThreshold {
+			typ = reflect.PtrTo(typ)
+			decision.Reason += fmt.Sprintf(", nullable: missing or null in %d of %d", missing, total)
+		}
+	}
+	decision.Type = typ.String()
+	*decisions = append(*decisions, decision)
+	return typ, nested
+}
@@ -0,0 +1,189 @@
+package structs
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type jsonAPIResource struct {
+	Type       string          `json:"type"`
+	ID         string          `json:"id,omitempty"`
+	Attributes json.RawMessage `json:"attributes,omitempty"`
+}
+
+type jsonAPIDocument struct {
+	Data json.RawMessage `json:"data"`
+}
+
+// jsonAPIIDIndex returns the index of the field used as the resource ID.
+//
+// This is the field marked with `structs:"id"`, or else the field named ID.
+func jsonAPIIDIndex(typ reflect.Type) int {
+	var byName = -1
+	for i := 0; i < typ.NumField(); i++ {
+		var field = typ.Field(i)
+		if hasStructsOption(field, "id") {
+			return i
+		}
+		if strings.EqualFold(field.Name, "ID") {
+			byName = i
+		}
+	}
+	return byName
+}
+
+// jsonAPIAttributes returns a struct type holding all fields except the ID field.
+func jsonAPIAttributes(typ reflect.Type, idIndex int) reflect.Type {
+	var fields = make([]reflect.StructField, 0, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		if i != idIndex {
+			fields = append(fields, typ.Field(i))
+		}
+	}
+	return reflect.StructOf(fields)
+}
+
+func (s *Struct) jsonAPIResource(resourceType string) (jsonAPIResource, error) {
+	var resource = jsonAPIResource{Type: resourceType}
+	var v = s.readable()
+	var idIndex = jsonAPIIDIndex(v.Type())
+	if idIndex >= 0 {
+		var id, err = formatValue(v.Field(idIndex))
+		if err != nil {
+			return resource, fmt.Errorf("id: %s", err)
+		}
+		resource.ID = id
+	}
+	var attributes = reflect.New(jsonAPIAttributes(v.Type(), idIndex)).Elem()
+	var j int
+	for i := 0; i < v.NumField(); i++ {
+		if i == idIndex {
+			continue
+		}
+		attributes.Field(j).Set(v.Field(i))
+		j++
+	}
+	var data, err = json.Marshal(attributes.Interface())
+	resource.Attributes = data
+	return resource, err
+}
+
+// setJSONAPIResource sets the fields of the struct from the resource through SetField.
+//
+// If any field cannot be decoded or set, an error is returned and the struct is left unchanged.
+func (s *Struct) setJSONAPIResource(resourceType string, resource jsonAPIResource) error {
+	if resource.Type != resourceType {
+		return fmt.Errorf("Expected resource type %s, got %s", resourceType, resource.Type)
+	}
+	var v = s.decodeTarget()
+	var idIndex = jsonAPIIDIndex(s.sstruct)
+	var attributes = reflect.New(jsonAPIAttributes(s.sstruct, idIndex))
+	var fields = make([]int, 0, s.sstruct.NumField())
+	for i := 0; i < s.sstruct.NumField(); i++ {
+		if i != idIndex {
+			attributes.Elem().Field(len(fields)).Set(v.Field(i))
+			fields = append(fields, i)
+		}
+	}
+	// Attributes which are not present in the document keep their current value.
+	if len(resource.Attributes) > 0 {
+		if err := json.Unmarshal(resource.Attributes, attributes.Interface()); err != nil {
+			return err
+		}
+	}
+	for j, i := range fields {
+		v.Field(i).Set(attributes.Elem().Field(j))
+	}
+	if idIndex >= 0 && resource.ID != "" {
+		var id, err = parseValue(resource.ID, s.sstruct.Field(idIndex).Type)
+		if err != nil {
+			return fmt.Errorf("id: %s", err)
+		}
+		v.Field(idIndex).Set(id)
+	}
+	return s.setDecoded(v)
+}
+
+// MarshalJSONAPI encodes the struct as a JSON:API document with a single resource object.
+//
+// The field marked with `structs:"id"`, or else the field named ID, is used as the resource ID.
+// All other fields are encoded as attributes, using the struct's tag.
+//
+// It will panic if the struct has not been made.
+func MarshalJSONAPI(resourceType string, s *Struct) ([]byte, error) {
+	s.checkMade("Cannot marshal if struct has not been made")
+	var resource, err = s.jsonAPIResource(resourceType)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonAPIDocument{Data: data})
+}
+
+// MarshalJSONAPICollection encodes the structs as a JSON:API document with an array of resource objects.
+//
+// It will panic if any of the structs has not been made.
+func MarshalJSONAPICollection(resourceType string, items []*Struct) ([]byte, error) {
+	var resources = make([]jsonAPIResource, len(items))
+	for i, s := range items {
+		s.checkMade("Cannot marshal if struct has not been made")
+		var resource, err = s.jsonAPIResource(resourceType)
+		if err != nil {
+			return nil, fmt.Errorf("%d: %s", i, err)
+		}
+		resources[i] = resource
+	}
+	var data, err = json.Marshal(resources)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonAPIDocument{Data: data})
+}
+
+// UnmarshalJSONAPI decodes a JSON:API document with a single resource object of the given type into the struct.
+//
+// It will panic if the struct has not been made.
+func UnmarshalJSONAPI(data []byte, resourceType string, s *Struct) error {
+	s.checkMade("Cannot unmarshal if struct has not been made")
+	var doc jsonAPIDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	var resource jsonAPIResource
+	if err := json.Unmarshal(doc.Data, &resource); err != nil {
+		return err
+	}
+	return s.setJSONAPIResource(resourceType, resource)
+}
+
+// UnmarshalJSONAPICollection decodes a JSON:API document with an array of resource objects of the given type.
+//
+// Every resource is decoded into a new copy of the schema struct.
+//
+// It will panic if the schema struct has not been made.
+func UnmarshalJSONAPICollection(data []byte, resourceType string, schema *Struct) ([]*Struct, error) {
+	schema.checkMade("Cannot unmarshal if struct has not been made")
+	var doc jsonAPIDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	var resources []jsonAPIResource
+	if err := json.Unmarshal(doc.Data, &resources); err != nil {
+		return nil, err
+	}
+	var items = make([]*Struct, len(resources))
+	for i, resource := range resources {
+		var s = schema.DeepCopy()
+		s.Make()
+		if err := s.setJSONAPIResource(resourceType, resource); err != nil {
+			return nil, fmt.Errorf("%d: %s", i, err)
+		}
+		items[i] = s
+	}
+	return items, nil
+}
@@ -0,0 +1,630 @@
+package structs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type jsonSchema struct {
+	Ref                  string                     `json:"$ref,omitempty"`
+	Type                 json.RawMessage            `json:"type,omitempty"`
+	Format               string                     `json:"format,omitempty"`
+	Nullable             bool                       `json:"nullable,omitempty"`
+	Properties           json.RawMessage            `json:"properties,omitempty"`
+	Required             []string                   `json:"required,omitempty"`
+	Items                *jsonSchema                `json:"items,omitempty"`
+	Enum                 json.RawMessage            `json:"enum,omitempty"`
+	AdditionalProperties json.RawMessage            `json:"additionalProperties,omitempty"`
+	Defs                 map[string]json.RawMessage `json:"$defs,omitempty"`
+	Definitions          map[string]json.RawMessage `json:"definitions,omitempty"`
+}
+
+// types returns the types listed in the schema, a type of "null" is reported separately.
+func (j *jsonSchema) types() (types []string, nullable bool, err error) {
+	if len(j.Type) == 0 {
+		return nil, false, nil
+	}
+	if j.Type[0] == '"' {
+		var t string
+		err = json.Unmarshal(j.Type, &t)
+		types = []string{t}
+	} else {
+		err = json.Unmarshal(j.Type, &types)
+	}
+	var kept = types[:0]
+	for _, t := range types {
+		if t == "null" {
+			nullable = true
+			continue
+		}
+		kept = append(kept, t)
+	}
+	return kept, nullable, err
+}
+
+type jsonSchemaImporter struct {
+	defs     map[string]json.RawMessage
+	resolved map[string]bool
+}
+
+// FromJSONSchema creates a struct from a JSON Schema describing an object
+//
+// Every property becomes a field, named after the property converted to an exported Go identifier,
+// with the property name in the json tag. Properties which are not required are tagged with omitempty,
+// required properties are tagged with `structs:"required"`.
+//
+// Nested objects with properties become nested structs, which can be retrieved with Nested.
+// Objects without properties become maps, arrays become slices of their items,
+// and a nullable type, I.E. ["integer", "null"], becomes a pointer.
+// The formats "date-time" and "byte" on strings become time.Time and []byte,
+// "int32" on integers becomes int32, and "float" on numbers becomes float32.
+//
+// Enums are kept as a JSON array in the enum tag, use EnumValidators to validate them.
+// References to "#/$defs/" and "#/definitions/" are resolved, recursive references are not supported.
+func FromJSONSchema(schema []byte) (*Struct, error) {
+	var root jsonSchema
+	if err := json.Unmarshal(schema, &root); err != nil {
+		return nil, err
+	}
+	var importer = &jsonSchemaImporter{
+		defs:     make(map[string]json.RawMessage),
+		resolved: make(map[string]bool),
+	}
+	for name, def := range root.Definitions {
+		importer.defs["#/definitions/"+name] = def
+	}
+	for name, def := range root.Defs {
+		importer.defs["#/$defs/"+name] = def
+	}
+	return importer.root(&root)
+}
+
+// FromOpenAPI creates a struct from the schema component with the given name in an OpenAPI 3 document in JSON format
+//
+// The schema is converted as with FromJSONSchema, "nullable: true" also makes a field a pointer.
+// References to "#/components/schemas/" are resolved.
+func FromOpenAPI(doc []byte, componentName string) (*Struct, error) {
+	var document struct {
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(doc, &document); err != nil {
+		return nil, err
+	}
+	var component, ok = document.Components.Schemas[componentName]
+	if !ok {
+		return nil, fmt.Errorf("Component schema %s not found", componentName)
+	}
+	var importer = &jsonSchemaImporter{
+		defs:     make(map[string]json.RawMessage, len(document.Components.Schemas)),
+		resolved: map[string]bool{"#/components/schemas/" + componentName: true},
+	}
+	for name, def := range document.Components.Schemas {
+		importer.defs["#/components/schemas/"+name] = def
+	}
+	var root jsonSchema
+	if err := json.Unmarshal(component, &root); err != nil {
+		return nil, fmt.Errorf("%s: %s", componentName, err)
+	}
+	return importer.root(&root)
+}
+
+func (im *jsonSchemaImporter) root(schema *jsonSchema) (*Struct, error) {
+	var resolved, done, err = im.resolve(schema)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	types, _, err := resolved.types()
+	if err != nil {
+		return nil, err
+	}
+	if len(types) != 1 || types[0] != "object" {
+		return nil, fmt.Errorf("Expected a schema of type object, got %s", resolved.Type)
+	}
+	return im.object(resolved)
+}
+
+func (im *jsonSchemaImporter) resolve(schema *jsonSchema) (*jsonSchema, func(), error) {
+	if schema.Ref == "" {
+		return schema, func() {}, nil
+	}
+	var ref = schema.Ref
+	var def, ok = im.defs[ref]
+	if !ok {
+		return nil, nil, fmt.Errorf("Cannot resolve $ref %s", ref)
+	}
+	if im.resolved[ref] {
+		return nil, nil, fmt.Errorf("Recursive $ref %s is not supported", ref)
+	}
+	var resolved jsonSchema
+	if err := json.Unmarshal(def, &resolved); err != nil {
+		return nil, nil, fmt.Errorf("%s: %s", ref, err)
+	}
+	im.resolved[ref] = true
+	return &resolved, func() { delete(im.resolved, ref) }, nil
+}
+
+func (im *jsonSchemaImporter) object(schema *jsonSchema) (*Struct, error) {
+	var s = New("json")
+	var names, err = orderedKeys(schema.Properties)
+	if err != nil {
+		return nil, err
+	}
+	var properties map[string]*jsonSchema
+	if len(schema.Properties) > 0 {
+		if err = json.Unmarshal(schema.Properties, &properties); err != nil {
+			return nil, err
+		}
+	}
+	var required = make(map[string]bool, len(schema.Required))
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+
+	var used = make(map[string]bool, len(names))
+	for _, property := range names {
+		var name = identifier(property, used)
+		var typ, nested, err = im.fieldType(properties[property])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", property, err)
+		}
+		var encoding = property
+		if !required[property] {
+			encoding += ",omitempty"
+		}
+		var pairs = []tagPair{{key: "json", value: encoding}}
+		if required[property] {
+			pairs = append(pairs, tagPair{key: "structs", value: "required"})
+		}
+		if enum := properties[property].Enum; len(enum) > 0 {
+			var compacted bytes.Buffer
+			if err = json.Compact(&compacted, enum); err != nil {
+				return nil, fmt.Errorf("%s: %s", property, err)
+			}
+			pairs = append(pairs, tagPair{key: "enum", value: compacted.String()})
+		}
+		s.AddStructField(reflect.StructField{
+			Name: name,
+			Type: typ,
+			Tag:  formatTag(pairs),
+		})
+		if nested != nil {
+			if s.nested == nil {
+				s.nested = make(map[string]*Struct)
+			}
+			s.nested[name] = nested
+		}
+	}
+	return s, nil
+}
+
+// fieldType returns the type for the schema, and the struct it was built from if it describes an object.
+func (im *jsonSchemaImporter) fieldType(schema *jsonSchema) (reflect.Type, *Struct, error) {
+	if schema == nil {
+		return interfaceType, nil, nil
+	}
+	var resolved, done, err = im.resolve(schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer done()
+	schema = resolved
+
+	types, nullable, err := schema.types()
+	if err != nil {
+		return nil, nil, err
+	}
+	nullable = nullable || schema.Nullable
+	if len(types) != 1 {
+		return interfaceType, nil, nil
+	}
+
+	var typ reflect.Type
+	var nested *Struct
+	switch types[0] {
+	case "string":
+		switch schema.Format {
+		case "date-time":
+			typ = timeType
+		case "byte":
+			typ = reflect.TypeOf([]byte(nil))
+		default:
+			typ = stringType
+		}
+	case "integer":
+		typ = int64Type
+		if schema.Format == "int32" {
+			typ = reflect.TypeOf(int32(0))
+		}
+	case "number":
+		typ = float64Type
+		if schema.Format == "float" {
+			typ = reflect.TypeOf(float32(0))
+		}
+	case "boolean":
+		typ = boolType
+	case "array":
+		var elem reflect.Type
+		if elem, _, err = im.fieldType(schema.Items); err != nil {
+			return nil, nil, fmt.Errorf("items: %s", err)
+		}
+		typ = reflect.SliceOf(elem)
+	case "object":
+		if len(schema.Properties) == 0 {
+			var elem = interfaceType
+			if len(schema.AdditionalProperties) > 0 && schema.AdditionalProperties[0] == '{' {
+				var additional jsonSchema
+				if err = json.Unmarshal(schema.AdditionalProperties, &additional); err != nil {
+					return nil, nil, err
+				}
+				if elem, _, err = im.fieldType(&additional); err != nil {
+					return nil, nil, fmt.Errorf("additionalProperties: %s", err)
+				}
+			}
+			typ = reflect.MapOf(stringType, elem)
+			break
+		}
+		if nested, err = im.object(schema); err != nil {
+			return nil, nil, err
+		}
+		nested.Make()
+		typ = nested.sstruct
+	default:
+		return nil, nil, fmt.Errorf("Unknown type %s", types[0])
+	}
+	if nullable {
+		typ = reflect.PtrTo(typ)
+	}
+	return typ, nested, nil
+}
+
+// orderedKeys returns the keys of the JSON object in the order they appear in.
+func orderedKeys(object json.RawMessage) ([]string, error) {
+	if len(object) == 0 {
+		return nil, nil
+	}
+	var decoder = json.NewDecoder(bytes.NewReader(object))
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	var keys = make([]string, 0)
+	for decoder.More() {
+		var key, err = decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key.(string))
+		var skip json.RawMessage
+		if err = decoder.Decode(&skip); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// EnumValidators returns a ValidatorMap holding a validator for every field with an enum tag,
+// I.E. `enum:"[\"draft\",\"published\"]"`, as set by FromJSONSchema.
+//
+// The validators compare the JSON encoding of the value with the allowed values.
+//
+// It will panic if the struct has not been made.
+func EnumValidators(s *Struct) (ValidatorMap, error) {
+	s.checkMade("Cannot create validators if struct has not been made")
+	var validators = make(ValidatorMap)
+	for i := 0; i < s.sstruct.NumField(); i++ {
+		var field = s.sstruct.Field(i)
+		var enum, ok = field.Tag.Lookup("enum")
+		if !ok {
+			continue
+		}
+		var allowed []json.RawMessage
+		if err := json.Unmarshal([]byte(enum), &allowed); err != nil {
+			return nil, fmt.Errorf("%s: invalid enum tag: %s", field.Name, err)
+		}
+		var encoded = make([]string, len(allowed))
+		for j, value := range allowed {
+			var compacted bytes.Buffer
+			if err := json.Compact(&compacted, value); err != nil {
+				return nil, fmt.Errorf("%s: invalid enum tag: %s", field.Name, err)
+			}
+			encoded[j] = compacted.String()
+		}
+		validators.Add(field.Name, func(value interface{}) error {
+			var data, err = json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			for _, e := range encoded {
+				if e == string(data) {
+					return nil
+				}
+			}
+			return fmt.Errorf("%s is not one of %s", data, strings.Join(encoded, ", "))
+		})
+	}
+	return validators, nil
+}
+
+type exportedSchema struct {
+	Schema               string           `json:"$schema,omitempty"`
+	Type                 interface{}      `json:"type,omitempty"`
+	Format               string           `json:"format,omitempty"`
+	ContentEncoding      string           `json:"contentEncoding,omitempty"`
+	Nullable             bool             `json:"nullable,omitempty"`
+	Description          string           `json:"description,omitempty"`
+	Enum                 json.RawMessage  `json:"enum,omitempty"`
+	Items                *exportedSchema  `json:"items,omitempty"`
+	MinItems             int              `json:"minItems,omitempty"`
+	MaxItems             int              `json:"maxItems,omitempty"`
+	Properties           schemaProperties `json:"properties,omitempty"`
+	Required             []string         `json:"required,omitempty"`
+	AdditionalProperties *exportedSchema  `json:"additionalProperties,omitempty"`
+}
+
+type schemaProperty struct {
+	name   string
+	schema *exportedSchema
+}
+
+// schemaProperties are encoded as a JSON object, keeping the order of the fields.
+type schemaProperties []schemaProperty
+
+func (p schemaProperties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, property := range p {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		var name, err = json.Marshal(property.name)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		schema, err := json.Marshal(property.schema)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(schema)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+type schemaExporter struct {
+	tag     string
+	openAPI bool
+	seen    map[reflect.Type]bool
+}
+
+// JSONSchema returns a JSON Schema document describing the fields of the struct
+//
+// Properties are named after the encoding names in the struct's tag, fields tagged "-" are skipped.
+// Fields marked with `structs:"required"` are listed in the required array.
+// Descriptions and formats are taken from the description and format tags, enums from the enum tag.
+//
+// Integers map to integer, floats to number, slices to array, maps to object with additionalProperties,
+// and structs to object with properties. Pointers allow null, and time.Time is a date-time string.
+// Money, Quantity, GeoPoint, BoundingBox and Blob are described as they are marshaled, flags fields added with asStrings
+// are arrays of their names. Other types implementing json.Marshaler allow any value,
+// and types implementing encoding.TextMarshaler are strings.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) JSONSchema() ([]byte, error) {
+	s.checkMade("Cannot create schema if struct has not been made")
+	var exporter = &schemaExporter{tag: s.tag, seen: make(map[reflect.Type]bool)}
+	var schema, err = exporter.schema(s.sstruct)
+	if err != nil {
+		return nil, err
+	}
+	schema.Schema = "https://json-schema.org/draft/2020-12/schema"
+	return json.Marshal(schema)
+}
+
+// OpenAPISchema returns an OpenAPI 3.0 schema object describing the fields of the struct
+//
+// The schema is created as with JSONSchema, but pointers are marked as nullable,
+// sized integers and floats have their format set, I.E. int64 and double, and []byte is a string with the byte format.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) OpenAPISchema() ([]byte, error) {
+	s.checkMade("Cannot create schema if struct has not been made")
+	var exporter = &schemaExporter{tag: s.tag, openAPI: true, seen: make(map[reflect.Type]bool)}
+	var schema, err = exporter.schema(s.sstruct)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(schema)
+}
+
+func (e *schemaExporter) schema(typ reflect.Type) (*exportedSchema, error) {
+	if typ.Kind() == reflect.Ptr {
+		var schema, err = e.schema(typ.Elem())
+		if err != nil || schema.Type == nil {
+			return schema, err
+		}
+		if e.openAPI {
+			schema.Nullable = true
+		} else {
+			schema.Type = []interface{}{schema.Type, "null"}
+		}
+		return schema, nil
+	}
+	if typ == timeType {
+		return &exportedSchema{Type: "string", Format: "date-time"}, nil
+	}
+	if reflect.PtrTo(typ).Implements(jsonMarshalerType) {
+		return e.marshalerSchema(typ), nil
+	}
+	if typ.Implements(textMarshalerType) || reflect.PtrTo(typ).Implements(textMarshalerType) {
+		return &exportedSchema{Type: "string"}, nil
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return &exportedSchema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var schema = &exportedSchema{Type: "integer"}
+		if e.openAPI {
+			switch typ.Kind() {
+			case reflect.Int32:
+				schema.Format = "int32"
+			case reflect.Int64:
+				schema.Format = "int64"
+			}
+		}
+		return schema, nil
+	case reflect.Float32, reflect.Float64:
+		var schema = &exportedSchema{Type: "number"}
+		if e.openAPI {
+			schema.Format = map[reflect.Kind]string{reflect.Float32: "float", reflect.Float64: "double"}[typ.Kind()]
+		}
+		return schema, nil
+	case reflect.String:
+		return &exportedSchema{Type: "string"}, nil
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 && typ.Kind() == reflect.Slice {
+			if e.openAPI {
+				return &exportedSchema{Type: "string", Format: "byte"}, nil
+			}
+			return &exportedSchema{Type: "string", ContentEncoding: "base64"}, nil
+		}
+		var items, err = e.schema(typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &exportedSchema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		var values, err = e.schema(typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &exportedSchema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.Interface:
+		return &exportedSchema{}, nil
+	case reflect.Struct:
+		if e.seen[typ] {
+			// Recursive types cannot be expanded, any value is allowed.
+			return &exportedSchema{}, nil
+		}
+		e.seen[typ] = true
+		defer delete(e.seen, typ)
+		var schema = &exportedSchema{Type: "object", Properties: schemaProperties{}}
+		if err := e.properties(typ, schema); err != nil {
+			return nil, err
+		}
+		return schema, nil
+	}
+	return nil, fmt.Errorf("Cannot create schema for type %s", typ.String())
+}
+
+// marshalerSchema returns the schema of the JSON written by a type implementing json.Marshaler.
+//
+// The types of this package are described exactly, any value is allowed for other types.
+func (e *schemaExporter) marshalerSchema(typ reflect.Type) *exportedSchema {
+	var object = func(required bool, properties ...schemaProperty) *exportedSchema {
+		var schema = &exportedSchema{Type: "object", Properties: properties}
+		if required {
+			for _, property := range properties {
+				schema.Required = append(schema.Required, property.name)
+			}
+		}
+		return schema
+	}
+	var number = &exportedSchema{Type: "number"}
+	if e.openAPI {
+		number.Format = "double"
+	}
+	switch typ {
+	case moneyType:
+		return object(true,
+			schemaProperty{name: "amount", schema: &exportedSchema{Type: "string"}},
+			schemaProperty{name: "currency", schema: &exportedSchema{Type: "string"}},
+		)
+	case quantityType:
+		return object(true,
+			schemaProperty{name: "value", schema: number},
+			schemaProperty{name: "unit", schema: &exportedSchema{Type: "string"}},
+		)
+	case geoPointType:
+		return object(true,
+			schemaProperty{name: "type", schema: &exportedSchema{Type: "string", Enum: json.RawMessage(`["Point"]`)}},
+			schemaProperty{name: "coordinates", schema: &exportedSchema{Type: "array", Items: number, MinItems: 2}},
+		)
+	case boundingBoxType:
+		return &exportedSchema{Type: "array", Items: number, MinItems: 4, MaxItems: 4}
+	case blobType.Elem():
+		var data = &exportedSchema{Type: "string", ContentEncoding: "base64"}
+		if e.openAPI {
+			data = &exportedSchema{Type: "string", Format: "byte"}
+		}
+		return object(false,
+			schemaProperty{name: "ref", schema: &exportedSchema{Type: "string"}},
+			schemaProperty{name: "data", schema: data},
+		)
+	}
+	return &exportedSchema{}
+}
+
+// flagsSchema returns the schema of a flags field which is marshaled as an array of names.
+func flagsSchema(names []string) (*exportedSchema, error) {
+	var enum, err = json.Marshal(names)
+	if err != nil {
+		return nil, err
+	}
+	return &exportedSchema{Type: "array", Items: &exportedSchema{Type: "string", Enum: enum}}, nil
+}
+
+// properties adds the fields of the struct type to the schema, fields of embedded structs are inlined.
+func (e *schemaExporter) properties(typ reflect.Type, schema *exportedSchema) error {
+	for i := 0; i < typ.NumField(); i++ {
+		var field = typ.Field(i)
+		var tag = field.Tag.Get(e.tag)
+		if tag == "-" || (field.PkgPath != "" && !field.Anonymous) {
+			continue
+		}
+		if name, _, _ := strings.Cut(tag, ","); field.Anonymous && field.Type.Kind() == reflect.Struct && name == "" {
+			if err := e.properties(field.Type, schema); err != nil {
+				return err
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		var property, err = e.schema(field.Type)
+		if names, asStrings := flagNames(field); field.Type == flagsType && asStrings {
+			property, err = flagsSchema(names)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %s", field.Name, err)
+		}
+		if description, ok := field.Tag.Lookup("description"); ok {
+			property.Description = description
+		}
+		if format, ok := field.Tag.Lookup("format"); ok {
+			property.Format = format
+		}
+		if enum, ok := field.Tag.Lookup("enum"); ok {
+			if !json.Valid([]byte(enum)) {
+				return fmt.Errorf("%s: invalid enum tag", field.Name)
+			}
+			property.Enum = json.RawMessage(enum)
+		}
+
+		var name = encName(field, e.tag)
+		schema.Properties = append(schema.Properties, schemaProperty{name: name, schema: property})
+		if IsRequired(field) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return nil
+}
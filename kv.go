@@ -0,0 +1,164 @@
+package structs
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ToKV flattens the struct into a map of slash separated paths to string values.
+//
+// Nested structs are flattened recursively, the encoding names of the fields are used as path segments.
+//
+// This is useful for storing the struct in key/value stores like etcd or Consul.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) ToKV(prefix string) map[string]string {
+	s.checkMade("Cannot convert to key/value pairs if struct has not been made")
+	var kv = make(map[string]string)
+	if err := toKV(s.readable(), s.tag, strings.TrimSuffix(prefix, "/"), "/", kv); err != nil {
+		panic(err)
+	}
+	return kv
+}
+
+// FromKV sets the fields of the struct from a map of slash or dot separated paths to string values,
+// I.E. db/host or db.host.
+//
+// Only keys starting with the prefix are considered, keys which do not match a field are ignored.
+// Changed fields are set through SetField. If any value cannot be parsed or set, an error is returned
+// and the struct is left unchanged.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) FromKV(prefix string, kv map[string]string) error {
+	s.checkMade("Cannot set from key/value pairs if struct has not been made")
+	var v = s.decodeTarget()
+	var err = fromKV(v, s.tag, strings.TrimRight(prefix, "/."), "/", func(path string) (string, bool) {
+		if value, ok := kv[path]; ok {
+			return value, true
+		}
+		var value, ok = kv[strings.ReplaceAll(path, "/", ".")]
+		return value, ok
+	})
+	if err != nil {
+		return err
+	}
+	return s.setDecoded(v)
+}
+
+func kvPath(prefix, name, sep string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + sep + name
+}
+
+func toKV(v reflect.Value, tag, prefix, sep string, kv map[string]string) error {
+	return walkKV(v, tag, prefix, sep, func(path string, field reflect.StructField, value reflect.Value) error {
+		var str, err = formatValue(value)
+		if err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+		kv[path] = str
+		return nil
+	})
+}
+
+// walkKV calls fn for every leaf field of the struct value in declaration order, recursing into nested structs.
+//
+// Leaves below a redacted field are passed to fn with the redacted field, so redaction applies to the whole subtree.
+// Callers must therefore use the type of the value, not the type of the field.
+func walkKV(v reflect.Value, tag, prefix, sep string, fn func(path string, field reflect.StructField, value reflect.Value) error) error {
+	for i := 0; i < v.NumField(); i++ {
+		var field = v.Type().Field(i)
+		if !field.IsExported() || field.Tag.Get(tag) == "-" {
+			continue
+		}
+		var path = kvPath(prefix, encName(field, tag), sep)
+		if !isTextType(field.Type) {
+			var inner = fn
+			if IsRedacted(field) {
+				inner = func(path string, _ reflect.StructField, value reflect.Value) error {
+					return fn(path, field, value)
+				}
+			}
+			if err := walkKV(v.Field(i), tag, path, sep, inner); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(path, field, v.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fromKV(v reflect.Value, tag, prefix, sep string, lookup func(path string) (string, bool)) error {
+	for i := 0; i < v.NumField(); i++ {
+		var field = v.Type().Field(i)
+		if !field.IsExported() || field.Tag.Get(tag) == "-" {
+			continue
+		}
+		var path = kvPath(prefix, encName(field, tag), sep)
+		if !isTextType(field.Type) {
+			if err := fromKV(v.Field(i), tag, path, sep, lookup); err != nil {
+				return err
+			}
+			continue
+		}
+		var str, ok = lookup(path)
+		if !ok {
+			continue
+		}
+		var value, err = parseValue(str, field.Type)
+		if err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+		v.Field(i).Set(value)
+	}
+	return nil
+}
+
+// Flatten returns the values of the struct in a map keyed by paths, I.E. address.city for a delimiter of ".".
+//
+// Nested structs are flattened recursively, the encoding names of the fields are used as path segments.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) Flatten(delim string) map[string]interface{} {
+	s.checkMade("Cannot flatten if struct has not been made")
+	var flat = make(map[string]interface{})
+	walkKV(s.readable(), s.tag, "", delim, func(path string, field reflect.StructField, value reflect.Value) error {
+		flat[path] = value.Interface()
+		return nil
+	})
+	return flat
+}
+
+// Unflatten sets the fields of the struct from a map keyed by paths, as returned by Flatten.
+//
+// Values are converted to the type of the field as with SetFromMap, keys which do not match a field are ignored.
+// Changed fields are set through SetField. If any value cannot be converted or set, an error is returned
+// and the struct is left unchanged.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) Unflatten(flat map[string]interface{}, delim string) error {
+	s.checkMade("Cannot unflatten if struct has not been made")
+	var v = s.decodeTarget()
+	var err = walkKV(v, s.tag, "", delim, func(path string, field reflect.StructField, value reflect.Value) error {
+		var flatValue, ok = flat[path]
+		if !ok {
+			return nil
+		}
+		var converted, err = convertTo(flatValue, value.Type(), s.tag)
+		if err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+		value.Set(converted)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return s.setDecoded(v)
+}
@@ -0,0 +1,93 @@
+package structs
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+// Lerp returns a copy of a with its numeric and time.Time fields linearly interpolated towards b,
+// where t = 0 returns the values of a and t = 1 the values of b.
+//
+// Integers are rounded to the nearest value, nested structs are interpolated recursively,
+// and pointers are interpolated if they are set in both structs. All other fields are copied from a.
+//
+// The returned struct has the policy of a. Fields which the policy of b does not allow to be read are copied from a.
+//
+// An error is returned if the structs do not have the same fields, or if an interpolated integer overflows.
+//
+// It will panic if either struct has not been made.
+func Lerp(a, b *Struct, t float64) (*Struct, error) {
+	a.checkMade("Cannot interpolate if struct has not been made")
+	b.checkMade("Cannot interpolate if struct has not been made")
+	if a.sstruct != b.sstruct {
+		return nil, fmt.Errorf("Cannot interpolate between structs with different fields")
+	}
+	var s = a.copySchema()
+	s.Make()
+	s.policy, s.policyCtx = a.policy, a.policyCtx
+	s.structValue.Set(a.structValue)
+	for i := 0; i < s.sstruct.NumField(); i++ {
+		var field = s.sstruct.Field(i)
+		var from, to = a.structValue.Field(i), b.structValue.Field(i)
+		if b.policy != nil && !b.policy.Allow(b.policyCtx, ActionRead, field.Name, to.Interface()) {
+			continue
+		}
+		if err := lerpValue(s.structValue.Field(i), from, to, t); err != nil {
+			return nil, fmt.Errorf("%s: %s", field.Name, err)
+		}
+	}
+	return s, nil
+}
+
+func lerpValue(dst, a, b reflect.Value, t float64) error {
+	if a.Type() == timeType {
+		var from, to = a.Interface().(time.Time), b.Interface().(time.Time)
+		var delta = to.Sub(from)
+		dst.Set(reflect.ValueOf(from.Add(time.Duration(math.Round(float64(delta) * t)))))
+		return nil
+	}
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var from, to = float64(a.Int()), float64(b.Int())
+		var r = math.Round(from + (to-from)*t)
+		if r < math.MinInt64 || r >= math.MaxInt64 || dst.OverflowInt(int64(r)) {
+			return fmt.Errorf("Interpolated value %v overflows %s", r, a.Type().String())
+		}
+		dst.SetInt(int64(r))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var from, to = float64(a.Uint()), float64(b.Uint())
+		var r = math.Round(from + (to-from)*t)
+		if r < 0 || r >= math.MaxUint64 || dst.OverflowUint(uint64(r)) {
+			return fmt.Errorf("Interpolated value %v overflows %s", r, a.Type().String())
+		}
+		dst.SetUint(uint64(r))
+	case reflect.Float32, reflect.Float64:
+		var from, to = a.Float(), b.Float()
+		dst.SetFloat(from + (to-from)*t)
+	case reflect.Ptr:
+		if a.IsNil() || b.IsNil() {
+			return nil
+		}
+		var elem = reflect.New(a.Type().Elem())
+		elem.Elem().Set(a.Elem())
+		if err := lerpValue(elem.Elem(), a.Elem(), b.Elem(), t); err != nil {
+			return err
+		}
+		dst.Set(elem)
+	case reflect.Struct:
+		if isTextType(a.Type()) {
+			return nil
+		}
+		for i := 0; i < a.NumField(); i++ {
+			if !a.Type().Field(i).IsExported() {
+				continue
+			}
+			if err := lerpValue(dst.Field(i), a.Field(i), b.Field(i), t); err != nil {
+				return fmt.Errorf("%s: %s", a.Type().Field(i).Name, err)
+			}
+		}
+	}
+	return nil
+}
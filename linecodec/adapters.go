@@ -0,0 +1,28 @@
+package linecodec
+
+// NewVCard returns a codec for vCard 4.0 records (RFC 6350).
+//
+// Register the fields of the struct on the returned codec, I.E.
+//
+//	var codec = linecodec.NewVCard().
+//		Register("Name", "FN", nil, nil).
+//		Register("Emails", "EMAIL", nil, map[string]string{"TYPE": "work"})
+func NewVCard() *Codec {
+	var c = New("VCARD")
+	c.Header = []Line{{Name: "VERSION", Value: "4.0"}}
+	return c
+}
+
+// NewICal returns a codec for iCalendar events (RFC 5545).
+//
+// The struct is written as a single VEVENT inside of a VCALENDAR, with the given product identifier.
+//
+// time.Time fields are written as UTC date-times by default, register them with Date for all-day events.
+func NewICal(prodID string) *Codec {
+	var c = New("VCALENDAR", "VEVENT")
+	c.Header = []Line{
+		{Name: "VERSION", Value: "2.0"},
+		{Name: "PRODID", Value: prodID},
+	}
+	return c
+}
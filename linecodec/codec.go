@@ -0,0 +1,199 @@
+package linecodec
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/Nigel2392/go-structs"
+)
+
+type property struct {
+	field  string
+	name   string
+	params map[string]string
+	codec  ValueCodec
+}
+
+// matches reports whether the line belongs to the property.
+func (p *property) matches(line Line) bool {
+	if line.Name != p.name {
+		return false
+	}
+	for key, value := range p.params {
+		if !strings.EqualFold(line.Params[key], value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Codec encodes and decodes a struct as a line based record.
+//
+// A record is wrapped in BEGIN and END lines for every component, I.E. BEGIN:VCARD.
+type Codec struct {
+	// The nested components the record is wrapped in, outermost first.
+	Components []string
+
+	// Lines which are written after the outermost BEGIN line, like VERSION:4.0.
+	//
+	// They are ignored when decoding.
+	Header []Line
+
+	properties []*property
+}
+
+// New returns a codec which wraps records in the given components.
+func New(components ...string) *Codec {
+	return &Codec{
+		Components: components,
+		properties: make([]*property, 0),
+	}
+}
+
+// Register maps the field of the struct to the property with the given name.
+//
+// If the value codec is nil, Text is used, or DateTime for time.Time fields.
+//
+// Parameters are written with the property, and lines must carry the same parameters to be decoded into the field.
+//
+// Slice fields are written as one line per element.
+func (c *Codec) Register(field, name string, codec ValueCodec, params map[string]string) *Codec {
+	if field == "" || name == "" {
+		panic("Field and property name cannot be empty")
+	}
+	var upper = make(map[string]string, len(params))
+	for key, value := range params {
+		upper[strings.ToUpper(key)] = value
+	}
+	c.properties = append(c.properties, &property{
+		field:  field,
+		name:   strings.ToUpper(name),
+		params: upper,
+		codec:  codec,
+	})
+	return c
+}
+
+func valueCodec(p *property, typ reflect.Type) ValueCodec {
+	if p.codec != nil {
+		return p.codec
+	}
+	if typ == timeType {
+		return DateTime
+	}
+	return Text
+}
+
+func isRepeated(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Slice && typ.Elem().Kind() != reflect.Uint8
+}
+
+// Encode writes the struct to w as a single record.
+//
+// It will panic if the struct has not been made.
+func (c *Codec) Encode(w io.Writer, s *structs.Struct) error {
+	var lines = make([]Line, 0)
+	for _, component := range c.Components {
+		lines = append(lines, Line{Name: "BEGIN", Value: component})
+		if len(lines) == 1 {
+			lines = append(lines, c.Header...)
+		}
+	}
+	for _, p := range c.properties {
+		var field, err = s.ReadField(p.field)
+		if err != nil {
+			return err
+		}
+		var values = []reflect.Value{field}
+		if isRepeated(field.Type()) {
+			values = values[:0]
+			for i := 0; i < field.Len(); i++ {
+				values = append(values, field.Index(i))
+			}
+		}
+		for _, value := range values {
+			if value.IsZero() {
+				continue
+			}
+			var str, err = valueCodec(p, value.Type()).Encode(value)
+			if err != nil {
+				return fmt.Errorf("%s: %s", p.field, err)
+			}
+			lines = append(lines, Line{Name: p.name, Params: p.params, Value: str})
+		}
+	}
+	for i := len(c.Components) - 1; i >= 0; i-- {
+		lines = append(lines, Line{Name: "END", Value: c.Components[i]})
+	}
+	return WriteLines(w, lines...)
+}
+
+// Decode reads a single record from r into the struct.
+//
+// Lines which do not belong to a registered property are ignored.
+// The fields are set through Struct.DecodeWith, so the struct is left unchanged if decoding fails.
+//
+// It will panic if the struct has not been made.
+func (c *Codec) Decode(r io.Reader, s *structs.Struct) error {
+	var lines, err = ReadLines(r)
+	if err != nil {
+		return err
+	}
+	return s.DecodeWith(func(v reflect.Value) error {
+		return c.decodeLines(lines, v)
+	})
+}
+
+func (c *Codec) decodeLines(lines []Line, v reflect.Value) error {
+	var depth int
+	for _, line := range lines {
+		switch line.Name {
+		case "BEGIN":
+			if depth >= len(c.Components) || !strings.EqualFold(line.Value, c.Components[depth]) {
+				return fmt.Errorf("Unexpected BEGIN:%s", line.Value)
+			}
+			depth++
+			continue
+		case "END":
+			if depth == 0 || !strings.EqualFold(line.Value, c.Components[depth-1]) {
+				return fmt.Errorf("Unexpected END:%s", line.Value)
+			}
+			depth--
+			continue
+		}
+		for _, p := range c.properties {
+			if !p.matches(line) {
+				continue
+			}
+			if err := c.decodeProperty(v, p, line); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	if depth != 0 {
+		return fmt.Errorf("Missing END:%s", c.Components[depth-1])
+	}
+	return nil
+}
+
+func (c *Codec) decodeProperty(v reflect.Value, p *property, line Line) error {
+	var field = v.FieldByName(p.field)
+	if !field.IsValid() {
+		return fmt.Errorf("Field %s does not exist", p.field)
+	}
+	if !isRepeated(field.Type()) {
+		if err := valueCodec(p, field.Type()).Decode(line.Value, field); err != nil {
+			return fmt.Errorf("%s: %s", p.field, err)
+		}
+		return nil
+	}
+	var elem = reflect.New(field.Type().Elem()).Elem()
+	if err := valueCodec(p, elem.Type()).Decode(line.Value, elem); err != nil {
+		return fmt.Errorf("%s: %s", p.field, err)
+	}
+	field.Set(reflect.Append(field, elem))
+	return nil
+}
@@ -0,0 +1,160 @@
+// Package linecodec encodes and decodes structs.Struct values to line based formats,
+// where every property is written as a NAME;PARAM=VALUE:VALUE content line.
+//
+// Properties are registered per field on a Codec.
+//
+// Adapters for vCard and iCalendar are provided by NewVCard and NewICal.
+package linecodec
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// The maximum length of a content line in octets, before it is folded.
+const FoldWidth = 75
+
+// Line is a single unfolded content line.
+type Line struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// String returns the unfolded representation of the line.
+//
+// Parameters are written in sorted order.
+func (l Line) String() string {
+	var b strings.Builder
+	b.WriteString(strings.ToUpper(l.Name))
+	var keys = make([]string, 0, len(l.Params))
+	for key := range l.Params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		b.WriteByte(';')
+		b.WriteString(strings.ToUpper(key))
+		b.WriteByte('=')
+		var value = l.Params[key]
+		if strings.ContainsAny(value, ";:,") {
+			value = `"` + value + `"`
+		}
+		b.WriteString(value)
+	}
+	b.WriteByte(':')
+	b.WriteString(l.Value)
+	return b.String()
+}
+
+// ParseLine parses a single unfolded content line.
+func ParseLine(s string) (Line, error) {
+	var line = Line{Params: make(map[string]string)}
+	var quoted bool
+	var start, colon = 0, -1
+	var parts = make([]string, 0)
+	for i := 0; i < len(s) && colon < 0; i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case ';':
+			if !quoted {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		case ':':
+			if !quoted {
+				parts = append(parts, s[start:i])
+				colon = i
+			}
+		}
+	}
+	if colon < 0 {
+		return line, fmt.Errorf("Missing ':' in line %q", s)
+	}
+	line.Name = strings.ToUpper(parts[0])
+	if line.Name == "" {
+		return line, fmt.Errorf("Missing property name in line %q", s)
+	}
+	for _, param := range parts[1:] {
+		var key, value, ok = strings.Cut(param, "=")
+		if !ok {
+			return line, fmt.Errorf("Invalid parameter %q in line %q", param, s)
+		}
+		line.Params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+	line.Value = s[colon+1:]
+	return line, nil
+}
+
+// Fold splits the line into multiple physical lines of at most width octets,
+// continuation lines start with a single space.
+//
+// Lines are never split inside of a multi-byte UTF-8 character.
+func Fold(s string, width int) string {
+	if len(s) <= width {
+		return s
+	}
+	var b strings.Builder
+	var limit = width
+	for len(s) > limit {
+		var cut = limit
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		// The leading space counts towards the width of continuation lines.
+		limit = width - 1
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// ReadLines reads all content lines from r, unfolding continuation lines.
+func ReadLines(r io.Reader) ([]Line, error) {
+	var lines = make([]Line, 0)
+	var scanner = bufio.NewScanner(r)
+	var current strings.Builder
+	var flush = func() error {
+		if current.Len() == 0 {
+			return nil
+		}
+		var line, err = ParseLine(current.String())
+		if err != nil {
+			return err
+		}
+		lines = append(lines, line)
+		current.Reset()
+		return nil
+	}
+	for scanner.Scan() {
+		var text = strings.TrimRight(scanner.Text(), "\r")
+		if strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t") {
+			current.WriteString(text[1:])
+			continue
+		}
+		if err := flush(); err != nil {
+			return nil, err
+		}
+		current.WriteString(text)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lines, flush()
+}
+
+// WriteLines writes the content lines to w, folding and terminating every line with CRLF.
+func WriteLines(w io.Writer, lines ...Line) error {
+	for _, line := range lines {
+		if _, err := io.WriteString(w, Fold(line.String(), FoldWidth)+"\r\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package linecodec_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Nigel2392/go-structs"
+	"github.com/Nigel2392/go-structs/linecodec"
+)
+
+func TestVCard(t *testing.T) {
+	var s = structs.New("json")
+	s.StringField("Name", "name")
+	s.SliceField("Emails", "emails", reflect.TypeOf(""))
+	s.StringField("Note", "note")
+	s.Make()
+	s.SetField("Name", "Nigel; the second")
+	s.SetField("Emails", []string{"a@example.com", "b@example.com"})
+	s.SetField("Note", strings.Repeat("long note, ", 10))
+
+	var codec = linecodec.NewVCard().
+		Register("Name", "FN", nil, nil).
+		Register("Emails", "EMAIL", nil, map[string]string{"TYPE": "work"}).
+		Register("Note", "NOTE", nil, nil)
+
+	var buf bytes.Buffer
+	if err := codec.Encode(&buf, s); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		if len(line) > linecodec.FoldWidth {
+			t.Errorf("Line %q was not folded", line)
+		}
+	}
+
+	var v = s.DeepCopy()
+	v.Make()
+	if err := codec.Decode(&buf, v); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(s.Interface(), v.Interface()) {
+		t.Errorf("Expected %v, got %v", s.Interface(), v.Interface())
+	}
+}
+
+func TestICal(t *testing.T) {
+	var s = structs.New("json")
+	s.StringField("Summary", "summary")
+	s.AddField("Start", "start", reflect.TypeOf(time.Time{}))
+	s.Make()
+
+	var src = "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VEVENT\r\nSUMMARY:Meet\r\n ing\r\nDTSTART:20230102T150405Z\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	var codec = linecodec.NewICal("-//go-structs//EN").
+		Register("Summary", "SUMMARY", nil, nil).
+		Register("Start", "DTSTART", nil, nil)
+	if err := codec.Decode(strings.NewReader(src), s); err != nil {
+		t.Fatal(err)
+	}
+	if s.GetField("Summary") != "Meeting" {
+		t.Errorf("Expected %s, got %s", "Meeting", s.GetField("Summary"))
+	}
+	var start = time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC)
+	if !s.GetField("Start").(time.Time).Equal(start) {
+		t.Errorf("Expected %s, got %s", start, s.GetField("Start"))
+	}
+}
@@ -0,0 +1,110 @@
+package linecodec
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValueCodec converts a single field value to and from the value of a content line.
+type ValueCodec interface {
+	Encode(v reflect.Value) (string, error)
+	Decode(s string, v reflect.Value) error
+}
+
+var (
+	// Text escapes backslashes, commas, semicolons and newlines as described in RFC 6350 and RFC 5545.
+	//
+	// String, bool, integer and float fields are supported.
+	Text ValueCodec = textCodec{}
+
+	// DateTime formats time.Time fields as UTC date-times, I.E. 20060102T150405Z.
+	DateTime ValueCodec = timeCodec{layout: "20060102T150405Z", utc: true}
+
+	// Date formats time.Time fields as dates, I.E. 20060102.
+	Date ValueCodec = timeCodec{layout: "20060102"}
+)
+
+var (
+	textEscaper   = strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\n", `\n`, "\r", "")
+	textUnescaper = strings.NewReplacer(`\\`, `\`, `\,`, ",", `\;`, ";", `\n`, "\n", `\N`, "\n")
+)
+
+type textCodec struct{}
+
+func (textCodec) Encode(v reflect.Value) (string, error) {
+	switch v.Kind() {
+	case reflect.String:
+		return textEscaper.Replace(v.String()), nil
+	case reflect.Bool:
+		return strings.ToUpper(strconv.FormatBool(v.Bool())), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("Cannot encode value of type %s as text", v.Type().String())
+}
+
+func (textCodec) Decode(s string, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(textUnescaper.Replace(s))
+		return nil
+	case reflect.Bool:
+		var b, err = strconv.ParseBool(strings.ToLower(s))
+		v.SetBool(b)
+		return err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i, err = strconv.ParseInt(s, 10, v.Type().Bits())
+		v.SetInt(i)
+		return err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var i, err = strconv.ParseUint(s, 10, v.Type().Bits())
+		v.SetUint(i)
+		return err
+	case reflect.Float32, reflect.Float64:
+		var f, err = strconv.ParseFloat(s, v.Type().Bits())
+		v.SetFloat(f)
+		return err
+	}
+	return fmt.Errorf("Cannot decode text into value of type %s", v.Type().String())
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+type timeCodec struct {
+	layout string
+	utc    bool
+}
+
+func (c timeCodec) Encode(v reflect.Value) (string, error) {
+	if v.Type() != timeType {
+		return "", fmt.Errorf("Cannot encode value of type %s as a date", v.Type().String())
+	}
+	var t = v.Interface().(time.Time)
+	if c.utc {
+		t = t.UTC()
+	}
+	return t.Format(c.layout), nil
+}
+
+func (c timeCodec) Decode(s string, v reflect.Value) error {
+	if v.Type() != timeType {
+		return fmt.Errorf("Cannot decode a date into value of type %s", v.Type().String())
+	}
+	var t, err = time.Parse(c.layout, s)
+	if err != nil && c.utc {
+		// Floating date-times without a trailing Z are interpreted as UTC.
+		t, err = time.Parse(strings.TrimSuffix(c.layout, "Z"), s)
+	}
+	if err != nil {
+		return err
+	}
+	v.Set(reflect.ValueOf(t))
+	return nil
+}
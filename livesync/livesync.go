@@ -0,0 +1,139 @@
+// Package livesync serializes changes made to a structs.Struct into JSON messages,
+// and applies inbound messages to a struct.
+//
+// It is meant to be used as the server side of live-updating forms,
+// where changes are sent over a WebSocket or Server-Sent Events connection.
+package livesync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/Nigel2392/go-structs"
+)
+
+// Change is a single field change.
+type Change struct {
+	Field string          `json:"field"`
+	Value json.RawMessage `json:"value"`
+	Time  time.Time       `json:"time"`
+}
+
+// Message is a batch of changes, as sent over the wire.
+type Message struct {
+	Changes []Change `json:"changes"`
+}
+
+// Encode returns a message containing all fields which were written after the given time.
+//
+// Use the zero time to encode all fields which have ever been set.
+// Fields which the installed policy does not allow to be read are left out.
+func Encode(s *structs.Struct, since time.Time) ([]byte, error) {
+	var msg = Message{Changes: make([]Change, 0)}
+	for i := 0; i < s.NumField(); i++ {
+		var name = s.Field(i).Name
+		var stamp = s.Stamp(name)
+		if stamp.IsZero() || !stamp.After(since) {
+			continue
+		}
+		var field, err = s.ReadField(name)
+		if errors.Is(err, structs.ErrDenied) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(field.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err)
+		}
+		msg.Changes = append(msg.Changes, Change{
+			Field: name,
+			Value: value,
+			Time:  stamp,
+		})
+	}
+	return json.Marshal(msg)
+}
+
+// Receiver applies inbound messages to a struct.
+type Receiver struct {
+	// Fields which are allowed to be changed.
+	//
+	// If empty, no fields may be changed.
+	Allowed []string
+
+	// Validators which are run for every changed field before any change is applied.
+	Validators structs.ValidatorMap
+}
+
+// Apply decodes the message and applies it to the struct.
+//
+// Either all changes are applied, or none are: the changes are first applied to a copy of the struct,
+// so errors raised by SetField, I.E. by normalizers or the installed policy, are returned before the struct is changed.
+//
+// The time of a change is recorded as its write time, but times in the future or missing times are replaced
+// with the current time, so a client cannot make its changes win every later merge.
+func (r *Receiver) Apply(s *structs.Struct, data []byte) error {
+	if !s.IsValid() {
+		return fmt.Errorf("Cannot apply changes if struct has not been made")
+	}
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	var now = time.Now()
+	var values = make([]interface{}, len(msg.Changes))
+	var times = make([]time.Time, len(msg.Changes))
+	for i, change := range msg.Changes {
+		if !r.allowed(change.Field) {
+			return fmt.Errorf("Field %s is not allowed to be changed", change.Field)
+		}
+		var field, err = s.TryFieldByName(change.Field)
+		if err != nil {
+			return err
+		}
+		var value = reflect.New(field.Type())
+		if err := json.Unmarshal(change.Value, value.Interface()); err != nil {
+			return fmt.Errorf("%s: %s", change.Field, err)
+		}
+		if r.Validators != nil {
+			if err := r.Validators.Validate(change.Field, value.Elem().Interface()); err != nil {
+				return err
+			}
+		}
+		values[i] = value.Elem().Interface()
+		times[i] = change.Time
+		if times[i].IsZero() || times[i].After(now) {
+			times[i] = now
+		}
+	}
+
+	var trial, err = s.TryDeepCopy()
+	if err != nil {
+		return err
+	}
+	for i, change := range msg.Changes {
+		if err := trial.TrySetFieldAt(change.Field, values[i], times[i]); err != nil {
+			return err
+		}
+	}
+	for i, change := range msg.Changes {
+		if err := s.TrySetFieldAt(change.Field, values[i], times[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Receiver) allowed(field string) bool {
+	for _, f := range r.Allowed {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package livesync_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Nigel2392/go-structs"
+	"github.com/Nigel2392/go-structs/livesync"
+)
+
+func newForm() *structs.Struct {
+	var s = structs.New("json")
+	s.StringField("Name", "name")
+	s.QuantityField("Weight", "weight", "kg")
+	s.IntField("Role", "role")
+	s.Make()
+	return s
+}
+
+func TestEncode(t *testing.T) {
+	var s = newForm()
+	var since = time.Now().Add(-time.Minute)
+	s.SetFieldAt("Name", "Nigel", since.Add(time.Second))
+	s.SetFieldAt("Role", 1, since.Add(-time.Second))
+
+	var data, err = livesync.Encode(s, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var other = newForm()
+	if err := (&livesync.Receiver{Allowed: []string{"Name"}}).Apply(other, data); err != nil {
+		t.Fatal(err)
+	}
+	if other.GetField("Name") != "Nigel" || !other.Stamp("Name").Equal(s.Stamp("Name")) {
+		t.Errorf("Expected the change to be applied with its time, got %v at %v", other.GetField("Name"), other.Stamp("Name"))
+	}
+}
+
+func TestEncodeDeniedFields(t *testing.T) {
+	var policy, err = structs.ParseRulePolicy(strings.NewReader("deny read Role\nallow * *"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s = newForm()
+	s.SetField("Name", "Nigel")
+	s.SetField("Role", 1)
+	s.SetPolicy(context.Background(), policy)
+
+	data, err := livesync.Encode(s, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"field":"Name"`) || strings.Contains(string(data), `"field":"Role"`) {
+		t.Errorf("Expected only Name to be encoded, got %s", data)
+	}
+}
+
+func TestApply(t *testing.T) {
+	var s = newForm()
+	var r = &livesync.Receiver{Allowed: []string{"Name", "Weight"}}
+
+	if err := (&livesync.Receiver{}).Apply(s, []byte(`{"changes":[{"field":"Name","value":"Nigel"}]}`)); err == nil {
+		t.Error("Expected no fields to be allowed by default")
+	}
+	if err := r.Apply(s, []byte(`{"changes":[{"field":"Role","value":1}]}`)); err == nil {
+		t.Error("Expected an error for a field which is not allowed")
+	}
+
+	var err = r.Apply(s, []byte(`{"changes":[{"field":"Name","value":"Nigel"},{"field":"Weight","value":{"value":1,"unit":"m"}}]}`))
+	if err == nil {
+		t.Error("Expected an error for a quantity in the wrong dimension")
+	}
+	if s.GetField("Name") != "" || !s.Stamp("Name").IsZero() {
+		t.Error("Expected no changes to be applied")
+	}
+
+	var before = time.Now()
+	if err := r.Apply(s, []byte(`{"changes":[{"field":"Name","value":"Nigel","time":"2999-01-01T00:00:00Z"}]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if s.GetField("Name") != "Nigel" || s.Stamp("Name").Year() == 2999 || s.Stamp("Name").Before(before) {
+		t.Errorf("Expected the future time to be replaced with the current time, got %v", s.Stamp("Name"))
+	}
+}
@@ -0,0 +1,121 @@
+package structs
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// The value written in place of redacted fields.
+const Redacted = "[REDACTED]"
+
+// MarshalLogfmt encodes the struct as a single logfmt line, I.E. `name=Nigel age=23`.
+//
+// Keys are the encoding names of the fields, nested structs are separated by a dot.
+//
+// The values of redacted fields, and of all fields nested in them, are replaced with Redacted, see IsRedacted.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) MarshalLogfmt() ([]byte, error) {
+	s.checkMade("Cannot marshal if struct has not been made")
+	var b strings.Builder
+	var err = walkKV(s.readable(), s.tag, "", ".", func(path string, field reflect.StructField, value reflect.Value) error {
+		var str = Redacted
+		if !IsRedacted(field) {
+			var err error
+			if str, err = formatValue(value); err != nil {
+				return fmt.Errorf("%s: %s", path, err)
+			}
+		}
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(path)
+		b.WriteByte('=')
+		if str == "" || strings.ContainsAny(str, " =\"\\") || strings.IndexFunc(str, func(r rune) bool { return r < ' ' }) >= 0 {
+			str = strconv.Quote(str)
+		}
+		b.WriteString(str)
+		return nil
+	})
+	return []byte(b.String()), err
+}
+
+// UnmarshalLogfmt decodes a single logfmt line into the struct.
+//
+// Keys without a value are treated as true, keys which do not match a field are ignored.
+//
+// Redacted fields, and the fields nested in them, are never set.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) UnmarshalLogfmt(data []byte) error {
+	s.checkMade("Cannot unmarshal if struct has not been made")
+	var pairs, err = parseLogfmt(string(data))
+	if err != nil {
+		return err
+	}
+	var v = s.decodeTarget()
+	err = walkKV(v, s.tag, "", ".", func(path string, field reflect.StructField, value reflect.Value) error {
+		var str, ok = pairs[path]
+		if !ok || IsRedacted(field) {
+			return nil
+		}
+		var parsed, err = parseValue(str, value.Type())
+		if err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+		value.Set(parsed)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return s.setDecoded(v)
+}
+
+func parseLogfmt(line string) (map[string]string, error) {
+	var pairs = make(map[string]string)
+	var i int
+	for i < len(line) {
+		if line[i] == ' ' || line[i] == '\t' {
+			i++
+			continue
+		}
+		var start = i
+		for i < len(line) && line[i] != '=' && line[i] != ' ' && line[i] != '\t' {
+			i++
+		}
+		var key = line[start:i]
+		if i >= len(line) || line[i] != '=' {
+			pairs[key] = "true"
+			continue
+		}
+		i++
+		if i < len(line) && line[i] == '"' {
+			var end = i + 1
+			for end < len(line) && line[end] != '"' {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(line) {
+				return nil, fmt.Errorf("Unterminated quoted value for key %s", key)
+			}
+			var value, err = strconv.Unquote(line[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("Invalid quoted value for key %s: %s", key, err)
+			}
+			pairs[key] = value
+			i = end + 1
+			continue
+		}
+		start = i
+		for i < len(line) && line[i] != ' ' && line[i] != '\t' {
+			i++
+		}
+		pairs[key] = line[start:i]
+	}
+	return pairs, nil
+}
@@ -0,0 +1,102 @@
+package structs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+func (s *Struct) touch(name string, at time.Time) {
+	if s.stamps == nil {
+		s.stamps = make(map[string]time.Time)
+	}
+	s.stamps[name] = at
+}
+
+// Stamp returns the time at which the field was last written.
+//
+// The zero time is returned if the field has never been set.
+func (s *Struct) Stamp(name string) time.Time {
+	return s.stamps[name]
+}
+
+// SetFieldAt sets the field like SetField, but records the given time as the write time.
+//
+// This is useful when applying edits which were made on another replica.
+func (s *Struct) SetFieldAt(name string, value interface{}, at time.Time) {
+	s.SetField(name, value)
+	s.touch(name, at)
+}
+
+// MergeLWW merges the other struct into this one using last-writer-wins semantics.
+//
+// For every field, the value with the latest write time is kept. Fields which were never written in either struct are skipped.
+//
+// If both write times are equal, the value whose JSON encoding sorts last wins,
+// so every replica resolves the conflict the same way. An error is returned if a tied value cannot be encoded.
+//
+// The winning values are deep copied, so no memory is shared with the other struct.
+//
+// Both structs must have been made, and must have the same fields.
+func (s *Struct) MergeLWW(other *Struct) error {
+	if !s.made || !other.made {
+		return fmt.Errorf("Cannot merge if struct has not been made")
+	}
+	if s.sstruct.NumField() != other.sstruct.NumField() {
+		return fmt.Errorf("Cannot merge structs with a different amount of fields")
+	}
+	for i := 0; i < s.sstruct.NumField(); i++ {
+		var field = s.sstruct.Field(i)
+		var otherField, ok = other.sstruct.FieldByName(field.Name)
+		if !ok {
+			return fmt.Errorf("Field %s does not exist in other struct", field.Name)
+		}
+		if otherField.Type != field.Type {
+			return fmt.Errorf("Field %s has type %s in other struct, expected %s", field.Name, otherField.Type, field.Type)
+		}
+	}
+	var winners []int
+	for i := 0; i < s.sstruct.NumField(); i++ {
+		var name = s.sstruct.Field(i).Name
+		var ourStamp, theirStamp = s.stamps[name], other.stamps[name]
+		switch {
+		case ourStamp.IsZero() && theirStamp.IsZero():
+			continue
+		case theirStamp.After(ourStamp):
+		case theirStamp.Equal(ourStamp):
+			var wins, err = lwwTieBreak(s.structValue.Field(i), other.structValue.FieldByName(name))
+			if err != nil {
+				return fmt.Errorf("Cannot resolve conflict for field %s: %s", name, err)
+			}
+			if !wins {
+				continue
+			}
+		default:
+			continue
+		}
+		winners = append(winners, i)
+	}
+	var seen = make(map[reflect.Value]reflect.Value)
+	for _, i := range winners {
+		var name = s.sstruct.Field(i).Name
+		s.structValue.Field(i).Set(deepCopyValue(other.structValue.FieldByName(name), seen))
+		s.touch(name, other.stamps[name])
+	}
+	return nil
+}
+
+// lwwTieBreak reports whether theirs should win over ours when both were written at the same time,
+// by comparing their JSON encodings.
+func lwwTieBreak(ours, theirs reflect.Value) (bool, error) {
+	var a, err = json.Marshal(ours.Interface())
+	if err != nil {
+		return false, err
+	}
+	b, err := json.Marshal(theirs.Interface())
+	if err != nil {
+		return false, err
+	}
+	return bytes.Compare(b, a) > 0, nil
+}
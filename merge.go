@@ -0,0 +1,142 @@
+package structs
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// MergeStrategy decides the value of a field when merging, given the current value in dst and the value in src.
+//
+// It can be used as a per-field callback, returning an error aborts the merge.
+type MergeStrategy func(field string, dst, src interface{}) (interface{}, error)
+
+var (
+	// MergeOverwrite replaces every field with the value in src.
+	MergeOverwrite MergeStrategy = func(field string, dst, src interface{}) (interface{}, error) {
+		return src, nil
+	}
+
+	// MergeFillZero only sets fields which hold their zero value in dst.
+	MergeFillZero MergeStrategy = func(field string, dst, src interface{}) (interface{}, error) {
+		if dst == nil || reflect.ValueOf(dst).IsZero() {
+			return src, nil
+		}
+		return dst, nil
+	}
+)
+
+// Merge merges the fields of src into dst, using the strategy to choose the value of each field.
+//
+// Fields are matched by name, fields which are only present in one of the structs are left alone,
+// as are fields which the policy of src does not allow to be read.
+// The chosen values are converted to the type of the field in dst as with SetFromMap, and set through SetField
+// if they differ from the current value.
+//
+// If any value cannot be converted, or a field may not be written, an error is returned and dst is left unchanged.
+//
+// Both structs must have been made.
+func Merge(dst, src *Struct, strategy MergeStrategy) error {
+	if !dst.made || !src.made {
+		return fmt.Errorf("Cannot merge if struct has not been made")
+	}
+	var names []string
+	var values []reflect.Value
+	for i := 0; i < dst.sstruct.NumField(); i++ {
+		var field = dst.sstruct.Field(i)
+		var theirs = src.structValue.FieldByName(field.Name)
+		if !theirs.IsValid() || src.policy != nil && !src.policy.Allow(src.policyCtx, ActionRead, field.Name, theirs.Interface()) {
+			continue
+		}
+		var ours = dst.structValue.Field(i)
+		var value, err = strategy(field.Name, ours.Interface(), theirs.Interface())
+		if err != nil {
+			return fmt.Errorf("%s: %s", field.Name, err)
+		}
+		converted, err := convertTo(value, field.Type, dst.tag)
+		if err != nil {
+			return fmt.Errorf("%s: %s", field.Name, err)
+		}
+		if reflect.DeepEqual(converted.Interface(), ours.Interface()) {
+			continue
+		}
+		if err := catch(func() { dst.checkPolicy(ActionWrite, field.Name, converted.Interface()) }); err != nil {
+			return err
+		}
+		names = append(names, field.Name)
+		values = append(values, converted)
+	}
+	for i, name := range names {
+		dst.SetField(name, values[i].Interface())
+	}
+	return nil
+}
+
+// Conflict is a field which was changed differently in both edits of a three-way merge.
+type Conflict struct {
+	Field  string // The path of the field, with nested fields separated by dots.
+	Base   interface{}
+	Mine   interface{}
+	Theirs interface{}
+}
+
+// Merge3 merges two edits of a common ancestor into a new struct
+//
+// Fields changed in only one of the edits take the changed value, fields changed to the same value in both edits
+// take that value. Fields changed differently in both edits are conflicts, which keep the value of mine.
+// Nested structs without unexported fields or custom marshalling are merged field by field.
+//
+// The merged struct has the policy of mine. Fields which the policy of any of the structs does not allow to be read
+// are not merged, they keep the value of mine and are never reported as conflicts.
+//
+// All structs must have been made and must have the same fields.
+func Merge3(base, mine, theirs *Struct) (*Struct, []Conflict, error) {
+	if !base.made || !mine.made || !theirs.made {
+		return nil, nil, fmt.Errorf("Cannot merge if struct has not been made")
+	}
+	if base.sstruct != mine.sstruct || base.sstruct != theirs.sstruct {
+		return nil, nil, fmt.Errorf("Cannot merge structs with different fields")
+	}
+	var s = mine.copySchema()
+	s.Make()
+	s.policy, s.policyCtx = mine.policy, mine.policyCtx
+	var conflicts []Conflict
+	for i := 0; i < s.sstruct.NumField(); i++ {
+		var name = s.sstruct.Field(i).Name
+		var readable = true
+		for _, st := range []*Struct{base, mine, theirs} {
+			if st.policy != nil && !st.policy.Allow(st.policyCtx, ActionRead, name, st.structValue.Field(i).Interface()) {
+				readable = false
+			}
+		}
+		if !readable {
+			s.structValue.Field(i).Set(mine.structValue.Field(i))
+			continue
+		}
+		merge3Value(s.structValue.Field(i), base.structValue.Field(i), mine.structValue.Field(i), theirs.structValue.Field(i), name, &conflicts)
+	}
+	return s, conflicts, nil
+}
+
+func merge3Value(dst, base, mine, theirs reflect.Value, path string, conflicts *[]Conflict) {
+	if isRebuildable(dst.Type()) {
+		for i := 0; i < dst.NumField(); i++ {
+			var field = dst.Type().Field(i)
+			var fieldPath = field.Name
+			if path != "" {
+				fieldPath = path + "." + field.Name
+			}
+			merge3Value(dst.Field(i), base.Field(i), mine.Field(i), theirs.Field(i), fieldPath, conflicts)
+		}
+		return
+	}
+	var baseValue, mineValue, theirsValue = base.Interface(), mine.Interface(), theirs.Interface()
+	switch {
+	case reflect.DeepEqual(mineValue, baseValue):
+		dst.Set(theirs)
+	case reflect.DeepEqual(theirsValue, baseValue), reflect.DeepEqual(mineValue, theirsValue):
+		dst.Set(mine)
+	default:
+		dst.Set(mine)
+		*conflicts = append(*conflicts, Conflict{Field: path, Base: baseValue, Mine: mineValue, Theirs: theirsValue})
+	}
+}
@@ -0,0 +1,199 @@
+package structs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// ApplyMergePatch applies a JSON Merge Patch (RFC 7386) to the struct
+//
+// Keys are matched to the encoding names or field names, keys which do not match a field or match a derived field are ignored.
+// null zeroes a field or deletes a map key, objects are merged recursively into nested structs, maps and interface{} values,
+// and all other values replace the value of the field, converted as with SetFromMap.
+// Types implementing json.Unmarshaler are decoded from the patched value.
+//
+// If the patch cannot be applied, an error is returned and the struct is left unchanged.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) ApplyMergePatch(data []byte) error {
+	s.checkMade("Cannot apply merge patch if struct has not been made")
+	var decoder = json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var patch interface{}
+	if err := decoder.Decode(&patch); err != nil {
+		return err
+	}
+	var object, ok = patch.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("Merge patch must be a JSON object")
+	}
+
+	var names = mapFields(s.sstruct, s.tag)
+	var values = make(map[string]reflect.Value, len(object))
+	for key, value := range object {
+		var field, ok = names[key]
+		if !ok || s.isDerived(field.Name) {
+			continue
+		}
+		var v = reflect.New(field.Type).Elem()
+		if value != nil {
+			v.Set(s.structValue.FieldByIndex(field.Index))
+			if err := mergeValue(v, value, s.tag); err != nil {
+				return fmt.Errorf("%s: %s", key, err)
+			}
+		}
+		if err := catch(func() { s.checkPolicy(ActionWrite, field.Name, v.Interface()) }); err != nil {
+			return err
+		}
+		if err := assign(reflect.New(field.Type).Elem(), field.Tag, v.Interface()); err != nil {
+			return fmt.Errorf("%s: %s", key, err)
+		}
+		values[field.Name] = v
+	}
+	for name, value := range values {
+		s.SetField(name, value.Interface())
+	}
+	return nil
+}
+
+// mergeValue merges the patch into v, which must be settable.
+//
+// Values shared with the original, like maps and pointers, are copied before they are modified.
+func mergeValue(v reflect.Value, patch interface{}, tag string) error {
+	var object, isObject = patch.(map[string]interface{})
+	if !isObject || reflect.PtrTo(v.Type()).Implements(jsonUnmarshalerType) {
+		return setMergeValue(v, patch, tag)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		var elem = reflect.New(v.Type().Elem())
+		if !v.IsNil() {
+			elem.Elem().Set(v.Elem())
+		}
+		if err := mergeValue(elem.Elem(), patch, tag); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.Struct:
+		if isTextType(v.Type()) {
+			return setMergeValue(v, patch, tag)
+		}
+		var names = mapFields(v.Type(), tag)
+		for key, value := range object {
+			var field, ok = names[key]
+			if !ok {
+				continue
+			}
+			var fieldValue = v.FieldByIndex(field.Index)
+			if value == nil {
+				fieldValue.Set(reflect.Zero(field.Type))
+				continue
+			}
+			if err := mergeValue(fieldValue, value, tag); err != nil {
+				return fmt.Errorf("%s: %s", key, err)
+			}
+		}
+	case reflect.Map:
+		var merged = reflect.MakeMapWithSize(v.Type(), v.Len())
+		var iter = v.MapRange()
+		for iter.Next() {
+			merged.SetMapIndex(iter.Key(), iter.Value())
+		}
+		for key, value := range object {
+			var mapKey, err = convertTo(key, v.Type().Key(), tag)
+			if err != nil {
+				return fmt.Errorf("Invalid key %s: %s", key, err)
+			}
+			if value == nil {
+				merged.SetMapIndex(mapKey, reflect.Value{})
+				continue
+			}
+			var elem = reflect.New(v.Type().Elem()).Elem()
+			if existing := merged.MapIndex(mapKey); existing.IsValid() {
+				elem.Set(existing)
+			}
+			if err := mergeValue(elem, value, tag); err != nil {
+				return fmt.Errorf("%s: %s", key, err)
+			}
+			merged.SetMapIndex(mapKey, elem)
+		}
+		v.Set(merged)
+	case reflect.Interface:
+		var target, _ = v.Interface().(map[string]interface{})
+		v.Set(reflect.ValueOf(mergeGeneric(target, object)))
+	default:
+		return setMergeValue(v, patch, tag)
+	}
+	return nil
+}
+
+func setMergeValue(v reflect.Value, patch interface{}, tag string) error {
+	if reflect.PtrTo(v.Type()).Implements(jsonUnmarshalerType) {
+		var data, err = json.Marshal(patch)
+		if err != nil {
+			return err
+		}
+		var decoded = reflect.New(v.Type())
+		if err := json.Unmarshal(data, decoded.Interface()); err != nil {
+			return err
+		}
+		v.Set(decoded.Elem())
+		return nil
+	}
+	if v.Kind() == reflect.Interface {
+		patch = plainNumbers(patch)
+	}
+	var converted, err = convertTo(patch, v.Type(), tag)
+	if err != nil {
+		return err
+	}
+	v.Set(converted)
+	return nil
+}
+
+// mergeGeneric merges the patch into a decoded JSON object, returning a new object.
+func mergeGeneric(target, patch map[string]interface{}) map[string]interface{} {
+	var merged = make(map[string]interface{}, len(target)+len(patch))
+	for key, value := range target {
+		merged[key] = value
+	}
+	for key, value := range patch {
+		switch value := value.(type) {
+		case nil:
+			delete(merged, key)
+		case map[string]interface{}:
+			var existing, _ = merged[key].(map[string]interface{})
+			merged[key] = mergeGeneric(existing, value)
+		default:
+			merged[key] = plainNumbers(value)
+		}
+	}
+	return merged
+}
+
+// plainNumbers replaces json.Number with float64 in a decoded JSON value, as json.Unmarshal would decode them.
+func plainNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		var f, _ = v.Float64()
+		return f
+	case map[string]interface{}:
+		var m = make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[key] = plainNumbers(value)
+		}
+		return m
+	case []interface{}:
+		var s = make([]interface{}, len(v))
+		for i, value := range v {
+			s[i] = plainNumbers(value)
+		}
+		return s
+	}
+	return v
+}
@@ -0,0 +1,58 @@
+package structs
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SetStringer sets the function used by String to describe the struct.
+//
+// Runtime struct types cannot carry methods, this allows configuring fmt.Stringer behaviour per schema.
+// A nil function restores the default.
+func (s *Struct) SetStringer(stringer func(*Struct) string) {
+	s.stringer = stringer
+}
+
+// SetLess sets the function used by Less and Sort to order structs.
+//
+// A nil function removes the ordering.
+func (s *Struct) SetLess(less func(a, b *Struct) bool) {
+	s.less = less
+}
+
+// String implements fmt.Stringer.
+//
+// If a stringer was set with SetStringer, it is used to describe the struct.
+// Otherwise the struct is written as a logfmt line, with the values of redacted fields replaced.
+func (s *Struct) String() string {
+	if s.stringer != nil {
+		return s.stringer(s)
+	}
+	if !s.made {
+		return "<struct not made>"
+	}
+	var line, err = s.MarshalLogfmt()
+	if err != nil {
+		return fmt.Sprintf("%+v", s.readable().Interface())
+	}
+	return string(line)
+}
+
+// Less reports whether the struct sorts before other, using the function set with SetLess.
+//
+// It will panic if no less function was set.
+func (s *Struct) Less(other *Struct) bool {
+	if s.less == nil {
+		panic("Cannot compare structs if no less function has been set")
+	}
+	return s.less(s, other)
+}
+
+// Sort sorts the structs in place with Less, keeping the order of equal structs.
+//
+// It will panic if any of the structs has no less function set.
+func Sort(structs []*Struct) {
+	sort.SliceStable(structs, func(i, j int) bool {
+		return structs[i].Less(structs[j])
+	})
+}
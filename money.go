@@ -0,0 +1,243 @@
+package structs
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// The number of minor units of currencies which do not use 2 decimals.
+var currencyExponents = map[string]int{
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+}
+
+// CurrencyExponent returns the number of decimals used by the ISO 4217 currency.
+//
+// It defaults to 2 for unknown currencies.
+func CurrencyExponent(currency string) int {
+	if exp, ok := currencyExponents[strings.ToUpper(currency)]; ok {
+		return exp
+	}
+	return 2
+}
+
+// Money is an amount in the minor units of a currency, I.E. cents.
+//
+// It never uses floating point arithmetic.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// ParseMoney parses a decimal amount like "12.34" or "-0.5" in the given currency.
+//
+// It returns an error if the amount has more decimals than the currency allows.
+func ParseMoney(amount, currency string) (Money, error) {
+	var m = Money{Currency: strings.ToUpper(currency)}
+	var exp = CurrencyExponent(currency)
+	amount = strings.TrimSpace(amount)
+	var negative = strings.HasPrefix(amount, "-")
+	if negative || strings.HasPrefix(amount, "+") {
+		amount = amount[1:]
+	}
+	var whole, frac, _ = strings.Cut(amount, ".")
+	if whole == "" && frac == "" {
+		return m, fmt.Errorf("Invalid amount %q", amount)
+	}
+	if len(frac) > exp {
+		return m, fmt.Errorf("Amount %q has more than %d decimals for currency %s", amount, exp, m.Currency)
+	}
+	var digits = whole + frac + strings.Repeat("0", exp-len(frac))
+	var minor, err = strconv.ParseInt(digits, 10, 64)
+	if err != nil || strings.ContainsAny(digits, "+-") {
+		return m, fmt.Errorf("Invalid amount %q", amount)
+	}
+	if negative {
+		minor = -minor
+	}
+	m.Amount = minor
+	return m, nil
+}
+
+// Decimal returns the amount formatted as a decimal, I.E. "12.34".
+func (m Money) Decimal() string {
+	var exp = CurrencyExponent(m.Currency)
+	var sign string
+	var abs = uint64(m.Amount)
+	if m.Amount < 0 {
+		sign = "-"
+		abs = uint64(-m.Amount)
+	}
+	var digits = strconv.FormatUint(abs, 10)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// String returns the amount followed by the currency, I.E. "12.34 EUR".
+func (m Money) String() string {
+	return strings.TrimSpace(m.Decimal() + " " + m.Currency)
+}
+
+func (m Money) checkCurrency(other Money) error {
+	if m.Currency != other.Currency {
+		return fmt.Errorf("Currency mismatch: %s and %s", m.Currency, other.Currency)
+	}
+	return nil
+}
+
+// Add returns the sum of both amounts, which must be in the same currency.
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.checkCurrency(other); err != nil {
+		return m, err
+	}
+	var sum = m.Amount + other.Amount
+	if (other.Amount > 0 && sum < m.Amount) || (other.Amount < 0 && sum > m.Amount) {
+		return m, fmt.Errorf("Overflow adding %s and %s", m, other)
+	}
+	return Money{Amount: sum, Currency: m.Currency}, nil
+}
+
+// Sub returns the difference of both amounts, which must be in the same currency.
+func (m Money) Sub(other Money) (Money, error) {
+	if other.Amount == math.MinInt64 {
+		return m, fmt.Errorf("Overflow subtracting %s from %s", other, m)
+	}
+	return m.Add(Money{Amount: -other.Amount, Currency: other.Currency})
+}
+
+// Allocate splits the amount according to the ratios, without losing any minor units.
+//
+// The remainder is distributed one unit at a time, starting at the first share.
+func (m Money) Allocate(ratios ...int) ([]Money, error) {
+	// The shares are computed with big integers, as amount * ratio and the total of the ratios may overflow.
+	var total = new(big.Int)
+	for _, ratio := range ratios {
+		if ratio < 0 {
+			return nil, fmt.Errorf("Cannot allocate with negative ratio %d", ratio)
+		}
+		total.Add(total, big.NewInt(int64(ratio)))
+	}
+	if total.Sign() == 0 {
+		return nil, fmt.Errorf("Cannot allocate with a total ratio of 0")
+	}
+	var shares = make([]Money, len(ratios))
+	var remainder = m.Amount
+	var amount = big.NewInt(m.Amount)
+	for i, ratio := range ratios {
+		// The share is at most the amount, so it always fits in an int64.
+		var share = new(big.Int).Mul(amount, big.NewInt(int64(ratio)))
+		share.Quo(share, total)
+		shares[i] = Money{Amount: share.Int64(), Currency: m.Currency}
+		remainder -= shares[i].Amount
+	}
+	var unit int64 = 1
+	if remainder < 0 {
+		unit = -1
+	}
+	for i := 0; remainder != 0; i = (i + 1) % len(shares) {
+		if ratios[i] == 0 {
+			continue
+		}
+		shares[i].Amount += unit
+		remainder -= unit
+	}
+	return shares, nil
+}
+
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON encodes the money as {"amount": "12.34", "currency": "EUR"}.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Decimal(), Currency: m.Currency})
+}
+
+// UnmarshalJSON decodes the format written by MarshalJSON, or a string like "12.34 EUR".
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v moneyJSON
+	if len(data) > 0 && data[0] == '"' {
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return err
+		}
+		v.Amount, v.Currency, _ = strings.Cut(strings.TrimSpace(str), " ")
+	} else if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	var parsed, err = ParseMoney(v.Amount, v.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+var moneyType = reflect.TypeOf(Money{})
+
+// checkCurrency checks the currency of a money field against the currency in its tag.
+//
+// Non-zero amounts without a currency are given the currency of the field, the zero Money is left as is.
+func checkCurrency(field reflect.Value, tag reflect.StructTag) error {
+	var currency = tag.Get("money")
+	if field.Type() != moneyType || currency == "" {
+		return nil
+	}
+	var m = field.Interface().(Money)
+	if m == (Money{}) {
+		return nil
+	}
+	if m.Currency != "" && !strings.EqualFold(m.Currency, currency) {
+		return fmt.Errorf("Currency mismatch: %s and %s", m.Currency, currency)
+	}
+	field.Set(reflect.ValueOf(Money{Amount: m.Amount, Currency: currency}))
+	return nil
+}
+
+// MoneyField adds a field of type Money in the given currency.
+//
+// The currency is stored in the `money` tag of the field, and is used by SetMoney.
+// Setting the field to money in another currency returns an error, money without a currency is given the field's currency.
+func (s *Struct) MoneyField(absolute_name, name, currency string, required ...bool) {
+	if name == "" {
+		name = absolute_name
+	}
+	var tag = fmt.Sprintf(`%s:"%s" money:"%s"`, s.tag, name, strings.ToUpper(currency))
+	if len(required) > 0 && required[0] {
+		tag += ` structs:"required"`
+	}
+	s.AddStructField(reflect.StructField{
+		Name: absolute_name,
+		Tag:  reflect.StructTag(tag),
+		Type: moneyType,
+	})
+}
+
+// SetMoney parses the decimal amount in the currency of the money field, and sets it.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) SetMoney(name, amount string) error {
+	s.checkMade("Cannot set money if struct has not been made")
+	var field, ok = s.sstruct.FieldByName(name)
+	if !ok || field.Type != moneyType {
+		return fmt.Errorf("Field %s is not a money field", name)
+	}
+	var m, err = ParseMoney(amount, field.Tag.Get("money"))
+	if err != nil {
+		return fmt.Errorf("%s: %s", name, err)
+	}
+	s.SetField(name, m)
+	return nil
+}
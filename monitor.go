@@ -0,0 +1,183 @@
+package structs
+
+import (
+	"math"
+	"reflect"
+	"sync"
+)
+
+// Anomaly is an outlier detected by a Monitor.
+type Anomaly struct {
+	Field  string
+	Value  float64 // The observed value, or the change since the previous observation when monitoring the rate of change.
+	Mean   float64
+	StdDev float64
+	Score  float64 // The amount of standard deviations the value is away from the mean.
+}
+
+// fieldStats holds the running mean and variance of a field, using Welford's algorithm.
+type fieldStats struct {
+	count    int
+	mean     float64
+	m2       float64
+	previous float64
+	seen     bool
+}
+
+func (f *fieldStats) add(v float64) {
+	f.count++
+	var delta = v - f.mean
+	f.mean += delta / float64(f.count)
+	f.m2 += delta * (v - f.mean)
+}
+
+func (f *fieldStats) stdDev() float64 {
+	if f.count < 2 {
+		return 0
+	}
+	return math.Sqrt(f.m2 / float64(f.count-1))
+}
+
+// Monitor tracks the numeric fields of a schema across instances, and reports values which are outliers.
+//
+// It is safe for concurrent use.
+type Monitor struct {
+	// The amount of standard deviations a value must be away from the mean to be reported, defaults to 3.
+	Threshold float64
+
+	// The amount of observations needed before values are reported, defaults to 30.
+	MinSamples int
+
+	// Monitor the change between consecutive observations instead of the values themselves.
+	RateOfChange bool
+
+	// Called for every anomaly, if set.
+	OnAnomaly func(Anomaly)
+
+	mu     sync.Mutex
+	fields []string
+	stats  map[string]*fieldStats
+}
+
+// NewMonitor creates a monitor for the numeric fields of the schema, including pointers to numbers.
+//
+// It will panic if the struct has not been made.
+func NewMonitor(schema *Struct, onAnomaly func(Anomaly)) *Monitor {
+	schema.checkMade("Cannot monitor if struct has not been made")
+	var m = &Monitor{
+		OnAnomaly: onAnomaly,
+		stats:     make(map[string]*fieldStats),
+	}
+	for i := 0; i < schema.sstruct.NumField(); i++ {
+		var field = schema.sstruct.Field(i)
+		var typ = field.Type
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if isNumericKind(typ.Kind()) {
+			m.fields = append(m.fields, field.Name)
+			m.stats[field.Name] = &fieldStats{}
+		}
+	}
+	return m
+}
+
+func isNumericKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func numericValue(v reflect.Value) (float64, bool) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return 0, false
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), !math.IsNaN(v.Float())
+	}
+	return 0, false
+}
+
+// Observe checks the monitored fields of the instance against the statistics gathered so far,
+// and then adds its values to them. Nil pointers and NaN are skipped.
+//
+// The anomalies are returned, and passed to OnAnomaly.
+//
+// It will panic if the struct has not been made.
+func (m *Monitor) Observe(s *Struct) []Anomaly {
+	s.checkMade("Cannot observe if struct has not been made")
+	var threshold = m.Threshold
+	if threshold <= 0 {
+		threshold = 3
+	}
+	var minSamples = m.MinSamples
+	if minSamples <= 0 {
+		minSamples = 30
+	}
+
+	m.mu.Lock()
+	var anomalies []Anomaly
+	for _, name := range m.fields {
+		var field = s.structValue.FieldByName(name)
+		if !field.IsValid() {
+			continue
+		}
+		var v, ok = numericValue(field)
+		if !ok {
+			continue
+		}
+		var stats = m.stats[name]
+		if m.RateOfChange {
+			var previous, seen = stats.previous, stats.seen
+			stats.previous, stats.seen = v, true
+			if !seen {
+				continue
+			}
+			v -= previous
+		}
+		if sd := stats.stdDev(); stats.count >= minSamples && sd > 0 {
+			var score = (v - stats.mean) / sd
+			if math.Abs(score) > threshold {
+				anomalies = append(anomalies, Anomaly{
+					Field:  name,
+					Value:  v,
+					Mean:   stats.mean,
+					StdDev: sd,
+					Score:  score,
+				})
+			}
+		}
+		stats.add(v)
+	}
+	m.mu.Unlock()
+
+	if m.OnAnomaly != nil {
+		for _, a := range anomalies {
+			m.OnAnomaly(a)
+		}
+	}
+	return anomalies
+}
+
+// Stats returns the running mean, standard deviation, and amount of observations of the field.
+func (m *Monitor) Stats(field string) (mean, stdDev float64, count int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var stats, ok = m.stats[field]
+	if !ok {
+		return 0, 0, 0
+	}
+	return stats.mean, stats.stdDev(), stats.count
+}
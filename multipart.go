@@ -0,0 +1,150 @@
+package structs
+
+import (
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var fileHeaderType = reflect.TypeOf((*multipart.FileHeader)(nil))
+
+// FileField adds a field of type *multipart.FileHeader, which is set by BindMultipart.
+//
+// If maxSize is larger than 0, files larger than maxSize bytes are rejected.
+//
+// If mimeTypes are given, only files with one of these content types are accepted, wildcards like "image/*" are allowed.
+//
+// The limits are stored in the `file` tag of the field, I.E. `file:"max=1024,types=image/png|image/jpeg"`.
+func (s *Struct) FileField(absolute_name, name string, maxSize int64, mimeTypes []string, required ...bool) {
+	if name == "" {
+		name = absolute_name
+	}
+	var tag = fmt.Sprintf(`%s:"%s"`, s.tag, name)
+	var limits = make([]string, 0, 2)
+	if maxSize > 0 {
+		limits = append(limits, fmt.Sprintf("max=%d", maxSize))
+	}
+	if len(mimeTypes) > 0 {
+		limits = append(limits, "types="+strings.Join(mimeTypes, "|"))
+	}
+	if len(limits) > 0 {
+		tag += fmt.Sprintf(` file:"%s"`, strings.Join(limits, ","))
+	}
+	if len(required) > 0 && required[0] {
+		tag += ` structs:"required"`
+	}
+	s.AddStructField(reflect.StructField{
+		Name: absolute_name,
+		Tag:  reflect.StructTag(tag),
+		Type: fileHeaderType,
+	})
+}
+
+// BindMultipart sets the fields of the struct from a parsed multipart form.
+//
+// Fields are matched by their encoding names. File fields are validated against the limits in their `file` tag,
+// all other fields are parsed from the first form value, or from every value for slices.
+//
+// An error is returned if a required field is missing from the form.
+// Changed fields are set through SetField, if any field cannot be bound or set the struct is left unchanged.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) BindMultipart(form *multipart.Form) error {
+	s.checkMade("Cannot bind if struct has not been made")
+	var v = s.decodeTarget()
+	for i := 0; i < s.sstruct.NumField(); i++ {
+		var field = s.sstruct.Field(i)
+		var name = encName(field, s.tag)
+		if field.Tag.Get(s.tag) == "-" {
+			continue
+		}
+		var value = v.Field(i)
+
+		if field.Type == fileHeaderType || field.Type == reflect.SliceOf(fileHeaderType) {
+			var files = form.File[name]
+			if len(files) == 0 {
+				if IsRequired(field) {
+					return fmt.Errorf("%s: file is required", name)
+				}
+				continue
+			}
+			for _, file := range files {
+				if err := validateFile(field, file); err != nil {
+					return fmt.Errorf("%s: %s", name, err)
+				}
+			}
+			if field.Type == fileHeaderType {
+				value.Set(reflect.ValueOf(files[0]))
+			} else {
+				value.Set(reflect.ValueOf(files))
+			}
+			continue
+		}
+
+		var values = form.Value[name]
+		if len(values) == 0 {
+			if IsRequired(field) {
+				return fmt.Errorf("%s: value is required", name)
+			}
+			continue
+		}
+		if field.Type.Kind() == reflect.Slice && isTextType(field.Type.Elem()) {
+			var slice = reflect.MakeSlice(field.Type, 0, len(values))
+			for _, v := range values {
+				var parsed, err = parseValue(v, field.Type.Elem())
+				if err != nil {
+					return fmt.Errorf("%s: %s", name, err)
+				}
+				slice = reflect.Append(slice, parsed)
+			}
+			value.Set(slice)
+			continue
+		}
+		var parsed, err = parseValue(values[0], field.Type)
+		if err != nil {
+			return fmt.Errorf("%s: %s", name, err)
+		}
+		value.Set(parsed)
+	}
+	return s.setDecoded(v)
+}
+
+func validateFile(field reflect.StructField, file *multipart.FileHeader) error {
+	for _, limit := range strings.Split(field.Tag.Get("file"), ",") {
+		var key, value, _ = strings.Cut(limit, "=")
+		switch key {
+		case "max":
+			var max, err = strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid max size %q", value)
+			}
+			if file.Size > max {
+				return fmt.Errorf("file %s is %d bytes, the maximum is %d bytes", file.Filename, file.Size, max)
+			}
+		case "types":
+			var mediaType, _, err = mime.ParseMediaType(file.Header.Get("Content-Type"))
+			if err != nil {
+				return fmt.Errorf("file %s has an invalid content type", file.Filename)
+			}
+			if !matchMediaType(mediaType, strings.Split(value, "|")) {
+				return fmt.Errorf("file %s has content type %s, which is not allowed", file.Filename, mediaType)
+			}
+		}
+	}
+	return nil
+}
+
+func matchMediaType(mediaType string, allowed []string) bool {
+	for _, a := range allowed {
+		if a == mediaType || a == "*/*" {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(a, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package structs
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// FromRecursive creates a struct like From, but also rebuilds nested struct fields as dynamic structs
+//
+// Fields of a struct type, or a pointer to a struct type, are rebuilt up to maxDepth levels deep.
+// A maxDepth of 0 behaves like From, a negative maxDepth has no limit.
+//
+// Types which are already being rebuilt higher up are kept as-is, so recursive types do not loop.
+// Types with unexported fields, or which implement their own encoding like time.Time, are also kept as-is.
+//
+// The nested structs can be retrieved with Nested, to filter and rewrite their fields.
+func FromRecursive(v interface{}, tag string, maxDepth int) *Struct {
+	var s = From(v, tag)
+	var seen = map[reflect.Type]bool{sourceType(v): true}
+	s.rebuildNested(maxDepth, seen)
+	return s
+}
+
+func sourceType(v interface{}) reflect.Type {
+	switch v := v.(type) {
+	case reflect.Value:
+		return v.Type()
+	case reflect.Type:
+		return v
+	case Struct:
+		return v.sstruct
+	default:
+		return reflect.TypeOf(v)
+	}
+}
+
+func (s *Struct) rebuildNested(depth int, seen map[reflect.Type]bool) {
+	if depth == 0 {
+		return
+	}
+	for _, field := range s.fieldsByName {
+		var typ = field.Type
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if seen[typ] || !isRebuildable(typ) {
+			continue
+		}
+
+		seen[typ] = true
+		var nested = From(typ, s.tag)
+		nested.rebuildNested(depth-1, seen)
+		delete(seen, typ)
+
+		if s.nested == nil {
+			s.nested = make(map[string]*Struct)
+		}
+		s.nested[field.Name] = nested
+	}
+}
+
+// isRebuildable returns whether the type can be rebuilt as a dynamic struct without losing behaviour.
+func isRebuildable(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Struct {
+		return false
+	}
+	var ptr = reflect.PtrTo(typ)
+	if ptr.Implements(textMarshalerType) || ptr.Implements(jsonMarshalerType) {
+		return false
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		if typ.Field(i).PkgPath != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Nested returns the dynamic struct a nested field was rebuilt as by FromRecursive
+//
+// The nested struct describes the type of the field, its values are not shared with the parent.
+// Changes to its fields are applied to the parent the next time the parent is made.
+//
+// It returns nil if the field was not rebuilt.
+func (s *Struct) Nested(name string) *Struct {
+	return s.nested[name]
+}
+
+// syncNested updates the types of nested fields, making the nested structs if needed.
+//
+// The made flag is reset if any type has changed.
+func (s *Struct) syncNested() {
+	if len(s.nested) == 0 {
+		return
+	}
+	var fields []reflect.StructField
+	for i, field := range s.fieldsByName {
+		var nested, ok = s.nested[field.Name]
+		if !ok {
+			continue
+		}
+		if !nested.made {
+			nested.Make()
+		}
+		var typ = nested.sstruct
+		if field.Type.Kind() == reflect.Ptr {
+			typ = reflect.PtrTo(typ)
+		}
+		if field.Type == typ {
+			continue
+		}
+		if fields == nil {
+			fields = make([]reflect.StructField, len(s.fieldsByName))
+			copy(fields, s.fieldsByName)
+		}
+		fields[i].Type = typ
+	}
+	if fields != nil {
+		s.fieldsByName = fields
+		s.made = false
+	}
+}
+
+// copyNested returns a copy of the nested structs, so the copies can be changed independently.
+func (s *Struct) copyNested() map[string]*Struct {
+	if s.nested == nil {
+		return nil
+	}
+	var nested = make(map[string]*Struct, len(s.nested))
+	for name, n := range s.nested {
+		var c = New(n.tag)
+		c.fieldsByName = append([]reflect.StructField(nil), n.fieldsByName...)
+		c.nested = n.copyNested()
+		nested[name] = c
+	}
+	return nested
+}
@@ -0,0 +1,95 @@
+package structs
+
+import (
+	"fmt"
+	"net/netip"
+	"reflect"
+)
+
+var (
+	addrType   = reflect.TypeOf(netip.Addr{})
+	prefixType = reflect.TypeOf(netip.Prefix{})
+)
+
+// IPField adds a field of type netip.Addr.
+//
+// The field can be set with a string like "192.168.1.1", which is parsed by SetField.
+func (s *Struct) IPField(absolute_name, name string, required ...bool) {
+	s.AddField(absolute_name, name, addrType, required...)
+}
+
+// CIDRField adds a field of type netip.Prefix.
+//
+// The field can be set with a string like "10.0.0.0/8", which is parsed by SetField.
+func (s *Struct) CIDRField(absolute_name, name string, required ...bool) {
+	s.AddField(absolute_name, name, prefixType, required...)
+}
+
+// ipAddr returns the address of a netip.Addr or netip.Prefix value.
+func ipAddr(value interface{}) (netip.Addr, error) {
+	switch v := value.(type) {
+	case netip.Addr:
+		if !v.IsValid() {
+			return v, fmt.Errorf("Invalid IP address")
+		}
+		return v, nil
+	case netip.Prefix:
+		if !v.IsValid() {
+			return netip.Addr{}, fmt.Errorf("Invalid CIDR prefix")
+		}
+		return v.Addr(), nil
+	}
+	return netip.Addr{}, fmt.Errorf("Cannot validate value of type %T as an IP address", value)
+}
+
+// ValidateIPv4 is a validator for IPv4 addresses and prefixes, to be used in a ValidatorMap.
+func ValidateIPv4(value interface{}) error {
+	var addr, err = ipAddr(value)
+	if err != nil {
+		return err
+	}
+	if !addr.Is4() {
+		return fmt.Errorf("%s is not an IPv4 address", addr)
+	}
+	return nil
+}
+
+// ValidateIPv6 is a validator for IPv6 addresses and prefixes, to be used in a ValidatorMap.
+//
+// IPv4-mapped IPv6 addresses are not accepted.
+func ValidateIPv6(value interface{}) error {
+	var addr, err = ipAddr(value)
+	if err != nil {
+		return err
+	}
+	if !addr.Is6() || addr.Is4In6() {
+		return fmt.Errorf("%s is not an IPv6 address", addr)
+	}
+	return nil
+}
+
+// ValidatePrivateIP is a validator which only accepts private (RFC 1918 and RFC 4193) and loopback addresses,
+// to be used in a ValidatorMap.
+func ValidatePrivateIP(value interface{}) error {
+	var addr, err = ipAddr(value)
+	if err != nil {
+		return err
+	}
+	if !addr.IsPrivate() && !addr.IsLoopback() {
+		return fmt.Errorf("%s is not a private IP address", addr)
+	}
+	return nil
+}
+
+// ValidatePublicIP is a validator which rejects private, loopback, link-local and unspecified addresses,
+// to be used in a ValidatorMap.
+func ValidatePublicIP(value interface{}) error {
+	var addr, err = ipAddr(value)
+	if err != nil {
+		return err
+	}
+	if addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsUnspecified() {
+		return fmt.Errorf("%s is not a public IP address", addr)
+	}
+	return nil
+}
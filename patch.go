@@ -0,0 +1,176 @@
+package structs
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// PatchOp is a JSON Patch (RFC 6902) operation.
+type PatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// MarshalJSON leaves out the value of remove operations.
+func (p PatchOp) MarshalJSON() ([]byte, error) {
+	if p.Op == "remove" {
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{p.Op, p.Path})
+	}
+	type patchOp PatchOp
+	return json.Marshal(patchOp(p))
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// Diff returns the JSON Patch (RFC 6902) operations which turn the values of a into the values of b
+//
+// Paths are JSON Pointers built from the encoding names in the tag of a, fields tagged "-" are skipped.
+// Nested structs and maps are compared recursively, following pointers and interfaces,
+// other values which differ are replaced as a whole.
+// Fields which are only present in b are added, fields which are only present in a are removed.
+//
+// An error is returned if the structs do not use the same tag.
+//
+// It will panic if either struct has not been made.
+func Diff(a, b *Struct) ([]PatchOp, error) {
+	a.checkMade("Cannot diff if struct has not been made")
+	b.checkMade("Cannot diff if struct has not been made")
+	if a.tag != b.tag {
+		return nil, fmt.Errorf("Cannot diff structs with tags %s and %s", a.tag, b.tag)
+	}
+	var ops []PatchOp
+	diffStruct(&ops, "", a.readable(), b.readable(), a.tag)
+	return ops, nil
+}
+
+func diffValue(ops *[]PatchOp, path string, a, b reflect.Value, tag string) {
+	if a.Type() != b.Type() {
+		*ops = append(*ops, PatchOp{Op: "replace", Path: path, Value: b.Interface()})
+		return
+	}
+	switch {
+	case (a.Kind() == reflect.Ptr || a.Kind() == reflect.Interface) && !a.IsNil() && !b.IsNil():
+		diffValue(ops, path, a.Elem(), b.Elem(), tag)
+	case a.Kind() == reflect.Struct && !isTextType(a.Type()):
+		diffStruct(ops, path, a, b, tag)
+	case a.Kind() == reflect.Map && !a.IsNil() && !b.IsNil():
+		diffMap(ops, path, a, b, tag)
+	case !reflect.DeepEqual(a.Interface(), b.Interface()):
+		*ops = append(*ops, PatchOp{Op: "replace", Path: path, Value: b.Interface()})
+	}
+}
+
+func diffStruct(ops *[]PatchOp, path string, a, b reflect.Value, tag string) {
+	var fieldsA, fieldsB = diffFields(a.Type(), tag), diffFields(b.Type(), tag)
+	for i := 0; i < a.NumField(); i++ {
+		var field = a.Type().Field(i)
+		var name = encName(field, tag)
+		if _, ok := fieldsA[name]; !ok {
+			continue
+		}
+		var fieldPath = path + "/" + pointerEscaper.Replace(name)
+		var other, ok = fieldsB[name]
+		if !ok {
+			*ops = append(*ops, PatchOp{Op: "remove", Path: fieldPath})
+			continue
+		}
+		diffValue(ops, fieldPath, a.Field(i), b.FieldByIndex(other.Index), tag)
+	}
+	for i := 0; i < b.NumField(); i++ {
+		var field = b.Type().Field(i)
+		var name = encName(field, tag)
+		if _, ok := fieldsB[name]; !ok {
+			continue
+		}
+		if _, ok := fieldsA[name]; !ok {
+			*ops = append(*ops, PatchOp{Op: "add", Path: path + "/" + pointerEscaper.Replace(name), Value: b.Field(i).Interface()})
+		}
+	}
+}
+
+// diffFields returns the exported fields of the struct type which are not skipped, by encoding name.
+func diffFields(typ reflect.Type, tag string) map[string]reflect.StructField {
+	var fields = make(map[string]reflect.StructField, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		var field = typ.Field(i)
+		if field.IsExported() && field.Tag.Get(tag) != "-" {
+			fields[encName(field, tag)] = field
+		}
+	}
+	return fields
+}
+
+func diffMap(ops *[]PatchOp, path string, a, b reflect.Value, tag string) {
+	var keys = make(map[string]reflect.Value)
+	for _, key := range append(a.MapKeys(), b.MapKeys()...) {
+		keys[fmt.Sprint(key.Interface())] = key
+	}
+	var names = make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var keyPath = path + "/" + pointerEscaper.Replace(name)
+		var valueA, valueB = a.MapIndex(keys[name]), b.MapIndex(keys[name])
+		switch {
+		case !valueA.IsValid():
+			*ops = append(*ops, PatchOp{Op: "add", Path: keyPath, Value: valueB.Interface()})
+		case !valueB.IsValid():
+			*ops = append(*ops, PatchOp{Op: "remove", Path: keyPath})
+		default:
+			diffValue(ops, keyPath, valueA, valueB, tag)
+		}
+	}
+}
+
+// FieldChange is a field which has a different value in two structs.
+type FieldChange struct {
+	Field string
+	Old   interface{}
+	New   interface{}
+}
+
+// Diff returns the fields whose values differ from the fields of other, in the order of the fields of s,
+// followed by the fields which are only present in other.
+//
+// Old holds the value in s, New the value in other. Fields which are only present in one of the structs
+// have a nil value in the other. Fields the installed policy of either struct does not allow to be read are skipped.
+//
+// It will panic if either struct has not been made.
+func (s *Struct) Diff(other *Struct) []FieldChange {
+	s.checkMade("Cannot diff if struct has not been made")
+	other.checkMade("Cannot diff if struct has not been made")
+	var changes []FieldChange
+	var readable = func(st *Struct, name string, value reflect.Value) bool {
+		return st.policy == nil || st.policy.Allow(st.policyCtx, ActionRead, name, value.Interface())
+	}
+	for i := 0; i < s.sstruct.NumField(); i++ {
+		var name = s.sstruct.Field(i).Name
+		var oldValue, newValue = s.structValue.Field(i), other.structValue.FieldByName(name)
+		if !readable(s, name, oldValue) || newValue.IsValid() && !readable(other, name, newValue) {
+			continue
+		}
+		switch {
+		case !newValue.IsValid():
+			changes = append(changes, FieldChange{Field: name, Old: oldValue.Interface()})
+		case !reflect.DeepEqual(oldValue.Interface(), newValue.Interface()):
+			changes = append(changes, FieldChange{Field: name, Old: oldValue.Interface(), New: newValue.Interface()})
+		}
+	}
+	for i := 0; i < other.sstruct.NumField(); i++ {
+		var name = other.sstruct.Field(i).Name
+		var newValue = other.structValue.Field(i)
+		if _, ok := s.sstruct.FieldByName(name); !ok && readable(other, name, newValue) {
+			changes = append(changes, FieldChange{Field: name, New: newValue.Interface()})
+		}
+	}
+	return changes
+}
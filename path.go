@@ -0,0 +1,216 @@
+package structs
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// GetPath returns the value at a dot separated path, I.E. Address.City or Items.0.Name
+//
+// Segments are field names or encoding names in the struct's tag, map keys, or slice indices,
+// which may also be written as Items[0]. Pointers and interfaces are followed.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) GetPath(path string) (interface{}, error) {
+	s.checkMade("Cannot get path if struct has not been made")
+	return s.getSegments(splitPath(path))
+}
+
+// SetPath sets the value at a dot separated path, see GetPath.
+//
+// Nil pointers and maps along the path are allocated, the value is converted to the type at the path as with SetFromMap.
+// The segment "-" appends an element to a slice.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) SetPath(path string, value interface{}) error {
+	s.checkMade("Cannot set path if struct has not been made")
+	return s.setSegments(splitPath(path), value)
+}
+
+func splitPath(path string) []string {
+	path = strings.ReplaceAll(path, "]", "")
+	path = strings.ReplaceAll(path, "[", ".")
+	return strings.Split(path, ".")
+}
+
+func (s *Struct) topField(segment string) (reflect.StructField, error) {
+	var field, ok = mapFields(s.sstruct, s.tag)[segment]
+	if !ok {
+		return field, fmt.Errorf("Field %s does not exist", segment)
+	}
+	return field, nil
+}
+
+func (s *Struct) getSegments(segments []string) (interface{}, error) {
+	var field, err = s.topField(segments[0])
+	if err != nil {
+		return nil, err
+	}
+	var value = s.structValue.FieldByIndex(field.Index)
+	if err := catch(func() { s.checkPolicy(ActionRead, field.Name, value.Interface()) }); err != nil {
+		return nil, err
+	}
+	value, err = getPath(value, segments[1:], s.tag)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", field.Name, err)
+	}
+	return value.Interface(), nil
+}
+
+func (s *Struct) setSegments(segments []string, value interface{}) error {
+	var field, err = s.topField(segments[0])
+	if err != nil {
+		return err
+	}
+	var v = reflect.New(field.Type).Elem()
+	v.Set(s.structValue.FieldByIndex(field.Index))
+	if err := setPath(v, segments[1:], value, s.tag); err != nil {
+		return fmt.Errorf("%s: %s", field.Name, err)
+	}
+	return catch(func() { s.SetField(field.Name, v.Interface()) })
+}
+
+func getPath(v reflect.Value, segments []string, tag string) (reflect.Value, error) {
+	for i, segment := range segments {
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return v, fmt.Errorf("Nil value at %s", strings.Join(segments[:i], "."))
+			}
+			v = v.Elem()
+		}
+		switch v.Kind() {
+		case reflect.Struct:
+			var field, ok = mapFields(v.Type(), tag)[segment]
+			if !ok {
+				return v, fmt.Errorf("Field %s does not exist", segment)
+			}
+			v = v.FieldByIndex(field.Index)
+		case reflect.Map:
+			var key, err = convertTo(segment, v.Type().Key(), tag)
+			if err != nil {
+				return v, fmt.Errorf("Invalid key %s: %s", segment, err)
+			}
+			var elem = v.MapIndex(key)
+			if !elem.IsValid() {
+				return v, fmt.Errorf("Key %s does not exist", segment)
+			}
+			v = elem
+		case reflect.Slice, reflect.Array:
+			var index, err = sliceIndex(segment, v.Len())
+			if err != nil {
+				return v, err
+			}
+			v = v.Index(index)
+		default:
+			return v, fmt.Errorf("Cannot index %s with %s", v.Type().String(), segment)
+		}
+	}
+	return v, nil
+}
+
+// setPath sets the value at the path below v, which must be settable.
+//
+// Maps, slices and pointers along the path are copied before they are modified, so values shared with v are not changed.
+func setPath(v reflect.Value, segments []string, value interface{}, tag string) error {
+	if len(segments) == 0 {
+		var converted, err = convertTo(value, v.Type(), tag)
+		if err != nil {
+			return err
+		}
+		v.Set(converted)
+		return nil
+	}
+
+	var segment = segments[0]
+	switch v.Kind() {
+	case reflect.Ptr:
+		var elem = reflect.New(v.Type().Elem())
+		if !v.IsNil() {
+			elem.Elem().Set(v.Elem())
+		}
+		if err := setPath(elem.Elem(), segments, value, tag); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	case reflect.Interface:
+		if v.IsNil() {
+			return fmt.Errorf("Nil value at %s", segment)
+		}
+		var elem = reflect.New(v.Elem().Type()).Elem()
+		elem.Set(v.Elem())
+		if err := setPath(elem, segments, value, tag); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	case reflect.Struct:
+		var field, ok = mapFields(v.Type(), tag)[segment]
+		if !ok {
+			return fmt.Errorf("Field %s does not exist", segment)
+		}
+		if err := setPath(v.FieldByIndex(field.Index), segments[1:], value, tag); err != nil {
+			return fmt.Errorf("%s: %s", segment, err)
+		}
+		return nil
+	case reflect.Map:
+		var key, err = convertTo(segment, v.Type().Key(), tag)
+		if err != nil {
+			return fmt.Errorf("Invalid key %s: %s", segment, err)
+		}
+		var elem = reflect.New(v.Type().Elem()).Elem()
+		if existing := v.MapIndex(key); existing.IsValid() {
+			elem.Set(existing)
+		}
+		if err := setPath(elem, segments[1:], value, tag); err != nil {
+			return fmt.Errorf("%s: %s", segment, err)
+		}
+		var copied = reflect.MakeMapWithSize(v.Type(), v.Len()+1)
+		var iter = v.MapRange()
+		for iter.Next() {
+			copied.SetMapIndex(iter.Key(), iter.Value())
+		}
+		copied.SetMapIndex(key, elem)
+		v.Set(copied)
+		return nil
+	case reflect.Slice, reflect.Array:
+		if segment == "-" && v.Kind() == reflect.Slice {
+			var elem = reflect.New(v.Type().Elem()).Elem()
+			if err := setPath(elem, segments[1:], value, tag); err != nil {
+				return fmt.Errorf("%s: %s", segment, err)
+			}
+			var copied = reflect.MakeSlice(v.Type(), v.Len(), v.Len()+1)
+			reflect.Copy(copied, v)
+			v.Set(reflect.Append(copied, elem))
+			return nil
+		}
+		var index, err = sliceIndex(segment, v.Len())
+		if err != nil {
+			return err
+		}
+		var target = v
+		if v.Kind() == reflect.Slice {
+			target = reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+			reflect.Copy(target, v)
+		}
+		if err := setPath(target.Index(index), segments[1:], value, tag); err != nil {
+			return fmt.Errorf("%s: %s", segment, err)
+		}
+		v.Set(target)
+		return nil
+	}
+	return fmt.Errorf("Cannot index %s with %s", v.Type().String(), segment)
+}
+
+func sliceIndex(segment string, length int) (int, error) {
+	var index, err = strconv.Atoi(segment)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid index %s", segment)
+	}
+	if index < 0 || index >= length {
+		return 0, fmt.Errorf("Index %d out of range for length %d", index, length)
+	}
+	return index, nil
+}
@@ -0,0 +1,53 @@
+package structs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GetPointer returns the value referenced by a JSON Pointer (RFC 6901), I.E. /items/0/name
+//
+// Segments are resolved as with GetPath, the empty pointer references the whole struct.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) GetPointer(pointer string) (interface{}, error) {
+	s.checkMade("Cannot get pointer if struct has not been made")
+	if pointer == "" {
+		return s.readable().Interface(), nil
+	}
+	var segments, err = splitPointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	return s.getSegments(segments)
+}
+
+// SetPointer sets the value referenced by a JSON Pointer (RFC 6901), see SetPath.
+//
+// The segment "-" appends an element to a slice. The empty pointer cannot be set.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) SetPointer(pointer string, value interface{}) error {
+	s.checkMade("Cannot set pointer if struct has not been made")
+	if pointer == "" {
+		return fmt.Errorf("Cannot replace the whole struct through a JSON Pointer")
+	}
+	var segments, err = splitPointer(pointer)
+	if err != nil {
+		return err
+	}
+	return s.setSegments(segments, value)
+}
+
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+func splitPointer(pointer string) ([]string, error) {
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("JSON Pointer %q must start with /", pointer)
+	}
+	var segments = strings.Split(pointer[1:], "/")
+	for i, segment := range segments {
+		segments[i] = pointerUnescaper.Replace(segment)
+	}
+	return segments, nil
+}
@@ -0,0 +1,226 @@
+package structs
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// Action is the kind of access a FieldPolicy is asked about.
+type Action string
+
+const (
+	// ActionRead is used by GetField and MarshalJSON.
+	ActionRead Action = "read"
+
+	// ActionWrite is used by SetField and SetFieldByIndex.
+	ActionWrite Action = "write"
+)
+
+// ErrDenied is wrapped by the errors raised when a FieldPolicy denies access to a field.
+var ErrDenied = errors.New("access denied by policy")
+
+// FieldPolicy decides whether a field may be accessed.
+//
+// For writes, value is the value which is about to be set.
+type FieldPolicy interface {
+	Allow(ctx context.Context, action Action, field string, value interface{}) bool
+}
+
+// SetPolicy installs the policy, which is consulted with the given context whenever field values are read or written.
+//
+// GetField and SetField panic with an error wrapping ErrDenied when access is denied, the Try methods return it.
+// Encoders, like MarshalJSON, ToKV, MarshalLogfmt and String, omit fields which may not be read,
+// or return an error wrapping ErrDenied if they need the value of the field.
+// Decoders, like UnmarshalJSON, FromKV and LoadEnv, set the fields they change through SetField,
+// and return an error wrapping ErrDenied, leaving the struct unchanged, if a changed field may not be written.
+// Decoders outside of this package can do the same with DecodeWith and ReadField.
+//
+// Operations on whole structs, like Equal, Hash, DeepCopy, Zero and ErasePersonalData, are not governed.
+//
+// A nil policy removes the installed policy.
+func (s *Struct) SetPolicy(ctx context.Context, policy FieldPolicy) {
+	s.policy = policy
+	s.policyCtx = ctx
+}
+
+// checkPolicy panics if the installed policy denies the action on the field.
+func (s *Struct) checkPolicy(action Action, name string, value interface{}) {
+	if s.policy != nil && !s.policy.Allow(s.policyCtx, action, name, value) {
+		panic(fmt.Errorf("Cannot %s field %s: %w", action, name, ErrDenied))
+	}
+}
+
+// ReadField returns a copy of the value of the field,
+// or an error wrapping ErrDenied if the installed policy does not allow it to be read.
+//
+// This is meant for encoders outside of this package.
+func (s *Struct) ReadField(name string) (reflect.Value, error) {
+	var field, err = s.tryField("read field", name)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if err := catch(func() { s.checkPolicy(ActionRead, name, field.Interface()) }); err != nil {
+		return reflect.Value{}, err
+	}
+	var value = reflect.New(field.Type()).Elem()
+	value.Set(field)
+	return value, nil
+}
+
+// readable returns the value of the struct without the fields the installed policy does not allow to be read.
+func (s *Struct) readable() reflect.Value {
+	if s.policy == nil {
+		return s.structValue
+	}
+	var fields = make([]reflect.StructField, 0, s.sstruct.NumField())
+	var indices = make([]int, 0, s.sstruct.NumField())
+	for i := 0; i < s.sstruct.NumField(); i++ {
+		var field = s.sstruct.Field(i)
+		if s.policy.Allow(s.policyCtx, ActionRead, field.Name, s.structValue.Field(i).Interface()) {
+			fields = append(fields, field)
+			indices = append(indices, i)
+		}
+	}
+	if len(fields) == s.sstruct.NumField() {
+		return s.structValue
+	}
+	var value = reflect.New(reflect.StructOf(fields)).Elem()
+	for j, i := range indices {
+		value.Field(j).Set(s.structValue.Field(i))
+	}
+	return value
+}
+
+// readableOrZero returns the value of the struct with the fields the installed policy does not allow to be read
+// set to their zero value, for encoders which depend on the layout of the struct.
+func (s *Struct) readableOrZero() reflect.Value {
+	if s.policy == nil {
+		return s.structValue
+	}
+	var value = reflect.New(s.sstruct).Elem()
+	for i := 0; i < s.sstruct.NumField(); i++ {
+		if s.policy.Allow(s.policyCtx, ActionRead, s.sstruct.Field(i).Name, s.structValue.Field(i).Interface()) {
+			value.Field(i).Set(s.structValue.Field(i))
+		}
+	}
+	return value
+}
+
+type rolesKey struct{}
+
+// WithRoles returns a context carrying the roles of the subject, as matched by RulePolicy.
+func WithRoles(ctx context.Context, roles ...string) context.Context {
+	return context.WithValue(ctx, rolesKey{}, roles)
+}
+
+// RolesFrom returns the roles set with WithRoles.
+func RolesFrom(ctx context.Context) []string {
+	if ctx == nil {
+		return nil
+	}
+	var roles, _ = ctx.Value(rolesKey{}).([]string)
+	return roles
+}
+
+type policyRule struct {
+	allow  bool
+	action string
+	fields []string
+	roles  []string
+}
+
+func (r policyRule) matches(action Action, field string, roles []string) bool {
+	if r.action != "*" && r.action != string(action) {
+		return false
+	}
+	if !matchesAny(r.fields, field) {
+		return false
+	}
+	if len(r.roles) == 0 {
+		return true
+	}
+	for _, role := range roles {
+		if matchesAny(r.roles, role) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAny(patterns []string, value string) bool {
+	for _, p := range patterns {
+		if p == "*" || p == value {
+			return true
+		}
+	}
+	return false
+}
+
+// RulePolicy is a FieldPolicy driven by a list of rules, the first matching rule decides.
+type RulePolicy struct {
+	rules        []policyRule
+	defaultAllow bool
+}
+
+// ParseRulePolicy reads a rule file, with one rule per line:
+//
+//	# comment
+//	default deny
+//	allow read *
+//	allow write Name,Email
+//	allow * Salary admin,hr
+//
+// A rule is "allow" or "deny", followed by the action or *, the field names or *,
+// and optionally the roles which must be present in the context, see WithRoles.
+// The first matching rule decides, if none match the default applies, which is to allow.
+func ParseRulePolicy(r io.Reader) (*RulePolicy, error) {
+	var policy = &RulePolicy{defaultAllow: true}
+	var scanner = bufio.NewScanner(r)
+	var line int
+	for scanner.Scan() {
+		line++
+		var fields = strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if fields[0] == "default" {
+			if len(fields) != 2 || (fields[1] != "allow" && fields[1] != "deny") {
+				return nil, fmt.Errorf("Line %d: expected default allow or default deny", line)
+			}
+			policy.defaultAllow = fields[1] == "allow"
+			continue
+		}
+		if (fields[0] != "allow" && fields[0] != "deny") || len(fields) < 3 || len(fields) > 4 {
+			return nil, fmt.Errorf("Line %d: expected allow|deny <action> <fields> [roles]", line)
+		}
+		var rule = policyRule{
+			allow:  fields[0] == "allow",
+			action: fields[1],
+			fields: strings.Split(fields[2], ","),
+		}
+		if len(fields) == 4 {
+			rule.roles = strings.Split(fields[3], ",")
+		}
+		policy.rules = append(policy.rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// Allow implements FieldPolicy.
+func (p *RulePolicy) Allow(ctx context.Context, action Action, field string, value interface{}) bool {
+	var roles = RolesFrom(ctx)
+	for _, rule := range p.rules {
+		if rule.matches(action, field, roles) {
+			return rule.allow
+		}
+	}
+	return p.defaultAllow
+}
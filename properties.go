@@ -0,0 +1,193 @@
+package structs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// LoadProperties sets the fields of the struct from a Java .properties file.
+//
+// Keys are the encoding names of the fields, nested structs are separated by a dot.
+//
+// If a prefix is given, only keys starting with the prefix are considered, I.E. a prefix of "app" matches "app.name".
+//
+// It will panic if the struct has not been made.
+func (s *Struct) LoadProperties(r io.Reader, prefix string) error {
+	s.checkMade("Cannot load properties if struct has not been made")
+	var props, err = parseProperties(r)
+	if err != nil {
+		return err
+	}
+	var v = s.decodeTarget()
+	err = fromKV(v, s.tag, strings.TrimSuffix(prefix, "."), ".", func(path string) (string, bool) {
+		var value, ok = props[path]
+		return value, ok
+	})
+	if err != nil {
+		return err
+	}
+	return s.setDecoded(v)
+}
+
+// SaveProperties writes the fields of the struct to w in the Java .properties format.
+//
+// Keys are written in sorted order, see LoadProperties for how keys are named.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) SaveProperties(w io.Writer, prefix string) error {
+	s.checkMade("Cannot save properties if struct has not been made")
+	var kv = make(map[string]string)
+	if err := toKV(s.readable(), s.tag, strings.TrimSuffix(prefix, "."), ".", kv); err != nil {
+		return err
+	}
+	for _, key := range sortedKeys(kv) {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", escapeProperty(key, true), escapeProperty(kv[key], false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseProperties(r io.Reader) (map[string]string, error) {
+	var props = make(map[string]string)
+	var scanner = bufio.NewScanner(r)
+	var logical strings.Builder
+	for scanner.Scan() {
+		var line = scanner.Text()
+		if logical.Len() > 0 {
+			line = strings.TrimLeft(line, " \t\f")
+		} else {
+			var trimmed = strings.TrimLeft(line, " \t\f")
+			if trimmed == "" || trimmed[0] == '#' || trimmed[0] == '!' {
+				continue
+			}
+			line = trimmed
+		}
+
+		// An odd amount of trailing backslashes continues the line.
+		var slashes = len(line) - len(strings.TrimRight(line, `\`))
+		if slashes%2 == 1 {
+			logical.WriteString(line[:len(line)-1])
+			continue
+		}
+		logical.WriteString(line)
+
+		var key, value, err = splitProperty(logical.String())
+		if err != nil {
+			return nil, err
+		}
+		props[key] = value
+		logical.Reset()
+	}
+	if logical.Len() > 0 {
+		var key, value, err = splitProperty(logical.String())
+		if err != nil {
+			return nil, err
+		}
+		props[key] = value
+	}
+	return props, scanner.Err()
+}
+
+func splitProperty(line string) (key, value string, err error) {
+	var end = len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.IndexByte("=: \t\f", line[i]) >= 0 {
+			end = i
+			break
+		}
+	}
+	var rest = strings.TrimLeft(line[end:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	if key, err = unescapeProperty(line[:end]); err != nil {
+		return "", "", err
+	}
+	if value, err = unescapeProperty(rest); err != nil {
+		return "", "", err
+	}
+	return key, value, nil
+}
+
+func unescapeProperty(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf("Invalid unicode escape in %q", s)
+			}
+			var r, err = strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("Invalid unicode escape in %q", s)
+			}
+			i += 4
+			if utf16.IsSurrogate(rune(r)) && i+7 <= len(s) && strings.HasPrefix(s[i+1:], `\u`) {
+				if low, err := strconv.ParseUint(s[i+3:i+7], 16, 16); err == nil {
+					b.WriteRune(utf16.DecodeRune(rune(r), rune(low)))
+					i += 6
+					continue
+				}
+			}
+			b.WriteRune(rune(r))
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+func escapeProperty(s string, isKey bool) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\f':
+			b.WriteString(`\f`)
+		case r == ' ' && (isKey || i == 0):
+			b.WriteString(`\ `)
+		case isKey && (r == '=' || r == ':'), i == 0 && (r == '#' || r == '!'):
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r > 0x7e:
+			if r > 0xffff {
+				var high, low = utf16.EncodeRune(r)
+				fmt.Fprintf(&b, `\u%04x\u%04x`, high, low)
+				continue
+			}
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,133 @@
+package structs
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ProtoDefinition returns a proto3 message definition for the fields of the struct
+//
+// Field numbers are taken from the proto tag, I.E. `proto:"3"`, as set by the protobuf package.
+// Fields without a number are numbered in declaration order, skipping numbers which are already taken,
+// so adding a tag to a field does not renumber the others.
+// Field names are the Go names in snake case.
+//
+// Nested structs become nested messages named after the field, slices become repeated fields, and pointers optional fields.
+// time.Time and time.Duration map to google.protobuf.Timestamp and Duration, interface{} maps to google.protobuf.Value,
+// these require the matching imports in the .proto file.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) ProtoDefinition(messageName string) string {
+	s.checkMade("Cannot create proto definition if struct has not been made")
+	var b strings.Builder
+	writeProtoMessage(&b, messageName, s.sstruct, "", make(map[reflect.Type]bool))
+	return b.String()
+}
+
+func writeProtoMessage(b *strings.Builder, name string, typ reflect.Type, indent string, seen map[reflect.Type]bool) {
+	seen[typ] = true
+	defer delete(seen, typ)
+
+	var numbers = make([]int, typ.NumField())
+	var taken = make(map[int]bool)
+	for i := 0; i < typ.NumField(); i++ {
+		if n, err := strconv.Atoi(typ.Field(i).Tag.Get("proto")); err == nil && n > 0 {
+			numbers[i] = n
+			taken[n] = true
+		}
+	}
+	var next = 1
+	for i := range numbers {
+		if numbers[i] != 0 {
+			continue
+		}
+		for taken[next] {
+			next++
+		}
+		numbers[i] = next
+		taken[next] = true
+	}
+
+	fmt.Fprintf(b, "%smessage %s {\n", indent, name)
+	var fields strings.Builder
+	for i := 0; i < typ.NumField(); i++ {
+		var field = typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		var label, fieldType = protoType(b, field.Name, field.Type, indent+"  ", seen)
+		fmt.Fprintf(&fields, "%s  %s%s %s = %d;\n", indent, label, fieldType, snakeCase(field.Name), numbers[i])
+	}
+	b.WriteString(fields.String())
+	fmt.Fprintf(b, "%s}\n", indent)
+}
+
+// protoType returns the label and type of a field, nested messages are written to b.
+func protoType(b *strings.Builder, name string, typ reflect.Type, indent string, seen map[reflect.Type]bool) (label, protoTyp string) {
+	switch {
+	case typ == timeType:
+		return "", "google.protobuf.Timestamp"
+	case typ == durationType:
+		return "", "google.protobuf.Duration"
+	case typ.Implements(textMarshalerType) || reflect.PtrTo(typ).Implements(textMarshalerType):
+		return "", "string"
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "", "bool"
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return "", "int32"
+	case reflect.Int, reflect.Int64:
+		return "", "int64"
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return "", "uint32"
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		return "", "uint64"
+	case reflect.Float32:
+		return "", "float"
+	case reflect.Float64:
+		return "", "double"
+	case reflect.String:
+		return "", "string"
+	case reflect.Ptr:
+		var _, elem = protoType(b, name, typ.Elem(), indent, seen)
+		return "optional ", elem
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return "", "bytes"
+		}
+		var _, elem = protoType(b, name, typ.Elem(), indent, seen)
+		return "repeated ", elem
+	case reflect.Map:
+		var _, key = protoType(b, name+"Key", typ.Key(), indent, seen)
+		var _, value = protoType(b, name, typ.Elem(), indent, seen)
+		return "", fmt.Sprintf("map<%s, %s>", key, value)
+	case reflect.Struct:
+		if seen[typ] {
+			return "", "google.protobuf.Value"
+		}
+		writeProtoMessage(b, name, typ, indent, seen)
+		return "", name
+	}
+	return "", "google.protobuf.Value"
+}
+
+// snakeCase converts a Go name to snake case, I.E. "UserID" becomes "user_id".
+func snakeCase(name string) string {
+	var runes = []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			var prev = runes[i-1]
+			var nextLower = i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
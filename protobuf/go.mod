@@ -0,0 +1,8 @@
+module github.com/Nigel2392/go-structs/protobuf
+
+go 1.23
+
+require (
+	github.com/Nigel2392/go-structs v0.1.0
+	google.golang.org/protobuf v1.36.9
+)
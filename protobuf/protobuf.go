@@ -0,0 +1,147 @@
+// Package protobuf builds a structs.Struct from protobuf message descriptors,
+// for runtime interop with proto-defined messages.
+package protobuf
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/Nigel2392/go-structs"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	anyType      = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// FromDescriptor creates a struct with a field for every field of the message.
+//
+// The field names are the proto names converted to Go names, I.E. "user_id" becomes "UserId",
+// the JSON names of the fields are used as encoding names in the given tag,
+// and the field numbers are kept in the proto tag, I.E. `json:"userId" proto:"1"`.
+// Proto2 required fields are tagged with `structs:"required"`.
+//
+// Scalars map to their Go types, enums to int32, bytes to []byte, repeated fields to slices and maps to maps.
+// Message fields become nested structs, google.protobuf.Timestamp and Duration become time.Time and time.Duration.
+// Fields with explicit presence, I.E. proto3 optional, become pointers.
+// Recursive messages cannot be represented, they become interface{}.
+func FromDescriptor(md protoreflect.MessageDescriptor, tag string) *structs.Struct {
+	return fromDescriptor(md, tag, map[protoreflect.FullName]bool{md.FullName(): true})
+}
+
+// FromFileDescriptorSet creates a struct for the named message, I.E. "shop.v1.Order", in the compiled descriptor set.
+func FromFileDescriptorSet(set *descriptorpb.FileDescriptorSet, message string, tag string) (*structs.Struct, error) {
+	var files, err = protodesc.NewFiles(set)
+	if err != nil {
+		return nil, err
+	}
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(message))
+	if err != nil {
+		return nil, err
+	}
+	var md, ok = desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a message", message)
+	}
+	return FromDescriptor(md, tag), nil
+}
+
+func fromDescriptor(md protoreflect.MessageDescriptor, tag string, seen map[protoreflect.FullName]bool) *structs.Struct {
+	var s = structs.New(tag)
+	var fields = md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		var fd = fields.Get(i)
+		var tags = map[string]string{
+			tag:     fd.JSONName(),
+			"proto": strconv.Itoa(int(fd.Number())),
+		}
+		if fd.Cardinality() == protoreflect.Required {
+			tags["structs"] = "required"
+		}
+		s.AddFieldWithTags(goName(string(fd.Name())), fieldType(fd, tag, seen), tags)
+	}
+	return s
+}
+
+func fieldType(fd protoreflect.FieldDescriptor, tag string, seen map[protoreflect.FullName]bool) reflect.Type {
+	if fd.IsMap() {
+		return reflect.MapOf(kindType(fd.MapKey(), tag, seen), kindType(fd.MapValue(), tag, seen))
+	}
+	var typ = kindType(fd, tag, seen)
+	if fd.IsList() {
+		return reflect.SliceOf(typ)
+	}
+	if fd.HasPresence() && fd.Message() == nil && typ != anyType {
+		return reflect.PtrTo(typ)
+	}
+	return typ
+}
+
+// kindType returns the type of a single value of the field.
+func kindType(fd protoreflect.FieldDescriptor, tag string, seen map[protoreflect.FullName]bool) reflect.Type {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return reflect.TypeOf(false)
+	case protoreflect.EnumKind, protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return reflect.TypeOf(int32(0))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return reflect.TypeOf(int64(0))
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return reflect.TypeOf(uint32(0))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return reflect.TypeOf(uint64(0))
+	case protoreflect.FloatKind:
+		return reflect.TypeOf(float32(0))
+	case protoreflect.DoubleKind:
+		return reflect.TypeOf(float64(0))
+	case protoreflect.StringKind:
+		return reflect.TypeOf("")
+	case protoreflect.BytesKind:
+		return reflect.TypeOf([]byte(nil))
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		var md = fd.Message()
+		switch md.FullName() {
+		case "google.protobuf.Timestamp":
+			return timeType
+		case "google.protobuf.Duration":
+			return durationType
+		}
+		if seen[md.FullName()] {
+			return anyType
+		}
+		seen[md.FullName()] = true
+		defer delete(seen, md.FullName())
+		var nested = fromDescriptor(md, tag, seen)
+		nested.Make()
+		return reflect.TypeOf(nested.Interface())
+	}
+	return anyType
+}
+
+// goName converts a proto field name to an exported Go name, I.E. "user_id" becomes "UserId".
+func goName(name string) string {
+	var b = make([]byte, 0, len(name))
+	var upper = true
+	for i := 0; i < len(name); i++ {
+		var c = name[i]
+		if c == '_' {
+			upper = true
+			continue
+		}
+		if upper && c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		upper = false
+		b = append(b, c)
+	}
+	if len(b) == 0 || b[0] < 'A' || b[0] > 'Z' {
+		return "X" + string(b)
+	}
+	return string(b)
+}
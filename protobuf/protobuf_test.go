@@ -0,0 +1,63 @@
+package protobuf_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Nigel2392/go-structs/protobuf"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestFromFileDescriptorSet(t *testing.T) {
+	var file = &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("shop.proto"),
+		Package:    proto.String("shop"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Item"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: proto.String("sku"), Number: proto.Int32(1), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), JsonName: proto.String("sku")},
+				},
+			},
+			{
+				Name: proto.String("Order"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: proto.String("order_id"), Number: proto.Int32(1), Type: descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), JsonName: proto.String("orderId")},
+					{Name: proto.String("items"), Number: proto.Int32(2), Type: descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(), TypeName: proto.String(".shop.Item"), Label: descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(), JsonName: proto.String("items")},
+					{Name: proto.String("created_at"), Number: proto.Int32(3), Type: descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(), TypeName: proto.String(".google.protobuf.Timestamp"), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), JsonName: proto.String("createdAt")},
+				},
+			},
+		},
+	}
+	var set = &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(timestamppb.File_google_protobuf_timestamp_proto),
+			file,
+		},
+	}
+
+	var s, err = protobuf.FromFileDescriptorSet(set, "shop.Order", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Make()
+	var typ = reflect.TypeOf(s.Interface())
+	if typ.NumField() != 3 {
+		t.Fatalf("Expected %d fields, got %d", 3, typ.NumField())
+	}
+	if field := typ.Field(0); field.Name != "OrderId" || field.Type != reflect.TypeOf(int64(0)) || field.Tag != `json:"orderId" proto:"1"` {
+		t.Errorf("Unexpected field %s %s %s", field.Name, field.Type, field.Tag)
+	}
+	if field := typ.Field(1); field.Type.Kind() != reflect.Slice || field.Type.Elem().Field(0).Name != "Sku" {
+		t.Errorf("Expected Items to be a slice of structs, got %s", field.Type)
+	}
+	if field := typ.Field(2); field.Type != reflect.TypeOf(time.Time{}) {
+		t.Errorf("Expected CreatedAt to be a time.Time, got %s", field.Type)
+	}
+}
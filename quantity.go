@@ -0,0 +1,246 @@
+package structs
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// unit converts values to the canonical unit of its dimension: canonical = value * factor + offset.
+type unit struct {
+	dimension string
+	factor    float64
+	offset    float64
+}
+
+var (
+	unitsMu sync.RWMutex
+	units   = map[string]unit{
+		"mg": {"mass", 1e-6, 0},
+		"g":  {"mass", 1e-3, 0},
+		"kg": {"mass", 1, 0},
+		"t":  {"mass", 1000, 0},
+		"oz": {"mass", 0.028349523125, 0},
+		"lb": {"mass", 0.45359237, 0},
+
+		"mm": {"length", 1e-3, 0},
+		"cm": {"length", 1e-2, 0},
+		"m":  {"length", 1, 0},
+		"km": {"length", 1000, 0},
+		"in": {"length", 0.0254, 0},
+		"ft": {"length", 0.3048, 0},
+		"yd": {"length", 0.9144, 0},
+		"mi": {"length", 1609.344, 0},
+
+		"ml":  {"volume", 1e-3, 0},
+		"l":   {"volume", 1, 0},
+		"m3":  {"volume", 1000, 0},
+		"gal": {"volume", 3.785411784, 0},
+
+		"K":  {"temperature", 1, 0},
+		"°C": {"temperature", 1, 273.15},
+		"°F": {"temperature", 5.0 / 9, 459.67 * 5 / 9},
+	}
+)
+
+// RegisterUnit adds a unit to the conversion table.
+//
+// Values are converted to the canonical unit of the dimension as value * factor + offset,
+// I.E. RegisterUnit("hm", "length", 100, 0) for hectometers, where meters are canonical.
+// The built-in dimensions are mass (kg), length (m), volume (l) and temperature (K).
+func RegisterUnit(name, dimension string, factor, offset float64) {
+	if factor == 0 {
+		panic(fmt.Sprintf("Unit %s must have a non-zero factor", name))
+	}
+	unitsMu.Lock()
+	units[name] = unit{dimension: dimension, factor: factor, offset: offset}
+	unitsMu.Unlock()
+}
+
+func lookupUnit(name string) (unit, error) {
+	unitsMu.RLock()
+	defer unitsMu.RUnlock()
+	if u, ok := units[name]; ok {
+		return u, nil
+	}
+	return unit{}, fmt.Errorf("Unknown unit %s", name)
+}
+
+// Quantity is a value in a unit of measure, I.E. 12.5 kg.
+type Quantity struct {
+	Value float64
+	Unit  string
+}
+
+// ParseQuantity parses a value followed by a unit, I.E. "12.5 kg".
+func ParseQuantity(s string) (Quantity, error) {
+	var value, unitName, ok = strings.Cut(strings.TrimSpace(s), " ")
+	if !ok {
+		return Quantity{}, fmt.Errorf("Invalid quantity %q", s)
+	}
+	var f, err = strconv.ParseFloat(value, 64)
+	if err != nil {
+		return Quantity{}, fmt.Errorf("Invalid quantity %q", s)
+	}
+	var q = Quantity{Value: f, Unit: strings.TrimSpace(unitName)}
+	if _, err := lookupUnit(q.Unit); err != nil {
+		return Quantity{}, err
+	}
+	return q, nil
+}
+
+// Convert returns the quantity in another unit of the same dimension.
+//
+// The converted value is rounded to 12 significant digits, to drop the error introduced by the conversion factors.
+func (q Quantity) Convert(to string) (Quantity, error) {
+	var from, err = lookupUnit(q.Unit)
+	if err != nil {
+		return q, err
+	}
+	target, err := lookupUnit(to)
+	if err != nil {
+		return q, err
+	}
+	if from.dimension != target.dimension {
+		return q, fmt.Errorf("Cannot convert %s (%s) to %s (%s)", q.Unit, from.dimension, to, target.dimension)
+	}
+	if q.Unit == to {
+		return q, nil
+	}
+	var canonical = q.Value*from.factor + from.offset
+	var value, _ = strconv.ParseFloat(strconv.FormatFloat((canonical-target.offset)/target.factor, 'g', 12, 64), 64)
+	return Quantity{Value: value, Unit: to}, nil
+}
+
+// String returns the value followed by the unit, I.E. "12.5 kg".
+func (q Quantity) String() string {
+	return strconv.FormatFloat(q.Value, 'g', -1, 64) + " " + q.Unit
+}
+
+// MarshalText encodes the quantity as returned by String.
+func (q Quantity) MarshalText() ([]byte, error) {
+	return []byte(q.String()), nil
+}
+
+// UnmarshalText decodes the format written by MarshalText.
+func (q *Quantity) UnmarshalText(text []byte) error {
+	var parsed, err = ParseQuantity(string(text))
+	if err != nil {
+		return err
+	}
+	*q = parsed
+	return nil
+}
+
+type quantityJSON struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit"`
+}
+
+// MarshalJSON encodes the quantity as {"value": 12.5, "unit": "kg"}.
+func (q Quantity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(quantityJSON{Value: q.Value, Unit: q.Unit})
+}
+
+// UnmarshalJSON decodes the format written by MarshalJSON, or a string like "12.5 kg".
+func (q *Quantity) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return err
+		}
+		return q.UnmarshalText([]byte(str))
+	}
+	var v quantityJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Unit != "" {
+		if _, err := lookupUnit(v.Unit); err != nil {
+			return err
+		}
+	}
+	*q = Quantity{Value: v.Value, Unit: v.Unit}
+	return nil
+}
+
+var quantityType = reflect.TypeOf(Quantity{})
+
+// QuantityField adds a field of type Quantity in the given unit.
+//
+// The unit is stored in the `quantity` tag of the field. Quantities set through SetField must have a unit
+// of the same dimension, quantities without a unit are assumed to be in the unit of the field.
+// The value is stored in the unit it was entered in.
+func (s *Struct) QuantityField(absolute_name, name, unit string, required ...bool) {
+	s.quantityField(absolute_name, name, unit, unit, required)
+}
+
+// CanonicalQuantityField adds a field of type Quantity like QuantityField,
+// which is converted to the unit of the field when the struct is marshalled to JSON.
+func (s *Struct) CanonicalQuantityField(absolute_name, name, unit string, required ...bool) {
+	s.quantityField(absolute_name, name, unit, unit+",canonical", required)
+}
+
+func (s *Struct) quantityField(absolute_name, name, unit, tagValue string, required []bool) {
+	if _, err := lookupUnit(unit); err != nil {
+		panic(err.Error())
+	}
+	if name == "" {
+		name = absolute_name
+	}
+	var pairs = []tagPair{{key: s.tag, value: name}, {key: "quantity", value: tagValue}}
+	if len(required) > 0 && required[0] {
+		pairs = append(pairs, tagPair{key: "structs", value: "required"})
+	}
+	s.AddStructField(reflect.StructField{
+		Name: absolute_name,
+		Tag:  formatTag(pairs),
+		Type: quantityType,
+	})
+}
+
+// checkUnit validates the unit of a quantity field against the unit in its tag.
+func checkUnit(field reflect.Value, tag reflect.StructTag) error {
+	var fieldUnit, _, _ = strings.Cut(tag.Get("quantity"), ",")
+	if field.Type() != quantityType || fieldUnit == "" {
+		return nil
+	}
+	var q = field.Interface().(Quantity)
+	if q.Unit == "" {
+		field.Set(reflect.ValueOf(Quantity{Value: q.Value, Unit: fieldUnit}))
+		return nil
+	}
+	var _, err = q.Convert(fieldUnit)
+	return err
+}
+
+// canonicalQuantities returns a copy of the struct value with the canonical quantity fields converted to their unit,
+// or the value itself if there are none.
+func canonicalQuantities(v reflect.Value) (reflect.Value, error) {
+	var copied bool
+	for i := 0; i < v.NumField(); i++ {
+		var field = v.Type().Field(i)
+		var fieldUnit, option, _ = strings.Cut(field.Tag.Get("quantity"), ",")
+		if field.Type != quantityType || option != "canonical" {
+			continue
+		}
+		var q = v.Field(i).Interface().(Quantity)
+		if q.Unit == "" || q.Unit == fieldUnit {
+			continue
+		}
+		var converted, err = q.Convert(fieldUnit)
+		if err != nil {
+			return v, fmt.Errorf("%s: %s", field.Name, err)
+		}
+		if !copied {
+			var c = reflect.New(v.Type()).Elem()
+			c.Set(v)
+			v, copied = c, true
+		}
+		v.Field(i).Set(reflect.ValueOf(converted))
+	}
+	return v, nil
+}
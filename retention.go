@@ -0,0 +1,98 @@
+package structs
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseRetention parses a retention period as used in the retain tag.
+//
+// Next to the units of time.ParseDuration, the units "d" for days and "w" for weeks are accepted, I.E. "30d".
+func ParseRetention(s string) (time.Duration, error) {
+	var unit time.Duration
+	switch {
+	case strings.HasSuffix(s, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(s, "w"):
+		unit = 7 * 24 * time.Hour
+	default:
+		return time.ParseDuration(s)
+	}
+	var n, err = strconv.ParseFloat(s[:len(s)-1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid retention period %q", s)
+	}
+	return time.Duration(n * float64(unit)), nil
+}
+
+// Retention returns the retention period of the field, as set with the retain tag, I.E. `retain:"30d"`.
+//
+// It returns false if the field has no retention period.
+//
+// It will panic if the struct has not been made, or the field does not exist.
+func (s *Struct) Retention(name string) (time.Duration, bool, error) {
+	s.checkMade("Cannot get retention if struct has not been made")
+	var field, ok = s.sstruct.FieldByName(name)
+	if !ok {
+		panic(fmt.Sprintf("Field %s does not exist", name))
+	}
+	var retain, set = field.Tag.Lookup("retain")
+	if !set {
+		return 0, false, nil
+	}
+	var d, err = ParseRetention(retain)
+	return d, err == nil, err
+}
+
+// Expire zeroes the values of fields which have outlived their retention period, and returns their names.
+//
+// A field expires when the time since it was last written, as reported by Stamp, exceeds its retention period.
+// Fields which have never been set do not expire. The write time of expired fields is cleared.
+//
+// Write times are only kept in memory, they are not part of any encoding and are cleared by Make and Zero.
+// When a struct is loaded from storage, restore the write times with SetFieldAt, or its fields will never expire.
+// Write times after now, I.E. from a replica with a skewed clock, are moved back to now,
+// so the field expires at most one retention period later.
+//
+// If the installed policy does not allow any of the expired fields to be written, an error is returned
+// and no fields are expired.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) Expire(now time.Time) ([]string, error) {
+	s.checkMade("Cannot expire fields if struct has not been made")
+	var expired []string
+	var indices []int
+	for i := 0; i < s.sstruct.NumField(); i++ {
+		var field = s.sstruct.Field(i)
+		var retain, ok = field.Tag.Lookup("retain")
+		if !ok {
+			continue
+		}
+		var period, err = ParseRetention(retain)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", field.Name, err)
+		}
+		var stamp = s.stamps[field.Name]
+		if stamp.After(now) {
+			s.stamps[field.Name] = now
+			continue
+		}
+		if stamp.IsZero() || now.Sub(stamp) <= period {
+			continue
+		}
+		if err := catch(func() { s.checkPolicy(ActionWrite, field.Name, reflect.Zero(field.Type).Interface()) }); err != nil {
+			return nil, err
+		}
+		expired = append(expired, field.Name)
+		indices = append(indices, i)
+	}
+	for j, i := range indices {
+		s.structValue.Field(i).Set(reflect.Zero(s.sstruct.Field(i).Type))
+		delete(s.stamps, expired[j])
+		s.updateDerived(expired[j])
+	}
+	return expired, nil
+}
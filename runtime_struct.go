@@ -1,25 +1,59 @@
 package structs
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
+	"time"
 )
 
 func IsRequired(field reflect.StructField) bool {
-	return strings.Contains(field.Tag.Get("structs"), "required")
+	return hasStructsOption(field, "required")
+}
+
+// IsRedacted returns whether the field holds sensitive data, which should not be written to logs.
+//
+// Fields are marked as redacted with the "redact" option in the structs tag, I.E. `structs:"redact"`.
+func IsRedacted(field reflect.StructField) bool {
+	return hasStructsOption(field, "redact")
+}
+
+// HasOption returns whether the structs tag of the field holds the option, I.E. `structs:"label"`.
+//
+// The value of the default option is not taken into account.
+func HasOption(field reflect.StructField, option string) bool {
+	return hasStructsOption(field, option)
+}
+
+// EncodingName returns the name of the field for the given tag, without options.
+//
+// The field name is used if the tag is empty.
+func EncodingName(field reflect.StructField, tag string) string {
+	return encName(field, tag)
 }
 
 type Struct struct {
 	// There is an optional parameter "required" for the fields of the struct.
 	//
 	// This can be used to determine whether the field is required or not in serialization for example.
-	tag          string                // Default tag to use for enc_name
-	fieldsByName []reflect.StructField // Inner fields.
-	sstruct      reflect.Type          // The struct type
-	structValue  reflect.Value         // The struct value
-	made         bool                  // Whether the struct has been made or not
+	tag          string                  // Default tag to use for enc_name
+	fieldsByName []reflect.StructField   // Inner fields.
+	sstruct      reflect.Type            // The struct type
+	structValue  reflect.Value           // The struct value
+	made         bool                    // Whether the struct has been made or not
+	stamps       map[string]time.Time    // Last time each field was written, used by MergeLWW
+	checksums    []checksum              // Checksum fields, recomputed by UpdateChecksums
+	derived      []derived               // Derived fields, recomputed when their source is set
+	relations    []relation              // Relations between fields, used by Solve
+	links        map[string]string       // HAL link templates, resolved by MarshalHAL
+	nested       map[string]*Struct      // Nested structs rebuilt by FromRecursive
+	policy       FieldPolicy             // Policy consulted on field access, if installed
+	policyCtx    context.Context         // Context passed to the policy
+	stringer     func(*Struct) string    // Used by String, if set
+	less         func(a, b *Struct) bool // Used by Less and Sort, if set
 }
 
 func From(v interface{}, tag string, fields ...string) *Struct {
@@ -52,26 +86,39 @@ func From(v interface{}, tag string, fields ...string) *Struct {
 			if !ok {
 				panic(fmt.Sprintf("Field %s does not exist in struct %s", field, structTyp.Name()))
 			}
-			var enc_name = f.Tag.Get(tag)
-			if enc_name == "-" {
+			if f.Tag.Get(tag) == "-" {
 				continue
 			}
-			s.AddField(field, enc_name, f.Type)
+			s.addSourceField(f)
 		}
 		return s
 	}
 	for i := 0; i < structTyp.NumField(); i++ {
 		var field = structTyp.Field(i)
-		var enc_name = field.Tag.Get(tag)
-		if enc_name == "-" {
+		if field.Tag.Get(tag) == "-" {
 			continue
 		}
-		var absolute_name = field.Name
-		s.AddField(absolute_name, enc_name, field.Type)
+		s.addSourceField(field)
 	}
 	return s
 }
 
+// addSourceField adds a field copied from an existing struct type
+//
+// The full tag of the field is kept, so tags other than the struct's tag are not lost.
+// If the struct's tag is missing, it is set to the name of the field.
+func (s *Struct) addSourceField(field reflect.StructField) {
+	var tag = field.Tag
+	if _, ok := tag.Lookup(s.tag); !ok || tag.Get(s.tag) == "" {
+		tag = setTagKey(tag, s.tag, field.Name)
+	}
+	s.AddStructField(reflect.StructField{
+		Name: field.Name,
+		Type: field.Type,
+		Tag:  tag,
+	})
+}
+
 func New(tag string) *Struct {
 	return &Struct{
 		tag:          tag,
@@ -79,6 +126,11 @@ func New(tag string) *Struct {
 	}
 }
 
+// Tag returns the tag used for the encoding names of the fields
+func (s *Struct) Tag() string {
+	return s.tag
+}
+
 func (s *Struct) FieldByName(name string) reflect.Value {
 	s.checkMade("Cannot get field by name if struct has not been made")
 	return s.structValue.FieldByName(name)
@@ -147,14 +199,13 @@ func (s *Struct) SetField(name string, value interface{}) {
 	if !field.IsValid() {
 		panic(fmt.Sprintf("Field %s does not exist", name))
 	}
-	var valueOf = valueOf(value)
-	if valueOf.Kind() == reflect.Ptr {
-		valueOf = valueOf.Elem()
-	}
-	if field.Kind() != valueOf.Kind() {
-		panic(fmt.Sprintf("Cannot set field %s with value of type %s", name, valueOf.Kind().String()))
+	s.checkPolicy(ActionWrite, name, value)
+	var structField, _ = s.sstruct.FieldByName(name)
+	if err := assign(field, structField.Tag, value); err != nil {
+		panic(fmt.Sprintf("Cannot set field %s: %s", name, err))
 	}
-	field.Set(valueOf)
+	s.touch(name, time.Now())
+	s.updateDerived(name)
 }
 
 func (s *Struct) SetFieldByIndex(index int, value interface{}) {
@@ -163,47 +214,110 @@ func (s *Struct) SetFieldByIndex(index int, value interface{}) {
 	if !field.IsValid() {
 		panic(fmt.Sprintf("Field %d does not exist", index))
 	}
+	s.checkPolicy(ActionWrite, s.sstruct.Field(index).Name, value)
+	if err := assign(field, s.sstruct.Field(index).Tag, value); err != nil {
+		panic(fmt.Sprintf("Cannot set field %d: %s", index, err))
+	}
+	s.touch(s.sstruct.Field(index).Name, time.Now())
+	s.updateDerived(s.sstruct.Field(index).Name)
+}
+
+// assign sets the field to the value.
+//
+// Pointers are dereferenced for non-pointer fields, values are converted to named types of the same kind,
+// and strings are parsed for fields implementing encoding.TextUnmarshaler. nil sets the field to its zero value.
+//
+// Fields implementing Normalizer are normalized, and the unit of quantity fields and the currency of money fields
+// are checked against their tag.
+// The previous value is restored if this fails.
+func assign(field reflect.Value, tag reflect.StructTag, value interface{}) error {
 	var valueOf = valueOf(value)
-	if valueOf.Kind() == reflect.Ptr {
+	if valueOf.Kind() == reflect.Ptr && field.Kind() != reflect.Ptr && field.Kind() != reflect.Interface {
 		valueOf = valueOf.Elem()
 	}
+	if valueOf.Kind() == reflect.String && field.Kind() != reflect.String && reflect.PtrTo(field.Type()).Implements(textUnmarshalerType) {
+		var parsed, err = parseValue(valueOf.String(), field.Type())
+		if err != nil {
+			return err
+		}
+		valueOf = parsed
+	}
+	if !valueOf.IsValid() {
+		valueOf = reflect.Zero(field.Type())
+	}
+	if field.Kind() == reflect.Interface && valueOf.Type().AssignableTo(field.Type()) {
+		valueOf = valueOf.Convert(field.Type())
+	}
 	if field.Kind() != valueOf.Kind() {
-		panic(fmt.Sprintf("Cannot set field %d with value of type %s", index, valueOf.Kind().String()))
+		return fmt.Errorf("value of type %s cannot be assigned to %s", valueOf.Kind().String(), field.Type().String())
+	}
+	if valueOf.Type() != field.Type() && valueOf.Type().ConvertibleTo(field.Type()) {
+		valueOf = valueOf.Convert(field.Type())
 	}
+	var previous = reflect.New(field.Type()).Elem()
+	previous.Set(field)
 	field.Set(valueOf)
+	if err := normalize(field, previous); err != nil {
+		return err
+	}
+	if err := checkUnit(field, tag); err != nil {
+		field.Set(previous)
+		return err
+	}
+	if err := checkCurrency(field, tag); err != nil {
+		field.Set(previous)
+		return err
+	}
+	return nil
 }
 
 // Deep copy of the struct
 //
-// This is useful for when you want to modify a struct without modifying the original
-func (s *Struct) DeepCopy() *Struct {
+// This is useful for when you want to modify a struct without modifying the original.
+//
+// Pointer fields point to a copy of their value, slices, maps and the values behind nested pointers are shared.
+// Blob fields are copied with Blob.Clone.
+// If recursive is true, slices, maps, pointers, interfaces and nested structs are copied recursively,
+// so no mutation of the copy can be observed through the original.
+func (s *Struct) DeepCopy(recursive ...bool) *Struct {
 	s.checkMade("Cannot deep copy if struct has not been made")
 	var newStruct = New(s.tag)
 	for _, field := range s.fieldsByName {
-		newStruct.AddField(field.Name, field.Tag.Get(s.tag), field.Type, field.Tag.Get("structs") == "required")
+		if field.Anonymous {
+			newStruct.embed(field.Name, field.Type)
+			continue
+		}
+		newStruct.AddStructField(field)
 	}
+	newStruct.nested = s.copyNested()
+	newStruct.policy, newStruct.policyCtx = s.policy, s.policyCtx
+	newStruct.stringer, newStruct.less = s.stringer, s.less
 
 	newStruct.Make()
 
-	for _, field := range s.fieldsByName {
-		var newFieldByIndex = newStruct.structValue.FieldByIndex(field.Index)
-		if newFieldByIndex.Kind() == reflect.Ptr {
-			newFieldByIndex = newFieldByIndex.Elem()
-		}
-		var fieldByIndex = s.structValue.FieldByIndex(field.Index)
-		if fieldByIndex.Kind() == reflect.Ptr {
-			fieldByIndex = fieldByIndex.Elem()
+	var seen = make(map[reflect.Value]reflect.Value)
+	for i := 0; i < s.sstruct.NumField(); i++ {
+		var value = s.structValue.Field(i)
+		switch {
+		case len(recursive) > 0 && recursive[0]:
+			value = deepCopyValue(value, seen)
+		case value.Type() == blobType && !value.IsNil():
+			value = reflect.ValueOf(value.Interface().(*Blob).Clone())
+		case value.Kind() == reflect.Ptr && !value.IsNil():
+			var ptr = reflect.New(value.Type().Elem())
+			ptr.Elem().Set(value.Elem())
+			value = ptr
 		}
-
-		if fieldByIndex.Kind() != newFieldByIndex.Kind() {
-			panic(fmt.Sprintf("Cannot deep copy field %s, because the types are different", field.Name))
-		}
-
-		if !newFieldByIndex.CanSet() {
-			panic(fmt.Sprintf("Cannot deep copy field %s, because it cannot be set", field.Name))
-		}
-
-		newFieldByIndex.Set(fieldByIndex)
+		newStruct.structValue.Field(i).Set(value)
+	}
+	for name, stamp := range s.stamps {
+		newStruct.touch(name, stamp)
+	}
+	newStruct.checksums = append(newStruct.checksums, s.checksums...)
+	newStruct.derived = append(newStruct.derived, s.derived...)
+	newStruct.relations = append(newStruct.relations, s.relations...)
+	if s.links != nil {
+		newStruct.WithLinks(s.links)
 	}
 	return newStruct
 }
@@ -256,7 +370,7 @@ func (s *Struct) AddStructField(field reflect.StructField) {
 		}
 	}
 	if field.Anonymous {
-		panic("Cannot add anonymous field")
+		panic("Cannot add anonymous field, use Embed or EmbedStruct")
 	}
 
 	// If the struct has already been made,
@@ -265,6 +379,325 @@ func (s *Struct) AddStructField(field reflect.StructField) {
 	s.fieldsByName = append(s.fieldsByName, field)
 }
 
+// Embed adds an anonymous field of the given struct type
+//
+// The fields of the embedded type are promoted, so they can be accessed with GetField and SetField.
+// The field is named after the type, and has no tag, so encoders like encoding/json inline its fields.
+func (s *Struct) Embed(typeOf reflect.Type) {
+	if typeOf.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("Cannot embed type of kind %s", typeOf.Kind().String()))
+	}
+	if typeOf.Name() == "" {
+		panic("Cannot embed unnamed type, use EmbedStruct")
+	}
+	s.embed(typeOf.Name(), typeOf)
+}
+
+// EmbedStruct adds the other struct as an anonymous field with the given name
+//
+// The fields of the other struct are promoted, so they can be accessed with GetField and SetField.
+//
+// It will panic if the other struct has not been made.
+func (s *Struct) EmbedStruct(name string, other *Struct) {
+	other.checkMade("Cannot embed struct if it has not been made")
+	s.embed(name, other.sstruct)
+}
+
+func (s *Struct) embed(name string, typeOf reflect.Type) {
+	if name == "" {
+		panic("Field name cannot be empty")
+	}
+	for _, f := range s.fieldsByName {
+		if f.Name == name {
+			panic(fmt.Sprintf("Field %s already exists", name))
+		}
+	}
+	s.made = false
+	s.fieldsByName = append(s.fieldsByName, reflect.StructField{
+		Name:      name,
+		Type:      typeOf,
+		Anonymous: true,
+	})
+}
+
+// AddFieldWithTags adds a field carrying multiple tags, I.E. json, db and validate tags
+//
+// The struct's tag is written first, the other tags follow sorted by key.
+// If the struct's tag is missing, it is set to the name of the field.
+func (s *Struct) AddFieldWithTags(name string, typeOf reflect.Type, tags map[string]string) {
+	var enc_name = tags[s.tag]
+	if enc_name == "" {
+		enc_name = name
+	}
+	var pairs = []tagPair{{key: s.tag, value: enc_name}}
+	for _, key := range sortedKeys(tags) {
+		if key != s.tag {
+			pairs = append(pairs, tagPair{key: key, value: tags[key]})
+		}
+	}
+	s.AddStructField(reflect.StructField{
+		Name: name,
+		Type: typeOf,
+		Tag:  formatTag(pairs),
+	})
+}
+
+// RemoveField removes a field that has been added to the struct
+//
+// The made flag is reset, so the struct has to be made again before it can be used.
+// The field is removed from the fields checksums are computed over, checksums left without any field are removed,
+// as are derived fields and relations of the field.
+//
+// It will panic if the field does not exist.
+func (s *Struct) RemoveField(name string) {
+	var index = -1
+	for i, field := range s.fieldsByName {
+		if field.Name == name {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		panic(fmt.Sprintf("Field %s does not exist", name))
+	}
+
+	s.made = false
+	s.fieldsByName = append(s.fieldsByName[:index:index], s.fieldsByName[index+1:]...)
+	delete(s.stamps, name)
+	delete(s.nested, name)
+
+	var checksums = s.checksums[:0:0]
+	for _, c := range s.checksums {
+		var over = make([]string, 0, len(c.over))
+		for _, field := range c.over {
+			if field != name {
+				over = append(over, field)
+			}
+		}
+		c.over = over
+		if c.field != name && len(c.over) > 0 {
+			checksums = append(checksums, c)
+		}
+	}
+	s.checksums = checksums
+
+	var derived = s.derived[:0:0]
+	for _, d := range s.derived {
+		if d.field != name && d.source != name {
+			derived = append(derived, d)
+		}
+	}
+	s.derived = derived
+
+	var relations = s.relations[:0:0]
+	for _, r := range s.relations {
+		if r.target != name && r.left.field != name && r.right.field != name {
+			relations = append(relations, r)
+		}
+	}
+	s.relations = relations
+}
+
+// RenameField renames a field that has been added to the struct
+//
+// The encoding name in the struct's tag is rewritten to the new name, or to enc_name if it is given.
+// Any options following the encoding name, and all other tags, are kept.
+//
+// If the struct has been made, it is re-made and the values of all fields are preserved.
+//
+// It will panic if the old field does not exist, or a field with the new name already exists.
+func (s *Struct) RenameField(oldName, newName string, enc_name ...string) {
+	if newName == "" {
+		panic("Field name cannot be empty")
+	}
+	var index = -1
+	for i, field := range s.fieldsByName {
+		if field.Name == newName && newName != oldName {
+			panic(fmt.Sprintf("Field %s already exists", newName))
+		}
+		if field.Name == oldName {
+			index = i
+		}
+	}
+	if index < 0 {
+		panic(fmt.Sprintf("Field %s does not exist", oldName))
+	}
+
+	var wasMade = s.made
+	var previous = s.structValue
+
+	var encoding = newName
+	if len(enc_name) > 0 && enc_name[0] != "" {
+		encoding = enc_name[0]
+	}
+	var field = s.fieldsByName[index]
+	if _, options, ok := strings.Cut(field.Tag.Get(s.tag), ","); ok {
+		encoding += "," + options
+	}
+	field.Name = newName
+	field.Tag = setTagKey(field.Tag, s.tag, encoding)
+
+	var fields = make([]reflect.StructField, len(s.fieldsByName))
+	copy(fields, s.fieldsByName)
+	fields[index] = field
+	s.fieldsByName = fields
+	s.made = false
+
+	var rename = func(name string) string {
+		if name == oldName {
+			return newName
+		}
+		return name
+	}
+	var stamps = s.stamps
+	if nested, ok := s.nested[oldName]; ok {
+		delete(s.nested, oldName)
+		s.nested[newName] = nested
+	}
+	for i := range s.checksums {
+		s.checksums[i].field = rename(s.checksums[i].field)
+		var over = make([]string, len(s.checksums[i].over))
+		for j, name := range s.checksums[i].over {
+			over[j] = rename(name)
+		}
+		s.checksums[i].over = over
+	}
+	for i := range s.derived {
+		s.derived[i].field = rename(s.derived[i].field)
+		s.derived[i].source = rename(s.derived[i].source)
+	}
+	var relations = make([]relation, len(s.relations))
+	for i, r := range s.relations {
+		r.target, r.left.field, r.right.field = rename(r.target), rename(r.left.field), rename(r.right.field)
+		relations[i] = r
+	}
+	s.relations = relations
+
+	if !wasMade {
+		return
+	}
+	s.Make()
+	for i := 0; i < previous.NumField(); i++ {
+		s.structValue.Field(i).Set(previous.Field(i))
+	}
+	for name, stamp := range stamps {
+		s.touch(rename(name), stamp)
+	}
+}
+
+// ReplaceField changes the type of a field that has been added to the struct
+//
+// The field keeps its position and its tag. Optional tags in the reflect.StructTag format, I.E. `db:"age"`,
+// are merged into the existing tag, replacing keys which are already present.
+//
+// If the struct has been made, it is re-made and the values of all fields are preserved.
+// The value of the replaced field is converted to the new type, I.E. an int to an int64.
+// If it cannot be converted, the field is set to the zero value of the new type.
+// Integers are never converted to strings, as Go would convert them to a rune.
+//
+// It will panic if the field does not exist.
+func (s *Struct) ReplaceField(name string, newType reflect.Type, tags ...string) {
+	if newType == nil {
+		panic(fmt.Sprintf("Field %s must have a type", name))
+	}
+	for i, field := range s.fieldsByName {
+		if field.Name != name {
+			continue
+		}
+		var wasMade = s.made
+		var previous = s.structValue
+		var stamps = s.stamps
+
+		field.Type = newType
+		for _, tag := range tags {
+			for _, pair := range parseTag(reflect.StructTag(tag)) {
+				field.Tag = setTagKey(field.Tag, pair.key, pair.value)
+			}
+		}
+		var fields = make([]reflect.StructField, len(s.fieldsByName))
+		copy(fields, s.fieldsByName)
+		fields[i] = field
+		s.fieldsByName = fields
+		s.made = false
+		delete(s.nested, name)
+
+		if !wasMade {
+			return
+		}
+		s.Make()
+		for j := 0; j < previous.NumField(); j++ {
+			if j != i {
+				s.structValue.Field(j).Set(previous.Field(j))
+				continue
+			}
+			if value, ok := convertValue(previous.Field(j), newType); ok {
+				s.structValue.Field(j).Set(value)
+			}
+		}
+		for stamped, stamp := range stamps {
+			s.touch(stamped, stamp)
+		}
+		return
+	}
+	panic(fmt.Sprintf("Field %s does not exist", name))
+}
+
+// convertValue converts the value to the type, and reports whether it could be converted.
+func convertValue(v reflect.Value, typ reflect.Type) (reflect.Value, bool) {
+	if !v.CanConvert(typ) {
+		return reflect.Value{}, false
+	}
+	if typ.Kind() == reflect.String && (v.CanInt() || v.CanUint()) {
+		return reflect.Value{}, false
+	}
+	return v.Convert(typ), true
+}
+
+// MoveField moves a field that has been added to the struct to the given index
+//
+// The other fields keep their relative order.
+//
+// The made flag is reset, so the struct has to be made again before it can be used.
+//
+// It will panic if the field does not exist, or the index is out of range.
+func (s *Struct) MoveField(name string, index int) {
+	if index < 0 || index >= len(s.fieldsByName) {
+		panic(fmt.Sprintf("Index %d is out of range", index))
+	}
+	var from = -1
+	for i, field := range s.fieldsByName {
+		if field.Name == name {
+			from = i
+			break
+		}
+	}
+	if from < 0 {
+		panic(fmt.Sprintf("Field %s does not exist", name))
+	}
+	var field = s.fieldsByName[from]
+	var fields = make([]reflect.StructField, 0, len(s.fieldsByName))
+	fields = append(fields, s.fieldsByName[:from]...)
+	fields = append(fields, s.fieldsByName[from+1:]...)
+	fields = append(fields[:index], append([]reflect.StructField{field}, fields[index:]...)...)
+	s.fieldsByName = fields
+	s.made = false
+}
+
+// SortFields sorts the fields that have been added to the struct
+//
+// The sort is stable, so fields which are equal according to less keep their relative order.
+//
+// The made flag is reset, so the struct has to be made again before it can be used.
+func (s *Struct) SortFields(less func(a, b reflect.StructField) bool) {
+	var fields = make([]reflect.StructField, len(s.fieldsByName))
+	copy(fields, s.fieldsByName)
+	sort.SliceStable(fields, func(i, j int) bool {
+		return less(fields[i], fields[j])
+	})
+	s.fieldsByName = fields
+	s.made = false
+}
+
 func (s *Struct) StringField(absolute_name, name string, required ...bool) {
 	s.AddField(absolute_name, name, reflect.TypeOf(""), required...)
 }
@@ -302,7 +735,9 @@ func (s *Struct) GetField(name string) interface{} {
 	if !field.IsValid() {
 		panic(fmt.Sprintf("Field %s does not exist", name))
 	}
-	return field.Interface()
+	var value = field.Interface()
+	s.checkPolicy(ActionRead, name, value)
+	return value
 }
 
 func (s *Struct) Remake() {
@@ -311,13 +746,16 @@ func (s *Struct) Remake() {
 }
 
 func (s *Struct) Make() {
+	s.syncNested()
 	if !s.made {
 		s.sstruct = reflect.StructOf(s.fieldsByName)
+		s.checkDerived()
 		s.made = true
 	}
 	if s.made {
 		var NewOf = reflect.New(s.sstruct)
 		s.structValue = NewOf.Elem()
+		s.stamps = nil
 	}
 }
 
@@ -419,10 +857,49 @@ func scanInto(s, dest interface{}, neededTags []string, validators ValidatorMap,
 
 func (s *Struct) MarshalJSON() ([]byte, error) {
 	s.checkMade("Cannot marshal if struct has not been made")
-	return json.Marshal(s.structValue.Interface())
+	var v, err = s.jsonValue()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v.Interface())
 }
 
+// jsonValue returns the value marshalled by MarshalJSON: checksums are computed,
+// fields the policy does not allow to be read are left out, canonical quantities are converted,
+// and flags fields added with asStrings are replaced by their names.
+func (s *Struct) jsonValue() (reflect.Value, error) {
+	var v, err = s.withChecksums(s.readable())
+	if err != nil {
+		return v, err
+	}
+	v, err = canonicalQuantities(v)
+	if err != nil {
+		return v, err
+	}
+	return flagsAsNames(v), nil
+}
+
+// UnmarshalJSON decodes the JSON object into the struct.
+//
+// Changed fields are set through SetField, if any field cannot be decoded or set an error is returned
+// and the struct is left unchanged.
 func (s *Struct) UnmarshalJSON(data []byte) error {
 	s.checkMade("Cannot unmarshal if struct has not been made")
-	return json.Unmarshal(data, s.structValue.Addr().Interface())
+	var v = s.decodeTarget()
+	if err := s.decodeJSON(data, v); err != nil {
+		return err
+	}
+	return s.setDecoded(v)
+}
+
+// decodeJSON decodes the JSON data into v, as returned by decodeTarget.
+func (s *Struct) decodeJSON(data []byte, v reflect.Value) error {
+	if flagsJSONType(s.sstruct) != nil {
+		var named = flagsAsNames(v)
+		if err := json.Unmarshal(data, named.Addr().Interface()); err != nil {
+			return err
+		}
+		return flagsFromNames(named, v)
+	}
+	return json.Unmarshal(data, v.Addr().Interface())
 }
@@ -0,0 +1,136 @@
+package structs
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// FieldConflict describes a field which has a different type in both structs.
+type FieldConflict struct {
+	Name     string
+	A, B     reflect.Type
+	Resolved reflect.Type
+}
+
+// ConflictReport lists the conflicts found while combining two structs.
+type ConflictReport []FieldConflict
+
+// ConflictResolver decides which field to keep when a field has a different type in both structs.
+//
+// Returning an error aborts the operation.
+type ConflictResolver func(a, b reflect.StructField) (reflect.StructField, error)
+
+// KeepA is the default ConflictResolver, which keeps the field of the first struct.
+func KeepA(a, b reflect.StructField) (reflect.StructField, error) {
+	return a, nil
+}
+
+// Union returns a new struct holding the fields of both structs, with the fields of a first.
+//
+// Fields which are present in both structs are added once, if their types differ the field of a is kept,
+// and the conflict is reported. Use UnionWith to resolve conflicts differently.
+//
+// The new struct uses the tag of a, and has not been made.
+func Union(a, b *Struct) (*Struct, ConflictReport) {
+	var s, report, _ = UnionWith(a, b, KeepA)
+	return s, report
+}
+
+// UnionWith is like Union, but calls resolve for every field which has a different type in both structs.
+func UnionWith(a, b *Struct, resolve ConflictResolver) (*Struct, ConflictReport, error) {
+	var s = New(a.tag)
+	var report ConflictReport
+	var others = fieldsByName(b)
+	for _, field := range a.fieldsByName {
+		var resolved, err = resolveField(field, others, resolve, &report)
+		if err != nil {
+			return nil, report, err
+		}
+		s.addCopiedField(resolved)
+	}
+	var ours = fieldsByName(a)
+	for _, field := range b.fieldsByName {
+		if _, ok := ours[field.Name]; !ok {
+			s.addCopiedField(field)
+		}
+	}
+	return s, report, nil
+}
+
+// Intersect returns a new struct holding the fields which are present in both structs, in the order of a.
+//
+// If the types of a field differ, the field of a is kept, and the conflict is reported.
+// Use IntersectWith to resolve conflicts differently.
+//
+// The new struct uses the tag of a, and has not been made.
+func Intersect(a, b *Struct) (*Struct, ConflictReport) {
+	var s, report, _ = IntersectWith(a, b, KeepA)
+	return s, report
+}
+
+// IntersectWith is like Intersect, but calls resolve for every field which has a different type in both structs.
+func IntersectWith(a, b *Struct, resolve ConflictResolver) (*Struct, ConflictReport, error) {
+	var s = New(a.tag)
+	var report ConflictReport
+	var others = fieldsByName(b)
+	for _, field := range a.fieldsByName {
+		if _, ok := others[field.Name]; !ok {
+			continue
+		}
+		var resolved, err = resolveField(field, others, resolve, &report)
+		if err != nil {
+			return nil, report, err
+		}
+		s.addCopiedField(resolved)
+	}
+	return s, report, nil
+}
+
+func fieldsByName(s *Struct) map[string]reflect.StructField {
+	var fields = make(map[string]reflect.StructField, len(s.fieldsByName))
+	for _, field := range s.fieldsByName {
+		fields[field.Name] = field
+	}
+	return fields
+}
+
+func resolveField(field reflect.StructField, others map[string]reflect.StructField, resolve ConflictResolver, report *ConflictReport) (reflect.StructField, error) {
+	var other, ok = others[field.Name]
+	if !ok || other.Type == field.Type {
+		return field, nil
+	}
+	var resolved, err = resolve(field, other)
+	if err != nil {
+		return resolved, fmt.Errorf("%s: %s", field.Name, err)
+	}
+	resolved.Name = field.Name
+	*report = append(*report, FieldConflict{
+		Name:     field.Name,
+		A:        field.Type,
+		B:        other.Type,
+		Resolved: resolved.Type,
+	})
+	return resolved, nil
+}
+
+// addCopiedField adds a field taken from another struct, keeping embedded fields anonymous.
+func (s *Struct) addCopiedField(field reflect.StructField) {
+	field.Index = nil
+	field.Offset = 0
+	if field.Anonymous {
+		s.embed(field.Name, field.Type)
+		return
+	}
+	s.AddStructField(field)
+}
+
+// copySchema returns a new struct with the same tag, fields and nested structs, which has not been made.
+func (s *Struct) copySchema() *Struct {
+	var c = New(s.tag)
+	for _, field := range s.fieldsByName {
+		c.addCopiedField(field)
+	}
+	c.nested = s.copyNested()
+	c.stringer, c.less = s.stringer, s.less
+	return c
+}
@@ -0,0 +1,253 @@
+package structs
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// relation holds target = left op right, or target = left if op is 0.
+//
+// Operands are field names, or constants if the name is empty.
+type relation struct {
+	source string
+	target string
+	left   operand
+	op     byte
+	right  operand
+}
+
+type operand struct {
+	field    string
+	constant float64
+}
+
+func parseOperand(s string) (operand, error) {
+	if s == "" {
+		return operand{}, fmt.Errorf("Missing operand")
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return operand{constant: f}, nil
+	}
+	if !isIdentifier(s) {
+		return operand{}, fmt.Errorf("Invalid operand %s", s)
+	}
+	return operand{field: s}, nil
+}
+
+func isIdentifier(s string) bool {
+	for i, c := range s {
+		var letter = c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+		if !letter && !(i > 0 && c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// Relation declares a relation between numeric fields, which is used by Solve
+//
+// Relations have the form "Total = Price * Qty", with one of the operators +, -, * or /, or "Price = Cost".
+// The operands are field names or numeric constants, I.E. "Tax = Total * 0.21".
+//
+// An error is returned if the relation cannot be parsed.
+func (s *Struct) Relation(expr string) error {
+	var target, expression, ok = strings.Cut(expr, "=")
+	if !ok {
+		return fmt.Errorf("Relation %q must have the form Field = expression", expr)
+	}
+	var r = relation{source: expr, target: strings.TrimSpace(target)}
+	if !isIdentifier(r.target) {
+		return fmt.Errorf("Relation %q: invalid field %s", expr, r.target)
+	}
+	expression = strings.TrimSpace(expression)
+	var index = strings.IndexAny(expression, "+-*/")
+	if index == 0 {
+		index = strings.IndexAny(expression[1:], "+-*/") + 1
+	}
+	var err error
+	if index <= 0 {
+		r.left, err = parseOperand(expression)
+	} else {
+		r.op = expression[index]
+		r.left, err = parseOperand(strings.TrimSpace(expression[:index]))
+		if err == nil {
+			r.right, err = parseOperand(strings.TrimSpace(expression[index+1:]))
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("Relation %q: %s", expr, err)
+	}
+	s.relations = append(s.relations, r)
+	return nil
+}
+
+// Solve fills missing fields which can be derived from the relations declared with Relation
+//
+// A field is missing if it is a nil pointer, or holds the zero value. Relations are applied repeatedly,
+// so fields derived by one relation can be used by the next, I.E. Total = Price * Qty is used to derive
+// Qty from Total and Price, or Price from Total and Qty.
+//
+// An error is returned if a relation does not hold for fields which are all present, if a field used in a relation
+// does not exist or is not numeric, or if a derived value cannot be stored in its field without losing precision.
+// If an error is returned, the struct is left unchanged.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) Solve() error {
+	s.checkMade("Cannot solve if struct has not been made")
+	var values = make(map[string]float64)
+	var solved = make(map[string]reflect.Value)
+	var present = func(o operand) (float64, bool) {
+		if o.field == "" {
+			return o.constant, true
+		}
+		var v, ok = values[o.field]
+		return v, ok
+	}
+	for _, r := range s.relations {
+		for _, name := range []string{r.target, r.left.field, r.right.field} {
+			if name == "" {
+				continue
+			}
+			var field = s.structValue.FieldByName(name)
+			if !field.IsValid() {
+				return fmt.Errorf("Relation %q: field %s does not exist", r.source, name)
+			}
+			var typ = field.Type()
+			if typ.Kind() == reflect.Ptr {
+				typ = typ.Elem()
+			}
+			if !isNumericKind(typ.Kind()) {
+				return fmt.Errorf("Relation %q: field %s is not numeric", r.source, name)
+			}
+			if v, ok := numericValue(field); ok && !field.IsZero() {
+				values[name] = v
+			}
+		}
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, r := range s.relations {
+			var operands = [3]operand{{field: r.target}, r.left, r.right}
+			var known [3]float64
+			var missing = -1
+			var count = 0
+			for i, o := range operands {
+				if i == 2 && r.op == 0 {
+					continue
+				}
+				var v, ok = present(o)
+				if !ok {
+					missing = i
+					count++
+				}
+				known[i] = v
+			}
+			switch count {
+			case 0:
+				var expected, ok = r.apply(known[1], known[2])
+				if ok && !approxEqual(known[0], expected) {
+					return fmt.Errorf("Relation %q does not hold: %s is %v, expected %v", r.source, r.target, known[0], expected)
+				}
+			case 1:
+				var v, ok = r.solveFor(missing, known)
+				if !ok {
+					continue
+				}
+				var name = operands[missing].field
+				var converted, err = numericFieldValue(s.structValue.FieldByName(name).Type(), v)
+				if err != nil {
+					return fmt.Errorf("Relation %q: %s: %s", r.source, name, err)
+				}
+				values[name] = v
+				solved[name] = converted
+				changed = true
+			}
+		}
+	}
+
+	for name, value := range solved {
+		if err := catch(func() { s.checkPolicy(ActionWrite, name, value.Interface()) }); err != nil {
+			return err
+		}
+	}
+	for name, value := range solved {
+		s.SetField(name, value.Interface())
+	}
+	return nil
+}
+
+func (r relation) apply(left, right float64) (float64, bool) {
+	switch r.op {
+	case '+':
+		return left + right, true
+	case '-':
+		return left - right, true
+	case '*':
+		return left * right, true
+	case '/':
+		return left / right, right != 0
+	}
+	return left, true
+}
+
+// solveFor returns the value of the missing operand: 0 for the target, 1 for the left and 2 for the right operand.
+func (r relation) solveFor(missing int, known [3]float64) (float64, bool) {
+	var target, left, right = known[0], known[1], known[2]
+	switch missing {
+	case 0:
+		return r.apply(left, right)
+	case 1:
+		switch r.op {
+		case '+':
+			return target - right, true
+		case '-':
+			return target + right, true
+		case '*':
+			return target / right, right != 0
+		case '/':
+			return target * right, true
+		}
+		return target, true
+	default:
+		switch r.op {
+		case '+':
+			return target - left, true
+		case '-':
+			return left - target, true
+		case '*':
+			return target / left, left != 0
+		}
+		return left / target, target != 0
+	}
+}
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+}
+
+// numericFieldValue converts a float to the numeric type, or a pointer to it.
+func numericFieldValue(typ reflect.Type, v float64) (reflect.Value, error) {
+	if typ.Kind() == reflect.Ptr {
+		var elem, err = numericFieldValue(typ.Elem(), v)
+		if err != nil {
+			return elem, err
+		}
+		var ptr = reflect.New(typ.Elem())
+		ptr.Elem().Set(elem)
+		return ptr, nil
+	}
+	switch typ.Kind() {
+	case reflect.Float32:
+		v = float64(float32(v))
+	case reflect.Float64:
+	default:
+		if approxEqual(v, math.Round(v)) {
+			v = math.Round(v)
+		}
+	}
+	return convertTo(v, typ, "")
+}
@@ -1,7 +1,30 @@
 package structs_test
 
 import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"github.com/Nigel2392/go-structs"
 )
@@ -55,3 +78,3252 @@ func TestMakeStruct(t *testing.T) {
 		t.Errorf("Expected %t, got %t", true, s.GetField("is_cool"))
 	}
 }
+
+func TestMergeLWW(t *testing.T) {
+	var s = structs.New("json")
+	s.StringField("Name", "name")
+	s.IntField("Age", "age")
+	s.Make()
+
+	var other = s.DeepCopy()
+	var now = time.Now()
+
+	s.SetFieldAt("Name", "Nigel", now)
+	s.SetFieldAt("Age", 23, now.Add(time.Second))
+	other.SetFieldAt("Name", "Nigel2", now.Add(time.Second))
+	other.SetFieldAt("Age", 24, now)
+
+	if err := s.MergeLWW(other); err != nil {
+		t.Fatal(err)
+	}
+	if s.GetField("Name") != "Nigel2" {
+		t.Errorf("Expected %s, got %s", "Nigel2", s.GetField("Name"))
+	}
+	if s.GetField("Age") != 23 {
+		t.Errorf("Expected %d, got %d", 23, s.GetField("Age"))
+	}
+}
+
+func TestMergeLWWTies(t *testing.T) {
+	var newReplica = func() *structs.Struct {
+		var s = structs.New("json")
+		s.IntField("Count", "count")
+		s.AddField("Owner", "owner", reflect.TypeOf((*string)(nil)))
+		s.AddField("Tags", "tags", reflect.TypeOf([]string{}))
+		s.StringField("Note", "note")
+		s.Make()
+		return s
+	}
+	var now = time.Now()
+	var ann, bob = "ann", "bob"
+	var a, b = newReplica(), newReplica()
+	a.SetFieldAt("Count", 9, now)
+	b.SetFieldAt("Count", 10, now)
+	a.SetFieldAt("Owner", &bob, now)
+	b.SetFieldAt("Owner", &ann, now)
+	a.SetFieldAt("Tags", []string{"a"}, now)
+	b.SetFieldAt("Tags", []string{"b"}, now)
+	a.SetField("Note", "kept")
+	a.SetFieldAt("Note", "kept", time.Time{})
+	var tags = b.GetField("Tags").([]string)
+
+	// Both replicas merge the other, and must end up with the same values.
+	var merged = a.DeepCopy(true)
+	if err := merged.MergeLWW(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.MergeLWW(a); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Count", "Owner", "Tags"} {
+		if !reflect.DeepEqual(merged.GetField(name), b.GetField(name)) {
+			t.Errorf("Expected replicas to agree on %s, got %v and %v", name, merged.GetField(name), b.GetField(name))
+		}
+	}
+	if merged.GetField("Note") != "kept" || b.GetField("Note") != "" {
+		t.Errorf("Expected fields which were never written to be skipped, got %q and %q", merged.GetField("Note"), b.GetField("Note"))
+	}
+
+	tags[0] = "changed"
+	if merged.GetField("Tags").([]string)[0] != "b" {
+		t.Error("Expected the winning value to be copied")
+	}
+}
+
+func TestKV(t *testing.T) {
+	var address = structs.New("json")
+	address.StringField("City", "city")
+	address.Make()
+
+	var s = structs.New("json")
+	s.StringField("Name", "name")
+	s.IntField("Age", "age")
+	s.StructField("Address", "address", address)
+	s.Make()
+
+	var kv = s.ToKV("config")
+	if kv["config/address/city"] != "" || kv["config/age"] != "0" {
+		t.Errorf("Unexpected key/value pairs %v", kv)
+	}
+
+	kv["config/name"] = "Nigel"
+	kv["config/age"] = "23"
+	kv["config/address/city"] = "Amsterdam"
+	if err := s.FromKV("config/", kv); err != nil {
+		t.Fatal(err)
+	}
+	if s.GetField("Name") != "Nigel" {
+		t.Errorf("Expected %s, got %s", "Nigel", s.GetField("Name"))
+	}
+	if s.GetField("Age") != 23 {
+		t.Errorf("Expected %d, got %d", 23, s.GetField("Age"))
+	}
+	if city := s.FieldByName("Address").FieldByName("City").String(); city != "Amsterdam" {
+		t.Errorf("Expected %s, got %s", "Amsterdam", city)
+	}
+}
+
+func TestFromKVSetsFields(t *testing.T) {
+	var s = structs.New("json")
+	s.StringField("Title", "title")
+	s.DerivedField("Slug", "slug", "Title", structs.Slug(structs.SlugOptions{}))
+	s.IntField("Port", "port")
+	s.Make()
+
+	if err := s.FromKV("app", map[string]string{"app.title": "Hello World", "app/port": "x"}); err == nil {
+		t.Error("Expected an error for an invalid port")
+	}
+	if s.GetField("Title") != "" {
+		t.Error("Expected the struct to be left unchanged")
+	}
+	if err := s.FromKV("app", map[string]string{"app.title": "Hello World", "app/port": "80"}); err != nil {
+		t.Fatal(err)
+	}
+	if s.GetField("Slug") != "hello-world" || s.GetField("Port") != 80 || s.Stamp("Title").IsZero() {
+		t.Errorf("Expected the fields to be set through SetField, got %v", s.Interface())
+	}
+}
+
+func TestDecodeSkipsDerivedFields(t *testing.T) {
+	var decoders = map[string]func(s *structs.Struct) error{
+		"UnmarshalJSON": func(s *structs.Struct) error {
+			return s.UnmarshalJSON([]byte(`{"title":"Hello World","slug":"evil"}`))
+		},
+		"SetFromMap": func(s *structs.Struct) error {
+			return s.SetFromMap(map[string]interface{}{"title": "Hello World", "slug": "evil"})
+		},
+		"ApplyMergePatch": func(s *structs.Struct) error {
+			return s.ApplyMergePatch([]byte(`{"title":"Hello World","slug":"evil"}`))
+		},
+		"FromKV": func(s *structs.Struct) error {
+			return s.FromKV("", map[string]string{"title": "Hello World", "slug": "evil"})
+		},
+	}
+	for name, decode := range decoders {
+		// Map iteration order is random, repeat to make sure the order of the keys does not matter.
+		for i := 0; i < 20; i++ {
+			var s = structs.New("json")
+			s.StringField("Title", "title")
+			s.DerivedField("Slug", "slug", "Title", structs.Slug(structs.SlugOptions{}))
+			s.Make()
+			if err := decode(s); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if s.GetField("Slug") != "hello-world" {
+				t.Fatalf("%s: Expected the slug to be derived from the title, got %q", name, s.GetField("Slug"))
+			}
+		}
+	}
+}
+
+func TestTryMethods(t *testing.T) {
+	var s = structs.New("json")
+	s.StringField("Name", "name")
+
+	if err := s.TrySetField("Name", "Nigel"); !errors.Is(err, structs.ErrNotMade) {
+		t.Errorf("Expected %v, got %v", structs.ErrNotMade, err)
+	}
+	if err := s.TryMake(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.TrySetField("Age", 23); !errors.Is(err, structs.ErrFieldNotFound) {
+		t.Errorf("Expected %v, got %v", structs.ErrFieldNotFound, err)
+	}
+	if err := s.TrySetField("Name", 23); err == nil {
+		t.Errorf("Expected an error when setting a string field to an int")
+	}
+	if err := s.TrySetField("Name", "Nigel"); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := s.TryGetField("Name"); err != nil || v != "Nigel" {
+		t.Errorf("Expected %s, got %v (%v)", "Nigel", v, err)
+	}
+
+	if err := s.TryAddField("lower", "lower", reflect.TypeOf("")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.TryMake(); err == nil {
+		t.Errorf("Expected an error when making a struct with an unexported field")
+	}
+}
+
+func TestTrySchemaMethods(t *testing.T) {
+	var s = structs.New("json")
+	s.StringField("Name", "name")
+	s.IntField("Age", "age")
+
+	if _, err := s.TryGetPath("Name"); !errors.Is(err, structs.ErrNotMade) {
+		t.Errorf("Expected %v, got %v", structs.ErrNotMade, err)
+	}
+	if err := s.TrySetPath("Name", "Nigel"); !errors.Is(err, structs.ErrNotMade) {
+		t.Errorf("Expected %v, got %v", structs.ErrNotMade, err)
+	}
+	if _, _, err := s.TryRetention("Name"); !errors.Is(err, structs.ErrNotMade) {
+		t.Errorf("Expected %v, got %v", structs.ErrNotMade, err)
+	}
+
+	for name, err := range map[string]error{
+		"remove":     s.TryRemoveField("Missing"),
+		"rename":     s.TryRenameField("Missing", "Other"),
+		"replace":    s.TryReplaceField("Missing", reflect.TypeOf(0)),
+		"move":       s.TryMoveField("Missing", 0),
+		"set tag":    s.TrySetTag("Missing", "db", "missing"),
+		"delete tag": s.TryDeleteTag("Missing", "db"),
+	} {
+		if !errors.Is(err, structs.ErrFieldNotFound) {
+			t.Errorf("%s: expected %v, got %v", name, structs.ErrFieldNotFound, err)
+		}
+	}
+	for name, err := range map[string]error{
+		"rename to existing":   s.TryRenameField("Name", "Age"),
+		"replace without type": s.TryReplaceField("Name", nil),
+		"move out of range":    s.TryMoveField("Name", 2),
+		"embed non struct":     s.TryEmbed(reflect.TypeOf(0)),
+		"embed nil":            s.TryEmbed(nil),
+	} {
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	if err := s.TrySetTag("Age", "retain", "30d"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.TryMoveField("Age", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.TryRenameField("Name", "Title"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.TryMake(); err != nil {
+		t.Fatal(err)
+	}
+	if s.Field(0).Name != "Age" || s.Field(1).Name != "Title" {
+		t.Errorf("Expected fields Age and Title, got %s and %s", s.Field(0).Name, s.Field(1).Name)
+	}
+	if d, ok, err := s.TryRetention("Age"); err != nil || !ok || d != 30*24*time.Hour {
+		t.Errorf("Expected retention of 30 days, got %v %v %v", d, ok, err)
+	}
+	if _, _, err := s.TryRetention("Name"); !errors.Is(err, structs.ErrFieldNotFound) {
+		t.Errorf("Expected %v, got %v", structs.ErrFieldNotFound, err)
+	}
+	if err := s.TrySetPath("Title", "Nigel"); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := s.TryGetPath("Title"); err != nil || v != "Nigel" {
+		t.Errorf("Expected %s, got %v (%v)", "Nigel", v, err)
+	}
+	if _, err := s.TryGetPath("Missing"); err == nil {
+		t.Error("Expected an error for a path which does not exist")
+	}
+}
+
+func TestRemoveField(t *testing.T) {
+	var s = structs.New("json")
+	s.StringField("Name", "name")
+	s.IntField("Age", "age")
+	s.BoolField("Is_cool", "is_cool")
+	s.Make()
+
+	s.RemoveField("Age")
+	if s.IsValid() {
+		t.Errorf("Expected struct to be invalid after removing a field")
+	}
+	s.Make()
+	if s.NumField() != 2 {
+		t.Fatalf("Expected %d fields, got %d", 2, s.NumField())
+	}
+	if s.Field(0).Name != "Name" || s.Field(1).Name != "Is_cool" {
+		t.Errorf("Expected fields Name and Is_cool, got %s and %s", s.Field(0).Name, s.Field(1).Name)
+	}
+	if _, err := s.TryGetField("Age"); !errors.Is(err, structs.ErrFieldNotFound) {
+		t.Errorf("Expected %v, got %v", structs.ErrFieldNotFound, err)
+	}
+}
+
+func TestRemoveFieldChecksumsAndDerived(t *testing.T) {
+	var s = structs.New("json")
+	s.StringField("Name", "name")
+	s.IntField("Age", "age")
+	s.StringField("Title", "title")
+	s.ChecksumField("Sum", "sum", crypto.SHA256, "Name", "Age")
+	s.ChecksumField("AgeSum", "age_sum", crypto.SHA256, "Age")
+	s.DerivedField("Slug", "slug", "Title", structs.Slug(structs.SlugOptions{}))
+	s.RemoveField("Age")
+	s.RemoveField("Title")
+	s.Make()
+
+	s.SetField("Name", "John")
+	if err := s.UpdateChecksums(); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := s.VerifyChecksums(); err != nil || !ok {
+		t.Errorf("Expected checksums to verify, got %v %v", ok, err)
+	}
+	if s.GetField("Sum") == "" || s.GetField("AgeSum") != "" {
+		t.Errorf("Expected only Sum to be computed, got %q and %q", s.GetField("Sum"), s.GetField("AgeSum"))
+	}
+	s.SetField("Slug", "custom")
+	if s.GetField("Slug") != "custom" {
+		t.Errorf("Expected Slug to be a plain field, got %q", s.GetField("Slug"))
+	}
+}
+
+func TestRenameField(t *testing.T) {
+	var s = structs.New("json")
+	s.AddStructField(reflect.StructField{
+		Name: "Type_",
+		Type: reflect.TypeOf(""),
+		Tag:  `json:"type_,omitempty" db:"type"`,
+	})
+	s.IntField("Age", "age")
+	s.Make()
+	s.SetField("Type_", "admin")
+	s.SetField("Age", 23)
+
+	s.RenameField("Type_", "Kind", "type")
+	if !s.IsValid() {
+		t.Fatalf("Expected struct to be re-made after renaming a field")
+	}
+	if s.GetField("Kind") != "admin" {
+		t.Errorf("Expected %s, got %v", "admin", s.GetField("Kind"))
+	}
+	if s.GetField("Age") != 23 {
+		t.Errorf("Expected %d, got %v", 23, s.GetField("Age"))
+	}
+	var field = s.Field(0)
+	if field.Tag.Get("json") != "type,omitempty" || field.Tag.Get("db") != "type" {
+		t.Errorf("Unexpected tag %q", field.Tag)
+	}
+}
+
+func TestFieldOrder(t *testing.T) {
+	var s = structs.New("json")
+	s.StringField("Name", "name")
+	s.IntField("Age", "age")
+	s.BoolField("Is_cool", "is_cool")
+
+	s.MoveField("Is_cool", 0)
+	s.Make()
+	var data, err = json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"is_cool":false,"name":"","age":0}` {
+		t.Errorf("Unexpected field order %s", data)
+	}
+
+	s.SortFields(func(a, b reflect.StructField) bool {
+		return a.Name < b.Name
+	})
+	s.Make()
+	if data, err = json.Marshal(s); err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"age":0,"is_cool":false,"name":""}` {
+		t.Errorf("Unexpected field order %s", data)
+	}
+}
+
+func TestFromPreservesTags(t *testing.T) {
+	type User struct {
+		Name  string `json:"name" db:"user_name" validate:"required"`
+		Email string `db:"email"`
+		Skip  string `json:"-"`
+	}
+
+	var s = structs.From(User{}, "json")
+	s.Make()
+	if s.NumField() != 2 {
+		t.Fatalf("Expected %d fields, got %d", 2, s.NumField())
+	}
+	var name = s.Field(0)
+	if name.Tag.Get("json") != "name" || name.Tag.Get("db") != "user_name" || name.Tag.Get("validate") != "required" {
+		t.Errorf("Unexpected tag %q", name.Tag)
+	}
+	var email = s.Field(1)
+	if email.Tag.Get("json") != "Email" || email.Tag.Get("db") != "email" {
+		t.Errorf("Unexpected tag %q", email.Tag)
+	}
+}
+
+func TestAddFieldWithTags(t *testing.T) {
+	var s = structs.New("json")
+	s.AddFieldWithTags("Age", reflect.TypeOf(0), map[string]string{
+		"validate": "min=0",
+		"db":       "age_col",
+		"json":     "age,omitempty",
+	})
+	s.AddFieldWithTags("Name", reflect.TypeOf(""), map[string]string{"db": "name"})
+	s.Make()
+
+	var tag = s.Field(0).Tag
+	if tag != `json:"age,omitempty" db:"age_col" validate:"min=0"` {
+		t.Errorf("Unexpected tag %q", tag)
+	}
+	if tag = s.Field(1).Tag; tag != `json:"Name" db:"name"` {
+		t.Errorf("Unexpected tag %q", tag)
+	}
+}
+
+func TestMarshalHAL(t *testing.T) {
+	var s = structs.New("json")
+	s.AddField("ID", "id", reflect.TypeOf(0))
+	s.StringField("Name", "name")
+	s.Make()
+	s.SetField("ID", 7)
+	s.SetField("Name", "Ann")
+	s.WithLinks(map[string]string{"self": "/users/{ID}"})
+
+	var data, err = structs.MarshalHAL(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"_links":{"self":{"href":"/users/7"}},"id":7,"name":"Ann"}` {
+		t.Errorf("Unexpected HAL document %s", data)
+	}
+
+	s.WithLinks(map[string]string{"self": "/users/{Missing}"})
+	if _, err = structs.MarshalHAL(s); err == nil {
+		t.Error("Expected error for unknown field in link")
+	}
+}
+
+func TestTagManipulation(t *testing.T) {
+	var s = structs.New("json")
+	s.IntField("Age", "age")
+	s.Make()
+
+	s.SetTag("Age", "db", "age_col")
+	if s.IsValid() {
+		t.Error("Expected struct to be invalidated by SetTag")
+	}
+	if value, ok := s.GetTag("Age", "db"); !ok || value != "age_col" {
+		t.Errorf("Expected db tag %q, got %q", "age_col", value)
+	}
+	s.SetTag("Age", "json", "years")
+	s.DeleteTag("Age", "db")
+	if _, ok := s.GetTag("Age", "db"); ok {
+		t.Error("Expected db tag to be deleted")
+	}
+	s.Make()
+	if tag := s.Field(0).Tag; tag != `json:"years"` {
+		t.Errorf("Unexpected tag %q", tag)
+	}
+}
+
+type Address struct {
+	City string `json:"city"`
+}
+
+func TestEmbed(t *testing.T) {
+	var s = structs.New("json")
+	s.StringField("Name", "name")
+	s.Embed(reflect.TypeOf(Address{}))
+
+	var meta = structs.New("json")
+	meta.IntField("Version", "version")
+	meta.Make()
+	s.EmbedStruct("Meta", meta)
+	s.Make()
+
+	s.SetField("City", "Amsterdam")
+	s.SetField("Version", 2)
+	if s.GetField("City") != "Amsterdam" {
+		t.Errorf("Expected promoted field City to be %q, got %v", "Amsterdam", s.GetField("City"))
+	}
+	if s.GetField("Address").(Address).City != "Amsterdam" {
+		t.Errorf("Expected embedded field to be set")
+	}
+
+	var data, err = json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"name":"","city":"Amsterdam","version":2}` {
+		t.Errorf("Unexpected JSON %s", data)
+	}
+
+	var copied = s.DeepCopy()
+	if copied.GetField("Version") != 2 {
+		t.Errorf("Expected copied promoted field Version to be %d, got %v", 2, copied.GetField("Version"))
+	}
+}
+
+type treeNode struct {
+	Label    string    `json:"label"`
+	Parent   *treeNode `json:"parent"`
+	Location Address   `json:"location" db:"loc"`
+	Created  time.Time `json:"created"`
+}
+
+func TestFromRecursive(t *testing.T) {
+	var s = structs.FromRecursive(treeNode{}, "json", -1)
+	if s.Nested("Parent") != nil {
+		t.Error("Expected recursive field Parent not to be rebuilt")
+	}
+	if s.Nested("Created") != nil {
+		t.Error("Expected time.Time not to be rebuilt")
+	}
+	var location = s.Nested("Location")
+	if location == nil {
+		t.Fatal("Expected Location to be rebuilt")
+	}
+	location.StringField("Country", "country")
+	s.Make()
+
+	var field, _ = reflect.TypeOf(s.Interface()).FieldByName("Location")
+	if field.Type.NumField() != 2 {
+		t.Errorf("Expected nested struct to have %d fields, got %d", 2, field.Type.NumField())
+	}
+	if field.Tag.Get("db") != "loc" {
+		t.Errorf("Expected tag of nested field to be kept, got %q", field.Tag)
+	}
+
+	if structs.FromRecursive(treeNode{}, "json", 0).Nested("Location") != nil {
+		t.Error("Expected no nested structs with a max depth of 0")
+	}
+}
+
+func TestFromMap(t *testing.T) {
+	var m map[string]interface{}
+	var data = []byte(`{"first_name":"Ann","age":30,"tags":["a","b"],"address":{"city":"Amsterdam"},"items":[{"id":1},{"name":"x"}],"extra":null}`)
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+
+	var s = structs.FromMap(m, "json")
+	s.Make()
+	var typ = reflect.TypeOf(s.Interface())
+	var expected = map[string]reflect.Type{
+		"FirstName": reflect.TypeOf(""),
+		"Age":       reflect.TypeOf(float64(0)),
+		"Tags":      reflect.TypeOf([]string{}),
+		"Extra":     reflect.TypeOf((*interface{})(nil)).Elem(),
+	}
+	for name, fieldType := range expected {
+		var field, ok = typ.FieldByName(name)
+		if !ok || field.Type != fieldType {
+			t.Errorf("Expected field %s of type %s, got %v", name, fieldType, field.Type)
+		}
+	}
+	if s.Nested("Address") == nil {
+		t.Error("Expected Address to be a nested struct")
+	}
+	var items, _ = typ.FieldByName("Items")
+	if items.Type.Kind() != reflect.Slice || items.Type.Elem().NumField() != 2 {
+		t.Errorf("Expected Items to be a slice of structs with %d fields, got %s", 2, items.Type)
+	}
+
+	var ptr = s.PtrTo()
+	if err := json.Unmarshal(data, ptr); err != nil {
+		t.Fatal(err)
+	}
+	out, err := json.Marshal(ptr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"address":{"city":"Amsterdam"},"age":30,"extra":null,"first_name":"Ann","items":[{"id":1,"name":""},{"id":0,"name":"x"}],"tags":["a","b"]}` {
+		t.Errorf("Unexpected JSON %s", out)
+	}
+}
+
+func TestSubject(t *testing.T) {
+	var s = structs.New("json")
+	s.StringField("Region", "region")
+	s.StringField("Status", "status")
+	s.IntField("Total", "total")
+	s.Make()
+	s.SetField("Region", "eu")
+	s.SetField("Status", "shipped")
+	s.SetField("Total", 12)
+
+	var subject, data, err = structs.EncodeMessage(s, "orders.{Region}.{Status}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject != "orders.eu.shipped" {
+		t.Errorf("Expected subject %q, got %q", "orders.eu.shipped", subject)
+	}
+
+	var decoded = s.DeepCopy()
+	decoded.Make()
+	if err = structs.DecodeMessage(decoded, "orders.{Region}.{Status}", "orders.us.shipped", data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.GetField("Region") != "us" || decoded.GetField("Total") != 12 {
+		t.Errorf("Unexpected decoded values %v", decoded.Interface())
+	}
+
+	// The body is valid, but the subject holds a total which cannot be parsed.
+	var before = decoded.DeepCopy()
+	if err = structs.DecodeMessage(decoded, "orders.{Region}.{Total}", "orders.eu.twelve", []byte(`{"status":"lost"}`)); err == nil {
+		t.Error("Expected error for a subject value which cannot be parsed")
+	}
+	if !reflect.DeepEqual(decoded.Interface(), before.Interface()) {
+		t.Errorf("Expected struct to be left unchanged, got %v", decoded.Interface())
+	}
+
+	s.SetField("Status", "in.transit")
+	if _, err = s.Subject("orders.{Region}.{Status}"); err == nil {
+		t.Error("Expected error for value containing a dot")
+	}
+	if _, err = structs.ParseSubject("orders.{Region}", "invoices.eu"); err == nil {
+		t.Error("Expected error for mismatched subject")
+	}
+}
+
+func TestTelemetry(t *testing.T) {
+	var s = structs.New("json")
+	s.StringField("Model", "model")
+	s.IntField("Serial", "serial")
+	s.FloatField("Temperature", "temperature")
+	s.AddField("Readings", "readings", reflect.TypeOf([]int16{}))
+	s.AddField("Seen", "seen", reflect.TypeOf(time.Time{}))
+	s.AddField("Battery", "battery", reflect.TypeOf((*uint8)(nil)))
+	s.Make()
+	s.SetField("Model", "t100")
+	s.SetField("Serial", 42)
+	s.SetField("Temperature", 21.5)
+	s.SetField("Readings", []int16{-3, 0, 300})
+	s.SetField("Seen", time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+
+	var topic, payload, err = structs.EncodeTelemetry(s, "devices/{Model}/{Serial}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if topic != "devices/t100/42" {
+		t.Errorf("Expected topic %q, got %q", "devices/t100/42", topic)
+	}
+
+	var decoded = s.DeepCopy()
+	decoded.Make()
+	if err = structs.DecodeTelemetry(decoded, "devices/{Model}/{Serial}", "devices/t200/42", payload); err != nil {
+		t.Fatal(err)
+	}
+	s.SetField("Model", "t200")
+	if !reflect.DeepEqual(decoded.Interface(), s.Interface()) {
+		t.Errorf("Expected %v, got %v", s.Interface(), decoded.Interface())
+	}
+
+	s.SetField("Temperature", 30.0)
+	newPayload, err := structs.MarshalCompact(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = structs.DecodeTelemetry(decoded, "devices/{Model}/{Serial}", "devices/t300/forty-two", newPayload); err == nil {
+		t.Error("Expected error for a topic value which cannot be parsed")
+	}
+	if decoded.GetField("Temperature") != 21.5 || decoded.GetField("Model") != "t200" {
+		t.Errorf("Expected struct to be left unchanged, got %v", decoded.Interface())
+	}
+
+	if err = structs.UnmarshalCompact(decoded, payload[:len(payload)-1]); err == nil {
+		t.Error("Expected error for truncated payload")
+	}
+}
+
+func TestFromCSV(t *testing.T) {
+	var data = "id,name,score,active,joined\n" +
+		"1,Ann,3,true,2024-01-02T15:04:05Z\n" +
+		"2,Bob,4.5,false,\n"
+
+	var s, err = structs.FromCSV(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Make()
+	var typ = reflect.TypeOf(s.Interface())
+	var expected = []reflect.Type{
+		reflect.TypeOf(int64(0)),
+		reflect.TypeOf(""),
+		reflect.TypeOf(float64(0)),
+		reflect.TypeOf(false),
+		reflect.TypeOf(time.Time{}),
+	}
+	for i, fieldType := range expected {
+		if typ.Field(i).Type != fieldType {
+			t.Errorf("Expected field %s of type %s, got %s", typ.Field(i).Name, fieldType, typ.Field(i).Type)
+		}
+	}
+
+	decoder, err := structs.NewCSVDecoder(strings.NewReader(data), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for {
+		if err = decoder.Decode(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, s.GetField("Name").(string))
+	}
+	if !reflect.DeepEqual(names, []string{"Ann", "Bob"}) {
+		t.Errorf("Unexpected rows %v", names)
+	}
+	if s.GetField("Score") != 4.5 || !s.GetField("Joined").(time.Time).IsZero() {
+		t.Errorf("Unexpected last row %v", s.Interface())
+	}
+}
+
+func TestDelta(t *testing.T) {
+	var prev = structs.New("json")
+	prev.StringField("Name", "name")
+	prev.IntField("Count", "count")
+	prev.FloatField("Level", "level")
+	prev.Make()
+	prev.SetField("Name", "sensor")
+	prev.SetField("Count", 5)
+	prev.SetField("Level", 0.5)
+
+	var curr = prev.DeepCopy()
+	curr.SetField("Count", 0)
+	curr.SetField("Level", 0.75)
+
+	var delta, err = structs.EncodeDelta(prev, curr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, err := structs.MarshalCompact(curr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(delta) >= len(full) {
+		t.Errorf("Expected delta of %d bytes to be smaller than %d bytes", len(delta), len(full))
+	}
+
+	if err = structs.ApplyDelta(prev, delta); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(prev.Interface(), curr.Interface()) {
+		t.Errorf("Expected %v, got %v", curr.Interface(), prev.Interface())
+	}
+}
+
+func TestCompactMarshalers(t *testing.T) {
+	var s = structs.New("json")
+	s.StringField("Name", "name")
+	s.BlobField("Image", "image")
+	s.Make()
+	s.SetField("Name", "avatar")
+	s.SetField("Image", structs.BlobRef("https://example.com/avatar.png"))
+
+	var data, err = structs.MarshalCompact(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded = s.DeepCopy()
+	decoded.SetField("Image", (*structs.Blob)(nil))
+	if err = structs.UnmarshalCompact(decoded, data); err != nil {
+		t.Fatal(err)
+	}
+	if blob := decoded.GetField("Image").(*structs.Blob); blob == nil || blob.Ref() != "https://example.com/avatar.png" {
+		t.Errorf("Expected blob reference to round-trip, got %v", blob)
+	}
+
+	type opaque struct{ n int }
+	var o = structs.New("json")
+	o.AddField("Opaque", "opaque", reflect.TypeOf(opaque{}))
+	o.Make()
+	if _, err = structs.MarshalCompact(o); err != nil {
+		t.Errorf("Expected zero opaque field to be omitted, got %v", err)
+	}
+	o.SetField("Opaque", opaque{n: 1})
+	if _, err = structs.MarshalCompact(o); err == nil {
+		t.Error("Expected error encoding struct with unexported fields")
+	}
+	if err = structs.UnmarshalCompact(o, []byte{1}); err == nil {
+		t.Error("Expected error decoding struct with unexported fields")
+	}
+}
+
+func TestChunked(t *testing.T) {
+	var s = structs.New("json")
+	s.AddField("Samples", "samples", reflect.TypeOf([]int{}))
+	s.Make()
+	var samples = make([]int, 500)
+	for i := range samples {
+		samples[i] = i * i
+	}
+	s.SetField("Samples", samples)
+
+	var buf bytes.Buffer
+	if err := s.MarshalChunked(&buf, 256); err != nil {
+		t.Fatal(err)
+	}
+
+	// Cut the transfer off halfway through, and resume from the first missing chunk.
+	var reader structs.ChunkReader
+	if _, err := reader.ReadFrom(bytes.NewReader(buf.Bytes()[:buf.Len()/2])); err != io.ErrUnexpectedEOF {
+		t.Fatalf("Expected %v, got %v", io.ErrUnexpectedEOF, err)
+	}
+	if reader.Done() {
+		t.Fatal("Expected transfer to be incomplete")
+	}
+	var decoded = s.DeepCopy()
+	decoded.Make()
+	if err := reader.Unmarshal(decoded); err != structs.ErrChunksIncomplete {
+		t.Errorf("Expected %v, got %v", structs.ErrChunksIncomplete, err)
+	}
+
+	buf.Reset()
+	if err := s.MarshalChunkedFrom(&buf, 256, reader.Next()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reader.ReadFrom(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := reader.Unmarshal(decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded.GetField("Samples"), samples) {
+		t.Error("Expected samples to survive the chunked transfer")
+	}
+}
+
+func TestChunkReaderLimits(t *testing.T) {
+	var header = func(index, total, size uint32) []byte {
+		var frame = binary.BigEndian.AppendUint32(nil, index)
+		frame = binary.BigEndian.AppendUint32(frame, total)
+		return binary.BigEndian.AppendUint32(frame, size)
+	}
+
+	var reader structs.ChunkReader
+	if _, err := reader.ReadFrom(bytes.NewReader(header(0, math.MaxUint32, 1))); err == nil {
+		t.Error("Expected an error for a transfer with too many chunks")
+	}
+
+	var s = structs.New("json")
+	s.StringField("Name", "name")
+	s.Make()
+	s.SetField("Name", strings.Repeat("x", 100))
+	var buf bytes.Buffer
+	if err := s.MarshalChunked(&buf, 16); err != nil {
+		t.Fatal(err)
+	}
+	reader = structs.ChunkReader{MaxSize: 64}
+	if _, err := reader.ReadFrom(&buf); err == nil {
+		t.Error("Expected an error for a transfer exceeding the maximum size")
+	}
+}
+
+func TestFromJSONSchema(t *testing.T) {
+	var schema = []byte(`{
+		"type": "object",
+		"required": ["id", "status"],
+		"properties": {
+			"id": {"type": "integer"},
+			"status": {"type": "string", "enum": ["draft", "published"]},
+			"score": {"type": ["number", "null"]},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"author": {"$ref": "#/$defs/person"},
+			"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+			"created": {"type": "string", "format": "date-time"}
+		},
+		"$defs": {
+			"person": {"type": "object", "properties": {"name": {"type": "string"}}}
+		}
+	}`)
+
+	var s, err = structs.FromJSONSchema(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Make()
+	var typ = reflect.TypeOf(s.Interface())
+	var expected = []struct {
+		name string
+		typ  reflect.Type
+		tag  reflect.StructTag
+	}{
+		{"Id", reflect.TypeOf(int64(0)), `json:"id" structs:"required"`},
+		{"Status", reflect.TypeOf(""), `json:"status" structs:"required" enum:"[\"draft\",\"published\"]"`},
+		{"Score", reflect.TypeOf((*float64)(nil)), `json:"score,omitempty"`},
+		{"Tags", reflect.TypeOf([]string{}), `json:"tags,omitempty"`},
+		{"Labels", reflect.TypeOf(map[string]string{}), `json:"labels,omitempty"`},
+		{"Created", reflect.TypeOf(time.Time{}), `json:"created,omitempty"`},
+	}
+	for _, e := range expected {
+		var field, ok = typ.FieldByName(e.name)
+		if !ok || field.Type != e.typ || field.Tag != e.tag {
+			t.Errorf("Expected field %s %s %s, got %s %s", e.name, e.typ, e.tag, field.Type, field.Tag)
+		}
+	}
+	if typ.Field(4).Name != "Author" || s.Nested("Author") == nil {
+		t.Errorf("Expected field Author to be a nested struct in schema order")
+	}
+
+	validators, err := structs.EnumValidators(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = validators.Validate("Status", "draft"); err != nil {
+		t.Error(err)
+	}
+	if err = validators.Validate("Status", "archived"); err == nil {
+		t.Error("Expected error for value not in enum")
+	}
+
+	if _, err = structs.FromJSONSchema([]byte(`{"type": "object", "properties": {"next": {"$ref": "#/$defs/node"}}, "$defs": {"node": {"type": "object", "properties": {"next": {"$ref": "#/$defs/node"}}}}}`)); err == nil {
+		t.Error("Expected error for recursive $ref")
+	}
+}
+
+func TestFromOpenAPI(t *testing.T) {
+	var doc = []byte(`{
+		"openapi": "3.0.3",
+		"components": {
+			"schemas": {
+				"Pet": {
+					"type": "object",
+					"required": ["id"],
+					"properties": {
+						"id": {"type": "integer", "format": "int64"},
+						"age": {"type": "integer", "format": "int32", "nullable": true},
+						"weight": {"type": "number", "format": "float"},
+						"born": {"type": "string", "format": "date-time"},
+						"photo": {"type": "string", "format": "byte"},
+						"owner": {"$ref": "#/components/schemas/Owner"}
+					}
+				},
+				"Owner": {"type": "object", "properties": {"name": {"type": "string"}}}
+			}
+		}
+	}`)
+
+	var s, err = structs.FromOpenAPI(doc, "Pet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Make()
+	var typ = reflect.TypeOf(s.Interface())
+	var expected = []reflect.Type{
+		reflect.TypeOf(int64(0)),
+		reflect.TypeOf((*int32)(nil)),
+		reflect.TypeOf(float32(0)),
+		reflect.TypeOf(time.Time{}),
+		reflect.TypeOf([]byte(nil)),
+	}
+	for i, fieldType := range expected {
+		if typ.Field(i).Type != fieldType {
+			t.Errorf("Expected field %s of type %s, got %s", typ.Field(i).Name, fieldType, typ.Field(i).Type)
+		}
+	}
+	if s.Nested("Owner") == nil {
+		t.Error("Expected Owner to be a nested struct")
+	}
+	if _, err = structs.FromOpenAPI(doc, "Missing"); err == nil {
+		t.Error("Expected error for missing component")
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	var s = structs.New("json")
+	s.AddField("ID", "id", reflect.TypeOf(int64(0)), true)
+	s.AddFieldWithTags("Status", reflect.TypeOf(""), map[string]string{
+		"json":        "status",
+		"enum":        `["draft","published"]`,
+		"description": "Publication state",
+	})
+	s.AddField("Score", "score", reflect.TypeOf((*float64)(nil)))
+	s.AddField("Tags", "tags", reflect.TypeOf([]string{}))
+	s.AddField("Created", "created", reflect.TypeOf(time.Time{}))
+	s.AddField("Secret", "-", reflect.TypeOf(""))
+	s.Embed(reflect.TypeOf(Address{}))
+	s.Make()
+
+	var data, err = s.JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected = `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{` +
+		`"id":{"type":"integer"},` +
+		`"status":{"type":"string","description":"Publication state","enum":["draft","published"]},` +
+		`"score":{"type":["number","null"]},` +
+		`"tags":{"type":"array","items":{"type":"string"}},` +
+		`"created":{"type":"string","format":"date-time"},` +
+		`"city":{"type":"string"}},"required":["id"]}`
+	if string(data) != expected {
+		t.Errorf("Unexpected schema\n%s\nexpected\n%s", data, expected)
+	}
+
+	imported, err := structs.FromJSONSchema(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported.Make()
+	if imported.NumField() != 6 {
+		t.Errorf("Expected %d fields after round trip, got %d", 6, imported.NumField())
+	}
+}
+
+func TestOpenAPISchema(t *testing.T) {
+	var s = structs.New("json")
+	s.AddField("ID", "id", reflect.TypeOf(int64(0)), true)
+	s.AddFieldWithTags("Age", reflect.TypeOf((*int32)(nil)), map[string]string{"json": "age", "description": "Age in years"})
+	s.AddField("Photo", "photo", reflect.TypeOf([]byte(nil)))
+	s.AddFieldWithTags("Email", reflect.TypeOf(""), map[string]string{"json": "email", "format": "email"})
+	s.Make()
+
+	var data, err = s.OpenAPISchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected = `{"type":"object","properties":{` +
+		`"id":{"type":"integer","format":"int64"},` +
+		`"age":{"type":"integer","format":"int32","nullable":true,"description":"Age in years"},` +
+		`"photo":{"type":"string","format":"byte"},` +
+		`"email":{"type":"string","format":"email"}},"required":["id"]}`
+	if string(data) != expected {
+		t.Errorf("Unexpected schema\n%s\nexpected\n%s", data, expected)
+	}
+}
+
+func TestSchemasForMarshalers(t *testing.T) {
+	var s = structs.New("json")
+	s.MoneyField("Price", "price", "EUR")
+	s.QuantityField("Weight", "weight", "kg")
+	s.GeoPointField("Location", "location")
+	s.BoundingBoxField("Area", "area")
+	s.BlobField("Image", "image")
+	s.FlagsField("Roles", "roles", []string{"admin", "editor"}, true)
+	s.FlagsField("Mask", "mask", []string{"read", "write"}, false)
+	s.Make()
+
+	var data, err = s.JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected = `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{` +
+		`"price":{"type":"object","properties":{"amount":{"type":"string"},"currency":{"type":"string"}},"required":["amount","currency"]},` +
+		`"weight":{"type":"object","properties":{"value":{"type":"number"},"unit":{"type":"string"}},"required":["value","unit"]},` +
+		`"location":{"type":"object","properties":{"type":{"type":"string","enum":["Point"]},"coordinates":{"type":"array","items":{"type":"number"},"minItems":2}},"required":["type","coordinates"]},` +
+		`"area":{"type":"array","items":{"type":"number"},"minItems":4,"maxItems":4},` +
+		`"image":{"type":["object","null"],"properties":{"ref":{"type":"string"},"data":{"type":"string","contentEncoding":"base64"}}},` +
+		`"roles":{"type":"array","items":{"type":"string","enum":["admin","editor"]}},` +
+		`"mask":{"type":"integer"}}}`
+	if string(data) != expected {
+		t.Errorf("Unexpected schema\n%s\nexpected\n%s", data, expected)
+	}
+
+	var ts = s.TypeScript("Product")
+	for _, property := range []string{
+		"price: { amount: string; currency: string };",
+		"weight: { value: number; unit: string };",
+		`location: { type: "Point"; coordinates: number[] };`,
+		"area: [number, number, number, number];",
+		"image: { ref?: string; data?: string } | null;",
+		`roles: ("admin" | "editor")[];`,
+		"mask: number;",
+	} {
+		if !strings.Contains(ts, property) {
+			t.Errorf("Expected TypeScript to contain %q, got\n%s", property, ts)
+		}
+	}
+
+	var graphQL = s.GraphQLType("Product")
+	var expectedGraphQL = "type Product {\n  price: String\n  weight: String\n  location: String\n  area: String\n  image: String\n  roles: [String!]\n  mask: Int\n}\n"
+	if graphQL != expectedGraphQL {
+		t.Errorf("Unexpected GraphQL type\n%s\nexpected\n%s", graphQL, expectedGraphQL)
+	}
+}
+
+func TestExpire(t *testing.T) {
+	var s = structs.New("json")
+	s.AddFieldWithTags("IP", reflect.TypeOf(""), map[string]string{"json": "ip", "retain": "30d"})
+	s.AddFieldWithTags("Session", reflect.TypeOf(""), map[string]string{"json": "session", "retain": "12h"})
+	s.StringField("Name", "name")
+	s.Make()
+
+	var now = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	s.SetFieldAt("IP", "10.0.0.1", now.AddDate(0, 0, -10))
+	s.SetFieldAt("Session", "abc", now.Add(-13*time.Hour))
+	s.SetFieldAt("Name", "Ann", now.AddDate(-1, 0, 0))
+
+	var expired, err = s.Expire(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expired, []string{"Session"}) {
+		t.Errorf("Expected only Session to expire, got %v", expired)
+	}
+	if s.GetField("Session") != "" || s.GetField("IP") != "10.0.0.1" || s.GetField("Name") != "Ann" {
+		t.Errorf("Unexpected values after expiry %v", s.Interface())
+	}
+
+	if expired, _ = s.Expire(now.AddDate(0, 0, 21)); !reflect.DeepEqual(expired, []string{"IP"}) {
+		t.Errorf("Expected IP to expire, got %v", expired)
+	}
+
+	// A write time in the future is moved back, so the field still expires.
+	s.SetFieldAt("Session", "def", now.AddDate(10, 0, 0))
+	if expired, _ = s.Expire(now); len(expired) != 0 {
+		t.Errorf("Expected no fields to expire, got %v", expired)
+	}
+	if expired, _ = s.Expire(now.Add(13 * time.Hour)); !reflect.DeepEqual(expired, []string{"Session"}) {
+		t.Errorf("Expected Session with a future write time to expire, got %v", expired)
+	}
+}
+
+func TestPersonalData(t *testing.T) {
+	var s = structs.New("json")
+	s.AddFieldWithTags("Email", reflect.TypeOf(""), map[string]string{"json": "email", "pii": "pseudonymize"})
+	s.AddFieldWithTags("Phone", reflect.TypeOf(""), map[string]string{"json": "phone", "pii": ""})
+	s.IntField("Orders", "orders")
+	s.Make()
+	s.SetField("Email", "ann@example.com")
+	s.SetField("Phone", "+31612345678")
+	s.SetField("Orders", 3)
+
+	var data, err = s.ExportPersonalData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var export structs.PersonalDataExport
+	if err = json.Unmarshal(data, &export); err != nil {
+		t.Fatal(err)
+	}
+	if len(export.Data) != 2 || string(export.Data["email"]) != `"ann@example.com"` {
+		t.Errorf("Unexpected export %s", data)
+	}
+
+	var receipt = s.ErasePersonalData([]byte("key"))
+	if !reflect.DeepEqual(receipt.Erased, []string{"Phone"}) || !reflect.DeepEqual(receipt.Pseudonymized, []string{"Email"}) {
+		t.Errorf("Unexpected receipt %+v", receipt)
+	}
+	var email = s.GetField("Email").(string)
+	if email == "ann@example.com" || len(email) != 64 {
+		t.Errorf("Expected email to be pseudonymized, got %q", email)
+	}
+	if s.GetField("Phone") != "" || s.GetField("Orders") != 3 {
+		t.Errorf("Unexpected values after erasure %v", s.Interface())
+	}
+}
+
+func TestProtoDefinition(t *testing.T) {
+	var s = structs.New("json")
+	s.AddField("UserID", "user_id", reflect.TypeOf(int64(0)))
+	s.AddFieldWithTags("Email", reflect.TypeOf(""), map[string]string{"json": "email", "proto": "1"})
+	s.AddField("Tags", "tags", reflect.TypeOf([]string{}))
+	s.AddField("Score", "score", reflect.TypeOf((*float32)(nil)))
+	s.Embed(reflect.TypeOf(Address{}))
+	s.AddField("Created", "created", reflect.TypeOf(time.Time{}))
+	s.Make()
+
+	var expected = "message User {\n" +
+		"  message Address {\n" +
+		"    string city = 1;\n" +
+		"  }\n" +
+		"  int64 user_id = 2;\n" +
+		"  string email = 1;\n" +
+		"  repeated string tags = 3;\n" +
+		"  optional float score = 4;\n" +
+		"  Address address = 5;\n" +
+		"  google.protobuf.Timestamp created = 6;\n" +
+		"}\n"
+	if definition := s.ProtoDefinition("User"); definition != expected {
+		t.Errorf("Unexpected definition\n%s\nexpected\n%s", definition, expected)
+	}
+}
+
+func TestRulePolicy(t *testing.T) {
+	var policy, err = structs.ParseRulePolicy(strings.NewReader(`
+# Salaries are only visible to HR
+allow * Salary hr
+deny * Salary
+allow read *
+allow write Name
+default deny
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var s = structs.New("json")
+	s.StringField("Name", "name")
+	s.IntField("Salary", "salary")
+	s.Make()
+	s.SetField("Salary", 5000)
+
+	s.SetPolicy(structs.WithRoles(context.Background(), "staff"), policy)
+	s.SetField("Name", "Ann")
+	if _, err = s.TryGetField("Salary"); !errors.Is(err, structs.ErrDenied) {
+		t.Errorf("Expected %v, got %v", structs.ErrDenied, err)
+	}
+	if err = s.TrySetField("Salary", 1); !errors.Is(err, structs.ErrDenied) {
+		t.Errorf("Expected %v, got %v", structs.ErrDenied, err)
+	}
+	var data, _ = json.Marshal(s)
+	if string(data) != `{"name":"Ann"}` {
+		t.Errorf("Expected salary to be omitted, got %s", data)
+	}
+
+	s.SetPolicy(structs.WithRoles(context.Background(), "hr"), policy)
+	if s.GetField("Salary") != 5000 {
+		t.Errorf("Expected HR to read the salary")
+	}
+	if err = s.TrySetField("Name", "Bob"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTypeScript(t *testing.T) {
+	var s = structs.New("json")
+	s.AddField("ID", "id", reflect.TypeOf(int64(0)))
+	s.AddField("Nickname", "nickname,omitempty", reflect.TypeOf((*string)(nil)))
+	s.AddField("Tags", "tags", reflect.TypeOf([]string{}))
+	s.AddField("Scores", "scores", reflect.TypeOf(map[string]float64{}))
+	s.AddField("Created", "created-at", reflect.TypeOf(time.Time{}))
+	s.AddField("Home", "home", reflect.TypeOf(Address{}))
+	s.Make()
+
+	var expected = "export interface User {\n" +
+		"  id: number;\n" +
+		"  nickname?: string | null;\n" +
+		"  tags: string[];\n" +
+		"  scores: Record<string, number>;\n" +
+		"  \"created-at\": string;\n" +
+		"  home: {\n" +
+		"    city: string;\n" +
+		"  };\n" +
+		"}\n"
+	if ts := s.TypeScript("User"); ts != expected {
+		t.Errorf("Unexpected interface\n%s\nexpected\n%s", ts, expected)
+	}
+}
+
+func TestMonitor(t *testing.T) {
+	var s = structs.New("json")
+	s.StringField("Sensor", "sensor")
+	s.FloatField("Temperature", "temperature")
+	s.Make()
+
+	var reported []structs.Anomaly
+	var m = structs.NewMonitor(s, func(a structs.Anomaly) {
+		reported = append(reported, a)
+	})
+	m.MinSamples = 10
+	for i := 0; i < 50; i++ {
+		s.SetField("Temperature", 20+float64(i%5)/10)
+		m.Observe(s)
+	}
+	if len(reported) != 0 {
+		t.Fatalf("Expected no anomalies, got %v", reported)
+	}
+	s.SetField("Temperature", 35.0)
+	m.Observe(s)
+	if len(reported) != 1 || reported[0].Field != "Temperature" || reported[0].Score < 3 {
+		t.Errorf("Expected one anomaly for Temperature, got %v", reported)
+	}
+	if _, _, count := m.Stats("Temperature"); count != 51 {
+		t.Errorf("Expected %d observations, got %d", 51, count)
+	}
+}
+
+func TestGraphQLType(t *testing.T) {
+	var s = structs.New("json")
+	s.AddField("ID", "id", reflect.TypeOf(int64(0)), true)
+	s.AddField("Name", "name", reflect.TypeOf(""), true)
+	s.AddField("Score", "score", reflect.TypeOf((*float64)(nil)))
+	s.AddField("Tags", "tags", reflect.TypeOf([]string{}))
+	s.AddField("Home", "home", reflect.TypeOf(Address{}))
+	s.AddField("Secret", "-", reflect.TypeOf(""))
+	s.Make()
+
+	var expected = "type UserHome {\n" +
+		"  city: String\n" +
+		"}\n" +
+		"type User {\n" +
+		"  id: Int!\n" +
+		"  name: String!\n" +
+		"  score: Float\n" +
+		"  tags: [String!]\n" +
+		"  home: UserHome\n" +
+		"}\n"
+	if sdl := s.GraphQLType("User"); sdl != expected {
+		t.Errorf("Unexpected SDL\n%s\nexpected\n%s", sdl, expected)
+	}
+}
+
+func TestDDL(t *testing.T) {
+	var s = structs.New("json")
+	s.AddField("ID", "id", reflect.TypeOf(int64(0)), true)
+	s.AddFieldWithTags("Email", reflect.TypeOf(""), map[string]string{"json": "email", "db": "email_address", "structs": "required"})
+	s.AddField("Score", "score", reflect.TypeOf((*float64)(nil)))
+	s.AddField("Tags", "tags", reflect.TypeOf([]string{}))
+	s.AddField("CreatedAt", "created", reflect.TypeOf(time.Time{}))
+	s.AddFieldWithTags("Internal", reflect.TypeOf(""), map[string]string{"db": "-"})
+	s.Make()
+
+	var ddl, err = s.DDL("postgres", "users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected = "CREATE TABLE \"users\" (\n" +
+		"\t\"id\" BIGINT NOT NULL,\n" +
+		"\t\"email_address\" TEXT NOT NULL,\n" +
+		"\t\"score\" DOUBLE PRECISION,\n" +
+		"\t\"tags\" JSONB,\n" +
+		"\t\"created_at\" TIMESTAMPTZ\n" +
+		");"
+	if ddl != expected {
+		t.Errorf("Unexpected DDL\n%s\nexpected\n%s", ddl, expected)
+	}
+
+	if ddl, err = s.DDL("mysql", "users"); err != nil || !strings.Contains(ddl, "`created_at` DATETIME(6)") {
+		t.Errorf("Unexpected mysql DDL %s (%v)", ddl, err)
+	}
+	if _, err = s.DDL("oracle", "users"); err == nil {
+		t.Error("Expected error for unknown dialect")
+	}
+}
+
+func TestInferSchema(t *testing.T) {
+	var docs = [][]byte{
+		[]byte(`{"id": 1, "price": 10, "code": "a", "tags": ["x"], "address": {"city": "Amsterdam"}}`),
+		[]byte(`{"id": 2, "price": 12.5, "code": 7, "note": null, "address": {"city": "Utrecht", "zip": "3511"}}`),
+	}
+
+	var s, decisions, err = structs.InferSchema(docs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Make()
+	var typ = reflect.TypeOf(s.Interface())
+	var expected = map[string]reflect.Type{
+		"Id":    reflect.TypeOf(int64(0)),
+		"Price": reflect.TypeOf(float64(0)),
+		"Code":  reflect.TypeOf((*interface{})(nil)).Elem(),
+		"Tags":  reflect.TypeOf([]string{}),
+		"Note":  reflect.TypeOf((*interface{})(nil)).Elem(),
+	}
+	for name, fieldType := range expected {
+		if field, _ := typ.FieldByName(name); field.Type != fieldType {
+			t.Errorf("Expected field %s of type %s, got %v", name, fieldType, field.Type)
+		}
+	}
+	var zip, _ = reflect.TypeOf(s.Nested("Address").Interface()).FieldByName("Zip")
+	if zip.Type != reflect.TypeOf((*string)(nil)) {
+		t.Errorf("Expected Zip to be nullable, got %s", zip.Type)
+	}
+
+	var reasons = make(map[string]string)
+	for _, d := range decisions {
+		reasons[d.Path] = d.Reason
+	}
+	if reasons["price"] != "promoted integer and float to float64" {
+		t.Errorf("Unexpected decision for price: %q", reasons["price"])
+	}
+
+	var rules = structs.InferRules{ConflictAsString: true, NullThreshold: 0.5}
+	if s, _, err = rules.Infer(docs...); err != nil {
+		t.Fatal(err)
+	}
+	s.Make()
+	typ = reflect.TypeOf(s.Interface())
+	if field, _ := typ.FieldByName("Price"); field.Type != reflect.TypeOf("") {
+		t.Errorf("Expected Price to be a string without widening, got %s", field.Type)
+	}
+	if field, _ := typ.FieldByName("Tags"); field.Type != reflect.TypeOf([]string{}) {
+		t.Errorf("Expected Tags to be a slice, got %s", field.Type)
+	}
+}
+
+func TestUnionIntersect(t *testing.T) {
+	var a = structs.New("json")
+	a.IntField("ID", "id")
+	a.StringField("Name", "name")
+	a.IntField("Score", "score")
+
+	var b = structs.New("json")
+	b.IntField("ID", "id")
+	b.FloatField("Score", "score")
+	b.StringField("Email", "email")
+
+	var union, report = structs.Union(a, b)
+	union.Make()
+	var names []string
+	for i := 0; i < union.NumField(); i++ {
+		names = append(names, union.Field(i).Name)
+	}
+	if !reflect.DeepEqual(names, []string{"ID", "Name", "Score", "Email"}) {
+		t.Errorf("Unexpected union fields %v", names)
+	}
+	if len(report) != 1 || report[0].Name != "Score" || report[0].Resolved != reflect.TypeOf(0) {
+		t.Errorf("Unexpected conflict report %v", report)
+	}
+
+	var intersection, _, err = structs.IntersectWith(a, b, func(a, b reflect.StructField) (reflect.StructField, error) {
+		return b, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	intersection.Make()
+	if intersection.NumField() != 2 || intersection.Field(1).Type != reflect.TypeOf(float64(0)) {
+		t.Errorf("Unexpected intersection %v", reflect.TypeOf(intersection.Interface()))
+	}
+}
+
+func TestMapSetFromMap(t *testing.T) {
+	var s = structs.New("json")
+	s.StringField("Name", "name")
+	s.IntField("Age", "age")
+	s.AddField("Address", "address", reflect.TypeOf(Address{}))
+	s.AddField("Tags", "tags", reflect.TypeOf([]string{}))
+	s.Make()
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(`{"name":"John","age":30,"address":{"city":"Amsterdam"},"tags":["a","b"],"other":1}`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetFromMap(decoded); err != nil {
+		t.Fatal(err)
+	}
+	var m = s.Map(structs.MapNested())
+	if m["name"] != "John" || m["age"] != 30 || !reflect.DeepEqual(m["address"], map[string]interface{}{"city": "Amsterdam"}) {
+		t.Errorf("Unexpected map %v", m)
+	}
+	if m = s.Map(structs.MapFieldNames()); m["Address"] != (Address{City: "Amsterdam"}) {
+		t.Errorf("Unexpected map %v", m)
+	}
+
+	if err := s.SetFromMap(map[string]interface{}{"Name": "Jane", "age": 1.5}); err == nil {
+		t.Error("Expected an error converting 1.5 to int")
+	}
+	if s.GetField("Name") != "John" {
+		t.Errorf("Expected the struct to be left unchanged, got %v", s.GetField("Name"))
+	}
+}
+
+func TestFlattenUnflatten(t *testing.T) {
+	var s = structs.New("json")
+	s.StringField("Name", "name")
+	s.AddField("Address", "address", reflect.TypeOf(Address{}))
+	s.Make()
+	s.SetField("Name", "John")
+	s.SetField("Address", Address{City: "Amsterdam"})
+
+	var flat = s.Flatten(".")
+	if !reflect.DeepEqual(flat, map[string]interface{}{"name": "John", "address.city": "Amsterdam"}) {
+		t.Errorf("Unexpected flattened values %v", flat)
+	}
+
+	var other = s.DeepCopy()
+	if err := other.Unflatten(map[string]interface{}{"address.city": "Berlin", "unknown": 1}, "."); err != nil {
+		t.Fatal(err)
+	}
+	if other.GetField("Address") != (Address{City: "Berlin"}) || other.GetField("Name") != "John" {
+		t.Errorf("Unexpected values %v", other.Interface())
+	}
+	if err := other.Unflatten(map[string]interface{}{"name": 1, "address.city": "Paris"}, "."); err == nil {
+		t.Error("Expected an error setting a number to a string field")
+	}
+	if other.GetField("Address") != (Address{City: "Berlin"}) {
+		t.Errorf("Expected the struct to be left unchanged, got %v", other.Interface())
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	var newSnapshot = func(requests int, latency float64) *structs.Struct {
+		var s = structs.New("json")
+		s.StringField("Host", "host")
+		s.IntField("Requests", "requests")
+		s.FloatField("Latency", "latency")
+		s.AddField("Errors", "errors", reflect.TypeOf(uint8(0)))
+		s.Make()
+		s.SetField("Requests", requests)
+		s.SetField("Latency", latency)
+		s.SetField("Errors", uint8(200))
+		return s
+	}
+	var a, b = newSnapshot(10, 1.5), newSnapshot(4, 0.5)
+	var dst = a.DeepCopy()
+	if err := structs.Add(dst, a, b, "Requests", "Latency"); err != nil {
+		t.Fatal(err)
+	}
+	if dst.GetField("Requests") != 14 || dst.GetField("Latency") != 2.0 {
+		t.Errorf("Unexpected sum %v", dst.Interface())
+	}
+	if err := structs.Sub(dst, a, b); err != nil {
+		t.Fatal(err)
+	}
+	if dst.GetField("Requests") != 6 || dst.GetField("Latency") != 1.0 || dst.GetField("Errors") != uint8(0) {
+		t.Errorf("Unexpected difference %v", dst.Interface())
+	}
+	if err := structs.Mul(dst, a, b, "Host"); err == nil {
+		t.Error("Expected an error multiplying a string field")
+	}
+	if err := structs.Add(dst, a, b); err == nil {
+		t.Error("Expected an error when the sum overflows uint8")
+	}
+	if dst.GetField("Requests") != 6 {
+		t.Errorf("Expected the struct to be left unchanged, got %v", dst.Interface())
+	}
+}
+
+func TestLerp(t *testing.T) {
+	var newParams = func(x int, scale float64, at time.Time) *structs.Struct {
+		var s = structs.New("json")
+		s.StringField("Name", "name")
+		s.IntField("X", "x")
+		s.FloatField("Scale", "scale")
+		s.AddField("At", "at", reflect.TypeOf(time.Time{}))
+		s.AddField("Opacity", "opacity", reflect.TypeOf((*float64)(nil)))
+		s.Make()
+		s.SetField("Name", "frame")
+		s.SetField("X", x)
+		s.SetField("Scale", scale)
+		s.SetField("At", at)
+		return s
+	}
+	var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var a = newParams(0, 1, start)
+	var b = newParams(10, 2, start.Add(time.Hour))
+	var from, to = 0.0, 1.0
+	a.SetField("Opacity", &from)
+	b.SetField("Opacity", &to)
+
+	var s, err = structs.Lerp(a, b, 0.25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.GetField("X") != 3 || s.GetField("Scale") != 1.25 || s.GetField("Name") != "frame" {
+		t.Errorf("Unexpected interpolated values %v", s.Interface())
+	}
+	if at := s.GetField("At").(time.Time); !at.Equal(start.Add(15 * time.Minute)) {
+		t.Errorf("Expected %v, got %v", start.Add(15*time.Minute), at)
+	}
+	if opacity := s.GetField("Opacity").(*float64); *opacity != 0.25 || from != 0 {
+		t.Errorf("Expected opacity 0.25 without modifying a, got %v", *opacity)
+	}
+
+	var other = structs.New("json")
+	other.IntField("X", "x")
+	other.Make()
+	if _, err := structs.Lerp(a, other, 0.5); err == nil {
+		t.Error("Expected an error interpolating structs with different fields")
+	}
+}
+
+func TestGetSetPath(t *testing.T) {
+	var s = structs.New("json")
+	s.AddField("Address", "address", reflect.TypeOf(&Address{}))
+	s.AddField("Items", "items", reflect.TypeOf([]Address{}))
+	s.AddField("Labels", "labels", reflect.TypeOf(map[string]Address{}))
+	s.Make()
+
+	if err := s.SetPath("Address.City", "Amsterdam"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetPath("labels.home.city", "Berlin"); err != nil {
+		t.Fatal(err)
+	}
+	s.SetField("Items", []Address{{City: "Paris"}, {City: "Rome"}})
+	if err := s.SetPath("Items[1].City", "Madrid"); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, expected := range map[string]interface{}{
+		"address.city":     "Amsterdam",
+		"Labels.home.City": "Berlin",
+		"Items.1.city":     "Madrid",
+		"Items[0]":         Address{City: "Paris"},
+	} {
+		var value, err = s.GetPath(path)
+		if err != nil {
+			t.Errorf("%s: %s", path, err)
+		} else if value != expected {
+			t.Errorf("%s: expected %v, got %v", path, expected, value)
+		}
+	}
+	for _, path := range []string{"Items.2", "Address.Street", "Labels.work.City"} {
+		if _, err := s.GetPath(path); err == nil {
+			t.Errorf("%s: expected an error", path)
+		}
+	}
+}
+
+func TestSetPathDeniedLeavesValuesUnchanged(t *testing.T) {
+	var policy, err = structs.ParseRulePolicy(strings.NewReader("deny write *"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s = structs.New("json")
+	s.AddField("Tags", "tags", reflect.TypeOf(map[string]string{}))
+	s.AddField("Items", "items", reflect.TypeOf([]Address{}))
+	s.AddField("Address", "address", reflect.TypeOf(&Address{}))
+	s.Make()
+	var tags = map[string]string{"a": "b"}
+	var items = make([]Address, 1, 2)
+	var address = &Address{City: "Paris"}
+	s.SetField("Tags", tags)
+	s.SetField("Items", items)
+	s.SetField("Address", address)
+	s.SetPolicy(context.Background(), policy)
+
+	for _, path := range []string{"Tags.a", "Items.0.City", "Items.-.City", "Address.City"} {
+		if err := s.SetPath(path, "pwned"); !errors.Is(err, structs.ErrDenied) {
+			t.Errorf("%s: expected %v, got %v", path, structs.ErrDenied, err)
+		}
+	}
+	if err := s.SetPointer("/tags/a", "pwned"); !errors.Is(err, structs.ErrDenied) {
+		t.Errorf("Expected %v, got %v", structs.ErrDenied, err)
+	}
+	if tags["a"] != "b" || items[0].City != "" || items[:2][1].City != "" || address.City != "Paris" {
+		t.Errorf("Expected the shared values to be left unchanged, got %v %v %v", tags, items[:2], address)
+	}
+}
+
+func TestSolve(t *testing.T) {
+	var s = structs.New("json")
+	s.FloatField("Price", "price")
+	s.IntField("Qty", "qty")
+	s.FloatField("Total", "total")
+	s.FloatField("Tax", "tax")
+	s.Make()
+	for _, relation := range []string{"Total = Price * Qty", "Tax = Total * 0.25"} {
+		if err := s.Relation(relation); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Relation("Total Price"); err == nil {
+		t.Error("Expected an error parsing an invalid relation")
+	}
+
+	s.SetField("Price", 2.5)
+	s.SetField("Tax", 5.0)
+	if err := s.Solve(); err != nil {
+		t.Fatal(err)
+	}
+	if s.GetField("Total") != 20.0 || s.GetField("Qty") != 8 {
+		t.Errorf("Unexpected solution %v", s.Interface())
+	}
+
+	s.SetField("Qty", 3)
+	if err := s.Solve(); err == nil {
+		t.Error("Expected an error for a contradiction")
+	}
+
+	var partial = s.DeepCopy()
+	partial.SetField("Qty", 0)
+	partial.SetField("Price", 3.0)
+	partial.SetField("Tax", 0.0)
+	partial.SetField("Total", 10.0)
+	if err := partial.Solve(); err == nil {
+		t.Error("Expected an error when the quantity is not a whole number")
+	}
+	if partial.GetField("Tax") != 0.0 {
+		t.Errorf("Expected the struct to be left unchanged, got %v", partial.Interface())
+	}
+}
+
+func TestJSONPointer(t *testing.T) {
+	var s = structs.New("json")
+	s.AddField("Items", "items", reflect.TypeOf([]Address{}))
+	s.AddField("Meta", "meta", reflect.TypeOf(map[string]string{}))
+	s.Make()
+
+	if err := s.SetPointer("/items/-", map[string]interface{}{"city": "Paris"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetPointer("/items/0/city", "Rome"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetPointer("/meta/a~1b~0c", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := s.GetPointer("/items/0/city"); err != nil || value != "Rome" {
+		t.Errorf("Expected Rome, got %v (%v)", value, err)
+	}
+	if value, err := s.GetPointer("/meta/a~1b~0c"); err != nil || value != "value" {
+		t.Errorf("Expected value, got %v (%v)", value, err)
+	}
+	if _, err := s.GetPointer("items"); err == nil {
+		t.Error("Expected an error for a pointer without a leading slash")
+	}
+	if _, err := s.GetPointer("/items/1"); err == nil {
+		t.Error("Expected an error for an index out of range")
+	}
+}
+
+func TestDiffJSONPatch(t *testing.T) {
+	var newStruct = func() *structs.Struct {
+		var s = structs.New("json")
+		s.StringField("Name", "name")
+		s.AddField("Address", "address", reflect.TypeOf(&Address{}))
+		s.AddField("Meta", "meta", reflect.TypeOf(map[string]string{}))
+		s.Make()
+		return s
+	}
+	var a, b = newStruct(), newStruct()
+	a.SetField("Name", "John")
+	a.SetField("Address", &Address{City: "Amsterdam"})
+	a.SetField("Meta", map[string]string{"a/b": "1", "c": "2"})
+	b.SetField("Name", "John")
+	b.SetField("Address", &Address{City: "Berlin"})
+	b.SetField("Meta", map[string]string{"c": "3", "d": "4"})
+
+	var ops, err = structs.Diff(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data, _ = json.Marshal(ops)
+	var expected = `[{"op":"replace","path":"/address/city","value":"Berlin"},` +
+		`{"op":"remove","path":"/meta/a~1b"},` +
+		`{"op":"replace","path":"/meta/c","value":"3"},` +
+		`{"op":"add","path":"/meta/d","value":"4"}]`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+
+	for _, op := range ops {
+		if op.Op == "remove" {
+			continue
+		}
+		if err := a.SetPointer(op.Path, op.Value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ops, _ := structs.Diff(a, b); len(ops) != 1 || ops[0].Path != "/meta/a~1b" {
+		t.Errorf("Expected only the removal to remain, got %v", ops)
+	}
+}
+
+func TestQuantityField(t *testing.T) {
+	var s = structs.New("json")
+	s.QuantityField("Weight", "weight", "kg")
+	s.CanonicalQuantityField("Temperature", "temperature", "°C")
+	s.Make()
+
+	s.SetField("Weight", "11 lb")
+	s.SetField("Temperature", structs.Quantity{Value: 212, Unit: "°F"})
+	if s.GetField("Weight") != (structs.Quantity{Value: 11, Unit: "lb"}) {
+		t.Errorf("Expected the weight as entered, got %v", s.GetField("Weight"))
+	}
+	if err := s.TrySetField("Weight", structs.Quantity{Value: 1, Unit: "m"}); err == nil {
+		t.Error("Expected an error setting a length to a mass field")
+	}
+	s.SetField("Weight", structs.Quantity{Value: 2})
+	if s.GetField("Weight") != (structs.Quantity{Value: 2, Unit: "kg"}) {
+		t.Errorf("Expected the unit of the field, got %v", s.GetField("Weight"))
+	}
+
+	var data, err = json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected = `{"weight":{"value":2,"unit":"kg"},"temperature":{"value":100,"unit":"°C"}}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+
+	var lb, _ = structs.Quantity{Value: 1, Unit: "kg"}.Convert("lb")
+	if lb.Value < 2.2046 || lb.Value > 2.2047 {
+		t.Errorf("Unexpected conversion %v", lb)
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	var s = structs.New("json")
+	s.StringField("Name", "name")
+	s.IntField("Age", "age")
+	s.AddField("Address", "address", reflect.TypeOf(&Address{}))
+	s.AddField("Meta", "meta", reflect.TypeOf(map[string]string{}))
+	s.AddField("Extra", "extra", reflect.TypeOf((*interface{})(nil)).Elem())
+	s.Make()
+	var meta = map[string]string{"a": "1", "b": "2"}
+	s.SetField("Name", "John")
+	s.SetField("Age", 30)
+	s.SetField("Address", &Address{City: "Amsterdam"})
+	s.SetField("Meta", meta)
+	s.SetField("Extra", map[string]interface{}{"x": 1.0, "y": 2.0})
+
+	var patch = `{"age":null,"address":{"city":"Berlin"},"meta":{"a":null,"c":"3"},"extra":{"y":null,"z":{"n":5}},"unknown":1}`
+	if err := s.ApplyMergePatch([]byte(patch)); err != nil {
+		t.Fatal(err)
+	}
+	var data, _ = json.Marshal(s)
+	var expected = `{"name":"John","age":0,"address":{"city":"Berlin"},"meta":{"b":"2","c":"3"},"extra":{"x":1,"z":{"n":5}}}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+	if len(meta) != 2 || meta["a"] != "1" {
+		t.Errorf("Expected the original map to be left unchanged, got %v", meta)
+	}
+
+	if err := s.ApplyMergePatch([]byte(`{"name":"Jane","age":"old"}`)); err == nil {
+		t.Error("Expected an error for an invalid age")
+	}
+	if s.GetField("Name") != "John" {
+		t.Errorf("Expected the struct to be left unchanged, got %v", s.GetField("Name"))
+	}
+	if err := s.ApplyMergePatch([]byte(`[1]`)); err == nil {
+		t.Error("Expected an error for a patch which is not an object")
+	}
+}
+
+func TestFieldDiff(t *testing.T) {
+	var a = structs.New("json")
+	a.StringField("Name", "name")
+	a.IntField("Age", "age")
+	a.StringField("Email", "email")
+	a.Make()
+	a.SetField("Name", "John")
+	a.SetField("Age", 30)
+	a.SetField("Email", "john@example.com")
+
+	var b = structs.New("json")
+	b.StringField("Name", "name")
+	b.IntField("Age", "age")
+	b.BoolField("Admin", "admin")
+	b.Make()
+	b.SetField("Name", "John")
+	b.SetField("Age", 31)
+	b.SetField("Admin", true)
+
+	var changes = a.Diff(b)
+	var expected = []structs.FieldChange{
+		{Field: "Age", Old: 30, New: 31},
+		{Field: "Email", Old: "john@example.com"},
+		{Field: "Admin", New: true},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected %v, got %v", expected, changes)
+	}
+	if changes := a.Diff(a.DeepCopy()); len(changes) != 0 {
+		t.Errorf("Expected no changes, got %v", changes)
+	}
+}
+
+func TestMergeStrategies(t *testing.T) {
+	var newStruct = func(name string, age int, email string) *structs.Struct {
+		var s = structs.New("json")
+		s.StringField("Name", "name")
+		s.IntField("Age", "age")
+		s.StringField("Email", "email")
+		s.Make()
+		s.SetField("Name", name)
+		s.SetField("Age", age)
+		s.SetField("Email", email)
+		return s
+	}
+	var src = newStruct("Jane", 25, "jane@example.com")
+
+	var dst = newStruct("John", 0, "")
+	if err := structs.Merge(dst, src, structs.MergeFillZero); err != nil {
+		t.Fatal(err)
+	}
+	if dst.GetField("Name") != "John" || dst.GetField("Age") != 25 || dst.GetField("Email") != "jane@example.com" {
+		t.Errorf("Unexpected fill-zero merge %v", dst.Interface())
+	}
+
+	dst = newStruct("John", 30, "")
+	if err := structs.Merge(dst, src, structs.MergeOverwrite); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dst.Interface(), src.Interface()) {
+		t.Errorf("Unexpected overwrite merge %v", dst.Interface())
+	}
+
+	dst = newStruct("John", 30, "")
+	var err = structs.Merge(dst, src, func(field string, ours, theirs interface{}) (interface{}, error) {
+		if field == "Age" {
+			return ours.(int) + theirs.(int), nil
+		}
+		if field == "Email" {
+			return nil, errors.New("Email may not be merged")
+		}
+		return ours, nil
+	})
+	if err == nil {
+		t.Error("Expected an error from the callback")
+	}
+	if dst.GetField("Age") != 30 {
+		t.Errorf("Expected dst to be left unchanged, got %v", dst.Interface())
+	}
+}
+
+func TestMerge3(t *testing.T) {
+	var newStruct = func(name, email, city string) *structs.Struct {
+		var s = structs.New("json")
+		s.StringField("Name", "name")
+		s.StringField("Email", "email")
+		s.AddField("Address", "address", reflect.TypeOf(Address{}))
+		s.Make()
+		s.SetField("Name", name)
+		s.SetField("Email", email)
+		s.SetField("Address", Address{City: city})
+		return s
+	}
+	var base = newStruct("John", "john@example.com", "Amsterdam")
+	var mine = newStruct("Johnny", "john@example.com", "Berlin")
+	var theirs = newStruct("John", "j@example.com", "Paris")
+
+	var merged, conflicts, err = structs.Merge3(base, mine, theirs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.GetField("Name") != "Johnny" || merged.GetField("Email") != "j@example.com" || merged.GetField("Address") != (Address{City: "Berlin"}) {
+		t.Errorf("Unexpected merge %v", merged.Interface())
+	}
+	var expected = []structs.Conflict{{Field: "Address.City", Base: "Amsterdam", Mine: "Berlin", Theirs: "Paris"}}
+	if !reflect.DeepEqual(conflicts, expected) {
+		t.Errorf("Expected %v, got %v", expected, conflicts)
+	}
+
+	var other = structs.New("json")
+	other.StringField("Name", "name")
+	other.Make()
+	if _, _, err := structs.Merge3(base, mine, other); err == nil {
+		t.Error("Expected an error merging structs with different fields")
+	}
+}
+
+func TestDeepCopyRecursive(t *testing.T) {
+	var s = structs.New("json")
+	s.AddField("Address", "address", reflect.TypeOf(&Address{}))
+	s.AddField("Tags", "tags", reflect.TypeOf([]string{}))
+	s.AddField("Meta", "meta", reflect.TypeOf(map[string][]int{}))
+	s.AddField("Empty", "empty", reflect.TypeOf((*Address)(nil)))
+	s.Make()
+	s.SetField("Address", &Address{City: "Amsterdam"})
+	s.SetField("Tags", []string{"a", "b"})
+	s.SetField("Meta", map[string][]int{"x": {1, 2}})
+
+	var shallow = s.DeepCopy()
+	shallow.GetField("Address").(*Address).City = "Berlin"
+	if s.GetField("Address").(*Address).City != "Amsterdam" {
+		t.Error("Expected pointer fields to point to a copy")
+	}
+
+	var deep = s.DeepCopy(true)
+	deep.GetField("Tags").([]string)[0] = "changed"
+	deep.GetField("Meta").(map[string][]int)["x"][0] = 100
+	deep.GetField("Meta").(map[string][]int)["y"] = nil
+	if !reflect.DeepEqual(s.GetField("Tags"), []string{"a", "b"}) || !reflect.DeepEqual(s.GetField("Meta"), map[string][]int{"x": {1, 2}}) {
+		t.Errorf("Expected the original to be left unchanged, got %v", s.Interface())
+	}
+	if deep.GetField("Empty").(*Address) != nil {
+		t.Error("Expected nil pointers to stay nil")
+	}
+}
+
+func TestEqual(t *testing.T) {
+	var newStruct = func(id int, name string, updated time.Time) *structs.Struct {
+		var s = structs.New("json")
+		s.IntField("ID", "id")
+		s.StringField("Name", "name")
+		s.AddField("Address", "address", reflect.TypeOf(&Address{}))
+		s.AddField("Updated", "updated", reflect.TypeOf(time.Time{}))
+		s.Make()
+		s.SetField("ID", id)
+		s.SetField("Name", name)
+		s.SetField("Address", &Address{City: "Amsterdam"})
+		s.SetField("Updated", updated)
+		return s
+	}
+	var a = newStruct(1, "John", time.Now())
+	var b = newStruct(2, "John", time.Now().Add(time.Hour))
+	if a.Equal(b) {
+		t.Error("Expected structs with different IDs not to be equal")
+	}
+	if !a.Equal(b, "ID", "Updated") {
+		t.Error("Expected structs to be equal when ignoring ID and Updated")
+	}
+	b.SetField("Address", &Address{City: "Berlin"})
+	if a.Equal(b, "ID", "Updated") {
+		t.Error("Expected nested values to be compared")
+	}
+
+	var other = structs.New("json")
+	other.StringField("Name", "name")
+	other.Make()
+	other.SetField("Name", "John")
+	if a.Equal(other, "ID", "Updated") || other.Equal(a, "ID", "Updated") {
+		t.Error("Expected structs with different fields not to be equal")
+	}
+	if !other.Equal(a, "ID", "Updated", "Address") {
+		t.Error("Expected structs to be equal when ignoring the fields which differ")
+	}
+}
+
+func TestUpsertSQL(t *testing.T) {
+	var s = structs.New("json")
+	s.AddFieldWithTags("ID", reflect.TypeOf(0), map[string]string{"json": "id", "db": "id"})
+	s.StringField("Name", "name")
+	s.AddField("Tags", "tags", reflect.TypeOf([]string{}))
+	s.AddField("Nickname", "nickname", reflect.TypeOf((*string)(nil)))
+	s.Make()
+
+	var query, args, err = s.UpsertSQL("users", []string{"ID"}, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected = `INSERT INTO "users" ("id", "name", "tags", "nickname") VALUES ($1, $2, $3, $4) ON CONFLICT ("id") DO NOTHING;`
+	if query != expected {
+		t.Errorf("Expected %s, got %s", expected, query)
+	}
+
+	s.SetField("ID", 1)
+	s.SetField("Name", "John")
+	s.SetField("Tags", []string{"a"})
+	query, args, err = s.UpsertSQL("users", []string{"id"}, "mysql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = "INSERT INTO `users` (`id`, `name`, `tags`, `nickname`) VALUES (?, ?, ?, ?) " +
+		"ON DUPLICATE KEY UPDATE `name` = VALUES(`name`), `tags` = VALUES(`tags`);"
+	if query != expected {
+		t.Errorf("Expected %s, got %s", expected, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{int64(1), "John", `["a"]`, nil}) {
+		t.Errorf("Unexpected arguments %v", args)
+	}
+
+	query, _, err = s.UpsertSQL("users", []string{"ID"}, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(query, `ON CONFLICT ("id") DO UPDATE SET "name" = excluded."name", "tags" = excluded."tags";`) {
+		t.Errorf("Unexpected sqlite statement %s", query)
+	}
+
+	var policy, _ = structs.ParseRulePolicy(strings.NewReader("deny read Name\nallow * *"))
+	s.SetPolicy(context.Background(), policy)
+	if _, _, err = s.UpsertSQL("users", []string{"ID"}, "postgres"); !errors.Is(err, structs.ErrDenied) {
+		t.Errorf("Expected %v, got %v", structs.ErrDenied, err)
+	}
+	if _, _, err := s.UpsertSQL("users", []string{"missing"}, "sqlite"); err == nil {
+		t.Error("Expected an error for an unknown conflict key")
+	}
+}
+
+func TestHash(t *testing.T) {
+	var a = structs.New("json")
+	a.StringField("Name", "name")
+	a.AddField("Meta", "meta", reflect.TypeOf(map[string]int{}))
+	a.AddField("Created", "created", reflect.TypeOf(time.Time{}))
+	a.Make()
+
+	var b = structs.New("json")
+	b.AddField("Created", "created", reflect.TypeOf(time.Time{}))
+	b.AddField("Meta", "meta", reflect.TypeOf(map[string]int{}))
+	b.StringField("Name", "name")
+	b.Make()
+
+	var created = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, s := range []*structs.Struct{a, b} {
+		s.SetField("Name", "John")
+		s.SetField("Meta", map[string]int{"a": 1, "b": 2, "c": 3})
+		s.SetField("Created", created)
+	}
+	b.SetField("Created", created.In(time.FixedZone("CET", 3600)))
+
+	var hashA, err = a.Hash(sha256.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashB, err := b.Hash(sha256.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(hashA, hashB) {
+		t.Error("Expected equal values to hash the same regardless of field order")
+	}
+	b.SetField("Name", "Jane")
+	if hashB, _ = b.Hash(sha256.New()); bytes.Equal(hashA, hashB) {
+		t.Error("Expected different values to hash differently")
+	}
+
+	var blobs = structs.New("json")
+	blobs.BlobField("Image", "image")
+	blobs.Make()
+	blobs.SetField("Image", structs.BlobRef("a.png"))
+	hashA, err = blobs.Hash(sha256.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	blobs.SetField("Image", structs.BlobRef("b.png"))
+	if hashB, _ = blobs.Hash(sha256.New()); bytes.Equal(hashA, hashB) {
+		t.Error("Expected blobs with different references to hash differently")
+	}
+
+	type opaque struct{ n int }
+	var unexported = structs.New("json")
+	unexported.AddField("Opaque", "opaque", reflect.TypeOf(opaque{}))
+	unexported.Make()
+	if _, err := unexported.Hash(sha256.New()); err == nil {
+		t.Error("Expected an error hashing a struct with unexported fields")
+	}
+
+	var invalid = structs.New("json")
+	invalid.AddField("Callback", "callback", reflect.TypeOf(func() {}))
+	invalid.Make()
+	invalid.SetField("Callback", func() {})
+	if _, err := invalid.Hash(sha256.New()); err == nil {
+		t.Error("Expected an error hashing a function")
+	}
+}
+
+func TestSchemaHash(t *testing.T) {
+	var newSchema = func() *structs.Struct {
+		var s = structs.New("json")
+		s.StringField("Name", "name")
+		s.AddField("Address", "address", reflect.TypeOf(&Address{}))
+		return s
+	}
+	var a, b = newSchema(), newSchema()
+	var hash = a.SchemaHash()
+	if len(hash) != 64 || hash != b.SchemaHash() {
+		t.Errorf("Expected equal schemas to have the same fingerprint, got %s and %s", hash, b.SchemaHash())
+	}
+	b.Make()
+	b.SetField("Name", "John")
+	if b.SchemaHash() != hash {
+		t.Error("Expected values not to change the fingerprint")
+	}
+	b.SetTag("Name", "db", "name")
+	if b.SchemaHash() == hash {
+		t.Error("Expected a tag change to change the fingerprint")
+	}
+	a.MoveField("Address", 0)
+	if a.SchemaHash() == hash {
+		t.Error("Expected the field order to change the fingerprint")
+	}
+}
+
+func TestZero(t *testing.T) {
+	var s = structs.New("json")
+	s.StringField("Name", "name")
+	s.IntField("Age", "age")
+	s.AddField("Tags", "tags", reflect.TypeOf([]string{}))
+	s.Make()
+	var ptr = s.PtrTo()
+	s.SetField("Name", "John")
+	s.SetField("Age", 30)
+	s.SetField("Tags", []string{"a"})
+
+	s.ZeroField("Age")
+	if s.GetField("Age") != 0 || s.GetField("Name") != "John" {
+		t.Errorf("Expected only Age to be zeroed, got %v", s.Interface())
+	}
+	if s.Stamp("Age").IsZero() {
+		t.Error("Expected ZeroField to record the write")
+	}
+
+	s.Zero()
+	if !reflect.ValueOf(ptr).Elem().IsZero() {
+		t.Errorf("Expected the value behind PtrTo to be zeroed, got %v", ptr)
+	}
+	if !s.Stamp("Name").IsZero() {
+		t.Error("Expected Zero to clear the write times")
+	}
+}
+
+func TestIsZero(t *testing.T) {
+	var s = structs.New("json")
+	s.StringField("Name", "name")
+	s.AddField("Tags", "tags", reflect.TypeOf([]string{}))
+	s.Make()
+	if !s.IsZero() || !s.FieldIsZero("Name") {
+		t.Error("Expected a new struct to be zero")
+	}
+	s.SetField("Tags", []string{})
+	if s.IsZero() || s.FieldIsZero("Tags") || !s.FieldIsZero("Name") {
+		t.Error("Expected an empty non-nil slice not to be zero")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic for a field which does not exist")
+		}
+	}()
+	s.FieldIsZero("Missing")
+}
+
+func TestFieldHandle(t *testing.T) {
+	var s = structs.New("json")
+	s.StringField("Name", "name")
+	s.IntField("Age", "age")
+	s.Make()
+
+	var age, err = structs.Field[int](s, "Age")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := age.Set(30); err != nil {
+		t.Fatal(err)
+	}
+	if age.Get() != 30 || s.GetField("Age") != 30 {
+		t.Errorf("Expected 30, got %v", age.Get())
+	}
+	if _, err := structs.Field[string](s, "Age"); err == nil {
+		t.Error("Expected an error for a handle of the wrong type")
+	}
+	if _, err := structs.Field[int](s, "Missing"); !errors.Is(err, structs.ErrFieldNotFound) {
+		t.Errorf("Expected ErrFieldNotFound, got %v", err)
+	}
+
+	s.Make()
+	if age.Get() != 0 {
+		t.Error("Expected the handle to follow the re-made value")
+	}
+	s.BoolField("Admin", "admin")
+	s.Make()
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic for a stale handle")
+		}
+	}()
+	age.Get()
+}
+
+func TestApplyDefaults(t *testing.T) {
+	var s = structs.New("json")
+	s.AddFieldWithTags("Port", reflect.TypeOf(0), map[string]string{"json": "port", "structs": "required,default=8080"})
+	s.AddFieldWithTags("Hosts", reflect.TypeOf([]string{}), map[string]string{"json": "hosts", "structs": `default='["a","b"]'`})
+	s.AddFieldWithTags("Timeout", reflect.TypeOf(time.Duration(0)), map[string]string{"json": "timeout", "structs": "default=5s"})
+	s.StringField("Name", "name")
+	s.AddFieldWithTags("Retries", reflect.TypeOf(0), map[string]string{"json": "retries", "structs": "default=5,required"})
+	s.AddFieldWithTags("Greeting", reflect.TypeOf(""), map[string]string{"json": "greeting", "structs": "default='it''s, hi',required"})
+	s.Make()
+	s.SetField("Timeout", time.Second)
+
+	if !structs.IsRequired(s.Field(0)) || structs.IsRequired(s.Field(1)) || !structs.IsRequired(s.Field(4)) || !structs.IsRequired(s.Field(5)) {
+		t.Error("Expected only Port, Retries and Greeting to be required")
+	}
+	if err := s.ApplyDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if s.GetField("Port") != 8080 || !reflect.DeepEqual(s.GetField("Hosts"), []string{"a", "b"}) || s.GetField("Timeout") != time.Second ||
+		s.GetField("Retries") != 5 || s.GetField("Greeting") != "it's, hi" {
+		t.Errorf("Unexpected defaults %v", s.Interface())
+	}
+	if s.Stamp("Port").IsZero() || !s.Stamp("Name").IsZero() {
+		t.Error("Expected the defaults to be set through SetField")
+	}
+
+	var invalid = structs.New("json")
+	invalid.AddFieldWithTags("Port", reflect.TypeOf(0), map[string]string{"structs": "default=http"})
+	invalid.Make()
+	if err := invalid.ApplyDefaults(); err == nil {
+		t.Error("Expected an error for an invalid default value")
+	}
+
+	for _, tag := range []string{"default='[1,2]", "default='1'2,required"} {
+		var malformed = structs.New("json")
+		malformed.AddFieldWithTags("Port", reflect.TypeOf(0), map[string]string{"structs": tag})
+		malformed.Make()
+		if err := malformed.ApplyDefaults(); err == nil {
+			t.Errorf("Expected an error for the malformed default in %q", tag)
+		}
+		if _, ok := structs.DefaultValue(malformed.Field(0)); ok {
+			t.Errorf("Expected no default value for %q", tag)
+		}
+	}
+}
+
+func TestStringerAndLess(t *testing.T) {
+	var people []*structs.Struct
+	for _, p := range []struct {
+		name string
+		age  int
+	}{{"Nigel", 23}, {"Alice", 31}, {"Bob", 23}} {
+		var s = structs.New("json")
+		s.StringField("Name", "name")
+		s.IntField("Age", "age")
+		s.AddFieldWithTags("Password", reflect.TypeOf(""), map[string]string{"json": "password", "structs": "redact"})
+		s.Make()
+		s.SetField("Name", p.name)
+		s.SetField("Age", p.age)
+		s.SetField("Password", "secret")
+		people = append(people, s)
+	}
+
+	if str := fmt.Sprint(people[0]); str != "name=Nigel age=23 password=[REDACTED]" {
+		t.Errorf("Unexpected default string %q", str)
+	}
+	for _, s := range people {
+		s.SetStringer(func(s *structs.Struct) string {
+			return fmt.Sprintf("%s (%d)", s.GetField("Name"), s.GetField("Age"))
+		})
+		s.SetLess(func(a, b *structs.Struct) bool {
+			return a.GetField("Age").(int) < b.GetField("Age").(int)
+		})
+	}
+	structs.Sort(people)
+	if str := fmt.Sprint(people); str != "[Nigel (23) Bob (23) Alice (31)]" {
+		t.Errorf("Unexpected sort order %s", str)
+	}
+	if copied := people[0].DeepCopy(); copied.String() != "Nigel (23)" || !copied.Less(people[2]) {
+		t.Error("Expected the copy to keep the stringer and less function")
+	}
+}
+
+func TestBlob(t *testing.T) {
+	var s = structs.New("json")
+	s.BlobField("B", "b")
+	s.Make()
+	if err := json.Unmarshal([]byte(`{"b":{"ref":"file:///etc/passwd"}}`), s); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetField("B").(*structs.Blob).Bytes(context.Background()); err == nil {
+		t.Error("Expected no file loader to be registered by default")
+	}
+
+	var dir = t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "small.txt"), []byte("hello"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "large.txt"), bytes.Repeat([]byte("x"), 100), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	structs.RegisterBlobLoader("testfile", structs.FileBlobLoader(dir, 10))
+	var blob = structs.BlobRef("testfile:///../small.txt")
+	if data, err := blob.Bytes(context.Background()); err != nil || string(data) != "hello" || !blob.Loaded() {
+		t.Errorf("Expected the blob to be loaded from the root, got %q %v", data, err)
+	}
+	if _, err := structs.BlobRef("testfile:///large.txt").Bytes(context.Background()); err == nil {
+		t.Error("Expected an error for a file exceeding the maximum size")
+	}
+
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte("x"), 100))
+	}))
+	defer server.Close()
+	var ref, _ = url.Parse(server.URL)
+	if _, err := structs.HTTPBlobLoader(server.Client(), 10)(context.Background(), ref); err == nil {
+		t.Error("Expected an error for a response exceeding the maximum size")
+	}
+	if data, err := structs.HTTPBlobLoader(server.Client(), 100)(context.Background(), ref); err != nil || len(data) != 100 {
+		t.Errorf("Expected the response to be loaded, got %d bytes %v", len(data), err)
+	}
+
+	var inline = structs.NewBlob([]byte("data"))
+	data, err := json.Marshal(inline)
+	if err != nil || string(data) != `{"data":"ZGF0YQ=="}` {
+		t.Errorf("Unexpected inline blob JSON %s %v", data, err)
+	}
+}
+
+func TestDeepCopyBlob(t *testing.T) {
+	var started, release = make(chan struct{}), make(chan struct{})
+	structs.RegisterBlobLoader("slowtest", func(ctx context.Context, ref *url.URL) ([]byte, error) {
+		close(started)
+		<-release
+		return []byte("loaded"), nil
+	})
+
+	var s = structs.New("json")
+	s.BlobField("Inline", "inline")
+	s.BlobField("Ref", "ref")
+	s.Make()
+	s.SetField("Inline", structs.NewBlob([]byte("data")))
+	s.SetField("Ref", structs.BlobRef("slowtest:///file"))
+
+	for _, recursive := range []bool{false, true} {
+		var copied = s.DeepCopy(recursive)
+		var original, _ = s.GetField("Inline").(*structs.Blob).Bytes(context.Background())
+		original[0] = 'D'
+		if data, _ := copied.GetField("Inline").(*structs.Blob).Bytes(context.Background()); string(data) != "data" {
+			t.Errorf("Expected the copy not to share its data, got %q", data)
+		}
+		original[0] = 'd'
+	}
+
+	// Copy the blob while it is locked by a load, the copy must not start out locked.
+	go s.GetField("Ref").(*structs.Blob).Bytes(context.Background())
+	<-started
+	var copied = make(chan *structs.Struct)
+	go func() { copied <- s.DeepCopy(true) }()
+	close(release)
+	var done = make(chan bool)
+	go func() { done <- (<-copied).GetField("Ref").(*structs.Blob).Loaded() }()
+	select {
+	case loaded := <-done:
+		if !loaded {
+			t.Error("Expected the copy to hold the loaded data")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the copied blob not to be locked")
+	}
+}
+
+func TestMarshalJSONLocalized(t *testing.T) {
+	var policy, err = structs.ParseRulePolicy(strings.NewReader("deny read Secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s = structs.New("json")
+	s.I18nStringField("Title", "title")
+	s.CanonicalQuantityField("Weight", "weight", "kg")
+	s.StringField("Secret", "secret")
+	s.Make()
+	s.SetField("Title", structs.I18nString{"en": "Hello", "nl": "Hallo"})
+	s.SetField("Weight", structs.Quantity{Value: 500, Unit: "g"})
+	s.SetField("Secret", "hunter2")
+	s.SetPolicy(context.Background(), policy)
+
+	data, err := s.MarshalJSONLocalized("nl-BE", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"title":"Hallo","weight":{"value":0.5,"unit":"kg"}}` {
+		t.Errorf("Unexpected localized JSON %s", data)
+	}
+}
+
+func TestPolicyEncodersAndDecoders(t *testing.T) {
+	var policy, err = structs.ParseRulePolicy(strings.NewReader("deny read Secret\ndeny write Locked"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s = structs.New("json")
+	s.StringField("Name", "name")
+	s.StringField("Secret", "secret")
+	s.AddFieldWithTags("Locked", reflect.TypeOf(0), map[string]string{"json": "locked", "structs": "default=5", "retain": "1h"})
+	s.Make()
+	s.SetField("Secret", "hunter2")
+	s.SetField("Locked", 1)
+	s.SetPolicy(context.Background(), policy)
+
+	if _, ok := s.ToKV("")["secret"]; ok {
+		t.Error("Expected ToKV to omit the secret")
+	}
+	if _, ok := s.Flatten(".")["secret"]; ok {
+		t.Error("Expected Flatten to omit the secret")
+	}
+	if line, _ := s.MarshalLogfmt(); strings.Contains(string(line), "hunter2") {
+		t.Errorf("Expected logfmt to omit the secret, got %s", line)
+	}
+	if strings.Contains(s.String(), "hunter2") {
+		t.Errorf("Expected String to omit the secret, got %s", s.String())
+	}
+	if data, _ := structs.MarshalCompact(s); bytes.Contains(data, []byte("hunter2")) {
+		t.Error("Expected the compact encoding to omit the secret")
+	}
+	if data, _ := structs.MarshalJSONAPI("user", s); bytes.Contains(data, []byte("hunter2")) {
+		t.Errorf("Expected JSON:API to omit the secret, got %s", data)
+	}
+
+	var decoders = map[string]func() error{
+		"UnmarshalJSON":   func() error { return s.UnmarshalJSON([]byte(`{"name":"Ann","locked":2}`)) },
+		"FromKV":          func() error { return s.FromKV("", map[string]string{"name": "Ann", "locked": "2"}) },
+		"LoadEnv":         func() error { return s.LoadEnv(strings.NewReader("NAME=Ann\nLOCKED=2\n"), "") },
+		"UnmarshalLogfmt": func() error { return s.UnmarshalLogfmt([]byte("name=Ann locked=2")) },
+		"Expire":          func() error { _, err := s.Expire(time.Now().Add(2 * time.Hour)); return err },
+	}
+	for name, decode := range decoders {
+		if err := decode(); !errors.Is(err, structs.ErrDenied) {
+			t.Errorf("%s: expected %v, got %v", name, structs.ErrDenied, err)
+		}
+		if s.GetField("Name") != "" || s.GetField("Locked") != 1 {
+			t.Errorf("%s: expected the struct to be left unchanged", name)
+		}
+	}
+	if err := s.UnmarshalJSON([]byte(`{"name":"Ann","locked":1}`)); err != nil || s.GetField("Name") != "Ann" {
+		t.Errorf("Expected unchanged denied fields to be accepted, got %v", err)
+	}
+
+	s.ZeroField("Name")
+	s.SetPolicy(context.Background(), nil)
+	s.ZeroField("Locked")
+	s.SetPolicy(context.Background(), policy)
+	if err := s.ApplyDefaults(); !errors.Is(err, structs.ErrDenied) || s.GetField("Locked") != 0 {
+		t.Errorf("ApplyDefaults: expected %v, got %v", structs.ErrDenied, err)
+	}
+}
+
+func TestLogfmt(t *testing.T) {
+	var s = structs.New("json")
+	s.StringField("Name", "name")
+	s.IntField("Age", "age")
+	s.BoolField("Admin", "admin")
+	s.AddFieldWithTags("Password", reflect.TypeOf(""), map[string]string{"json": "password", "structs": "redact"})
+	s.AddFieldWithTags("Creds", reflect.TypeOf(struct {
+		User  string `json:"user"`
+		Token string `json:"token"`
+	}{}), map[string]string{"json": "creds", "structs": "redact"})
+	s.Make()
+	s.SetField("Name", `Nigel "the" dev`)
+	s.SetField("Age", 23)
+	s.SetField("Admin", true)
+	s.SetField("Password", "secret")
+	s.FieldByName("Creds").FieldByName("Token").SetString("hunter2")
+
+	var line, err = s.MarshalLogfmt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected = `name="Nigel \"the\" dev" age=23 admin=true password=[REDACTED] creds.user=[REDACTED] creds.token=[REDACTED]`
+	if string(line) != expected {
+		t.Errorf("Expected %s, got %s", expected, line)
+	}
+	if s.String() != expected {
+		t.Errorf("Expected String to match the logfmt line, got %s", s.String())
+	}
+
+	var decoded = s.DeepCopy()
+	decoded.Zero()
+	if err := decoded.UnmarshalLogfmt([]byte(`name="Nigel \"the\" dev" age=23 admin password=x creds.token=x`)); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.GetField("Name") != `Nigel "the" dev` || decoded.GetField("Age") != 23 || decoded.GetField("Admin") != true {
+		t.Errorf("Unexpected decoded values %v", decoded.Interface())
+	}
+	if decoded.GetField("Password") != "" || decoded.FieldByName("Creds").FieldByName("Token").String() != "" {
+		t.Error("Expected redacted fields not to be set")
+	}
+	if err := decoded.UnmarshalLogfmt([]byte(`age=old`)); err == nil {
+		t.Error("Expected an error for an invalid value")
+	}
+}
+
+func TestFlags(t *testing.T) {
+	type acl struct {
+		Perms structs.Flags `json:"perms" flags:"read,write"`
+	}
+	var s = structs.New("json")
+	s.FlagsField("Roles", "roles", []string{"admin", "editor", "viewer"}, true)
+	s.FlagsField("Mask", "mask", []string{"read", "write"}, false)
+	s.AddField("ACL", "acl", reflect.TypeOf(acl{}))
+	s.Make()
+	if !s.IsZero() || !s.FieldIsZero("Roles") {
+		t.Error("Expected new struct with flags fields to be zero")
+	}
+
+	if err := s.SetFlag("Roles", "editor", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetFlag("Mask", "write", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetFlag("Roles", "owner", true); err == nil {
+		t.Error("Expected error for unknown flag")
+	}
+	if ok, err := s.HasFlag("Roles", "editor"); err != nil || !ok {
+		t.Errorf("Expected editor to be set, got %v %v", ok, err)
+	}
+	if ok, err := s.HasFlag("Roles", "admin"); err != nil || ok {
+		t.Errorf("Expected admin not to be set, got %v %v", ok, err)
+	}
+	if names, err := s.FlagNames("Roles"); err != nil || !reflect.DeepEqual(names, []string{"editor"}) {
+		t.Errorf("Unexpected flag names %v %v", names, err)
+	}
+	s.SetField("ACL", acl{Perms: 1})
+
+	var data, err = s.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"roles":["editor"],"mask":2,"acl":{"perms":1}}` {
+		t.Errorf("Unexpected JSON %s", data)
+	}
+	var decoded = s.DeepCopy()
+	decoded.Zero()
+	if err = decoded.UnmarshalJSON(data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded.Interface(), s.Interface()) {
+		t.Errorf("Expected %v, got %v", s.Interface(), decoded.Interface())
+	}
+	if err = decoded.UnmarshalJSON([]byte(`{"roles":["owner"]}`)); err == nil {
+		t.Error("Expected error for unknown flag name")
+	}
+
+	var kv = s.ToKV("")
+	if kv["roles"] != "2" || kv["mask"] != "2" || kv["acl/perms"] != "1" {
+		t.Errorf("Unexpected key/value pairs %v", kv)
+	}
+
+	compact, err := structs.MarshalCompact(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded.Zero()
+	if err = structs.UnmarshalCompact(decoded, compact); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded.Interface(), s.Interface()) {
+		t.Errorf("Expected %v, got %v", s.Interface(), decoded.Interface())
+	}
+}
+
+func TestContactEmptyValues(t *testing.T) {
+	var s = structs.New("json")
+	s.EmailField("Email", "email")
+	s.PhoneField("Phone", "phone")
+	s.Make()
+
+	s.SetField("Email", " Ann@Example.com ")
+	s.SetField("Phone", "+31 6 1234 5678")
+	if s.GetField("Email") != structs.Email("ann@example.com") || s.GetField("Phone") != structs.Phone("+31612345678") {
+		t.Errorf("Unexpected normalized values %v", s.Interface())
+	}
+	s.SetField("Email", structs.Email(""))
+	s.SetField("Phone", structs.Phone(""))
+	if s.GetField("Email") != structs.Email("") || s.GetField("Phone") != structs.Phone("") {
+		t.Errorf("Expected empty values to be accepted, got %v", s.Interface())
+	}
+	if err := s.UnmarshalJSON([]byte(`{"email":"","phone":""}`)); err != nil {
+		t.Errorf("Expected empty values to decode, got %v", err)
+	}
+	if err := s.UnmarshalJSON([]byte(`{"email":"not an email"}`)); err == nil {
+		t.Error("Expected error for invalid email address")
+	}
+	if _, err := structs.ParseEmail(""); err == nil {
+		t.Error("Expected ParseEmail to reject an empty address")
+	}
+}
+
+func TestMoneyCurrencyAndAllocate(t *testing.T) {
+	var s = structs.New("json")
+	s.MoneyField("Price", "price", "EUR")
+	s.Make()
+
+	if err := s.TrySetField("Price", structs.Money{Amount: 100, Currency: "USD"}); err == nil {
+		t.Error("Expected error setting money in another currency")
+	}
+	if err := s.UnmarshalJSON([]byte(`{"price":"1.00 USD"}`)); err == nil {
+		t.Error("Expected error decoding money in another currency")
+	}
+	s.SetField("Price", structs.Money{Amount: 250})
+	if s.GetField("Price") != (structs.Money{Amount: 250, Currency: "EUR"}) {
+		t.Errorf("Expected money without currency to get the field's currency, got %v", s.GetField("Price"))
+	}
+	if err := s.UnmarshalJSON([]byte(`{"price":"12.34 eur"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if s.GetField("Price") != (structs.Money{Amount: 1234, Currency: "EUR"}) {
+		t.Errorf("Unexpected price %v", s.GetField("Price"))
+	}
+
+	// amount * ratio overflows an int64 on 64-bit platforms.
+	var ratio = int(^uint(0) >> 2)
+	var shares, err = structs.Money{Amount: math.MaxInt64, Currency: "EUR"}.Allocate(ratio, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sum int64
+	for _, share := range shares {
+		if share.Amount < 0 {
+			t.Fatalf("Unexpected negative share %v", share)
+		}
+		sum += share.Amount
+	}
+	if sum != math.MaxInt64 || shares[0].Amount < shares[1].Amount {
+		t.Errorf("Unexpected shares %v", shares)
+	}
+	shares, err = structs.Money{Amount: -5, Currency: "EUR"}.Allocate(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shares[0].Amount != -3 || shares[1].Amount != -2 {
+		t.Errorf("Unexpected shares %v", shares)
+	}
+}
+
+func TestFixedWidth(t *testing.T) {
+	var s = structs.New("json")
+	s.AddFieldWithTags("Name", reflect.TypeOf(""), map[string]string{"json": "name", "fixed": "0:8"})
+	s.AddFieldWithTags("Amount", reflect.TypeOf(0), map[string]string{"json": "amount", "fixed": "8:14,pad=0"})
+	s.Make()
+	s.SetField("Name", "Ann")
+	s.SetField("Amount", 42)
+
+	var codec, err = structs.NewFixedWidthCodec(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err := codec.EncodeRecord(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record != "Ann     000042" {
+		t.Errorf("Unexpected record %q", record)
+	}
+	var decoded = s.DeepCopy()
+	decoded.Zero()
+	if err = codec.DecodeRecord(record, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded.Interface(), s.Interface()) {
+		t.Errorf("Expected %v, got %v", s.Interface(), decoded.Interface())
+	}
+
+	var tests = map[string][2]string{
+		"negative start": {"-1:4", "4:8"},
+		"overlap":        {"0:5", "4:8"},
+		"contained":      {"0:8", "2:4"},
+	}
+	for name, tags := range tests {
+		var invalid = structs.New("json")
+		invalid.AddFieldWithTags("A", reflect.TypeOf(""), map[string]string{"fixed": tags[0]})
+		invalid.AddFieldWithTags("B", reflect.TypeOf(""), map[string]string{"fixed": tags[1]})
+		if _, err = structs.NewFixedWidthCodec(invalid); err == nil {
+			t.Errorf("%s: Expected error for fixed tags %v", name, tags)
+		}
+	}
+}
+
+func TestFixedWidthPadding(t *testing.T) {
+	var leftZero = structs.New("json")
+	leftZero.AddFieldWithTags("Amount", reflect.TypeOf(0), map[string]string{"fixed": "0:5,left,pad=0"})
+	if _, err := structs.NewFixedWidthCodec(leftZero); err == nil {
+		t.Error("Expected error for zero padding on a left aligned number")
+	}
+
+	var s = structs.New("json")
+	s.AddFieldWithTags("Amount", reflect.TypeOf(0), map[string]string{"fixed": "0:5,pad=0"})
+	s.AddFieldWithTags("Code", reflect.TypeOf(""), map[string]string{"fixed": "5:10,pad=0"})
+	s.AddFieldWithTags("Note", reflect.TypeOf(""), map[string]string{"fixed": "10:16,pad=*"})
+	s.Make()
+	var codec, err = structs.NewFixedWidthCodec(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, amount := range []int{0, 120, -5} {
+		s.SetField("Amount", amount)
+		s.SetField("Code", "1")
+		s.SetField("Note", " a b")
+		var record, err = codec.EncodeRecord(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded = s.DeepCopy()
+		decoded.Zero()
+		if err = codec.DecodeRecord(record, decoded); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(decoded.Interface(), s.Interface()) {
+			t.Errorf("Record %q: expected %v, got %v", record, s.Interface(), decoded.Interface())
+		}
+	}
+
+	// "100" would be decoded as "1", as the zeros cannot be told apart from the padding.
+	s.SetField("Code", "100")
+	if _, err = codec.EncodeRecord(s); err == nil {
+		t.Error("Expected error for a value ending with the padding character")
+	}
+}
+
+func TestSlug(t *testing.T) {
+	var tests = []struct {
+		opts     structs.SlugOptions
+		source   string
+		expected string
+	}{
+		{structs.SlugOptions{}, "Héllo, World!", "hello-world"},
+		{structs.SlugOptions{Separator: "_", KeepCase: true}, "Crème Brûlée", "Creme_Brulee"},
+		{structs.SlugOptions{MaxLength: 7}, "Hello World", "hello-w"},
+		{structs.SlugOptions{MaxLength: 6}, "Hello World", "hello"},
+		// "привет" is 12 bytes, the cut at 5 bytes falls inside the third letter.
+		{structs.SlugOptions{MaxLength: 5}, "привет мир", "пр"},
+		{structs.SlugOptions{Separator: "--", MaxLength: 7}, "ab cd ef", "ab--cd"},
+	}
+	for _, test := range tests {
+		var slug = structs.Slug(test.opts)(test.source)
+		if slug != test.expected || !utf8.ValidString(slug) {
+			t.Errorf("Slug(%q) with %+v: expected %q, got %q", test.source, test.opts, test.expected, slug)
+		}
+	}
+
+	var s = structs.New("json")
+	s.IntField("Count", "count")
+	s.DerivedField("Slug", "slug", "Count", structs.Slug(structs.SlugOptions{}))
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected Make to panic for a derived field with a non-string source")
+		}
+	}()
+	s.Make()
+}
+
+func TestGeoNormalize(t *testing.T) {
+	var s = structs.New("json")
+	s.GeoPointField("Location", "location")
+	s.BoundingBoxField("Area", "area")
+	s.Make()
+
+	var amsterdam = structs.GeoPoint{Lat: 52.37, Lon: 4.89}
+	if err := s.TrySetField("Location", amsterdam); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.TrySetField("Location", structs.GeoPoint{Lat: 91, Lon: 0}); err == nil {
+		t.Error("Expected error for latitude out of range")
+	}
+	if err := s.TrySetField("Location", structs.GeoPoint{Lat: 0, Lon: math.NaN()}); err == nil {
+		t.Error("Expected error for invalid longitude")
+	}
+	if s.GetField("Location") != amsterdam {
+		t.Errorf("Expected previous value to be kept, got %v", s.GetField("Location"))
+	}
+
+	var box = structs.BoundingBox{SouthWest: structs.GeoPoint{Lat: 50, Lon: 3}, NorthEast: structs.GeoPoint{Lat: 54, Lon: 7}}
+	if err := s.TrySetField("Area", box); err != nil {
+		t.Fatal(err)
+	}
+	var inverted = structs.BoundingBox{SouthWest: box.NorthEast, NorthEast: box.SouthWest}
+	if err := s.TrySetField("Area", inverted); err == nil {
+		t.Error("Expected error for a box with its south edge north of its north edge")
+	}
+	if err := s.SetFromMap(map[string]interface{}{"Area": structs.BoundingBox{SouthWest: structs.GeoPoint{Lat: -100}}}); err == nil {
+		t.Error("Expected error setting an invalid box from a map")
+	}
+	if err := s.ApplyMergePatch([]byte(`{"location":{"type":"Point","coordinates":[4.89,52.37]},"area":[7,54,3,50]}`)); err == nil {
+		t.Error("Expected error applying a patch with an invalid box")
+	}
+	if s.GetField("Area") != box {
+		t.Errorf("Expected previous value to be kept, got %v", s.GetField("Area"))
+	}
+}
+
+func TestJSONAPI(t *testing.T) {
+	var s = structs.New("json")
+	s.AddField("ID", "id", reflect.TypeOf(int64(0)))
+	s.StringField("Title", "title")
+	s.IntField("Pages", "pages")
+	s.Make()
+	s.SetField("ID", int64(7))
+	s.SetField("Title", "Dune")
+	s.SetField("Pages", 412)
+
+	var data, err = structs.MarshalJSONAPI("books", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"data":{"type":"books","id":"7","attributes":{"title":"Dune","pages":412}}}` {
+		t.Errorf("Unexpected document %s", data)
+	}
+	var decoded = s.DeepCopy()
+	decoded.Zero()
+	if err = structs.UnmarshalJSONAPI(data, "books", decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded.Interface(), s.Interface()) {
+		t.Errorf("Expected %v, got %v", s.Interface(), decoded.Interface())
+	}
+	if err = structs.UnmarshalJSONAPI(data, "authors", decoded); err == nil {
+		t.Error("Expected error for a different resource type")
+	}
+
+	// A resource without an id, I.E. one which is being created, keeps the current id.
+	var created = s.DeepCopy()
+	created.Zero()
+	if err = structs.UnmarshalJSONAPI([]byte(`{"data":{"type":"books","attributes":{"title":"Emma"}}}`), "books", created); err != nil {
+		t.Fatal(err)
+	}
+	if created.GetField("ID") != int64(0) || created.GetField("Title") != "Emma" {
+		t.Errorf("Unexpected resource without id %v", created.Interface())
+	}
+
+	var other = s.DeepCopy()
+	other.SetField("ID", int64(8))
+	other.SetField("Title", "Ulysses")
+	collection, err := structs.MarshalJSONAPICollection("books", []*structs.Struct{s, other})
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, err := structs.UnmarshalJSONAPICollection(collection, "books", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].GetField("ID") != int64(7) || items[1].GetField("Title") != "Ulysses" {
+		t.Errorf("Unexpected collection %s", collection)
+	}
+	if _, err = structs.UnmarshalJSONAPICollection([]byte(`{"data":[{"type":"books","id":"x"}]}`), "books", s); err == nil {
+		t.Error("Expected error for an id which cannot be parsed")
+	}
+}
+
+func newEnvStruct() *structs.Struct {
+	var address = structs.New("json")
+	address.StringField("City", "city")
+	address.Make()
+
+	var s = structs.New("json")
+	s.StringField("Name", "name")
+	s.IntField("Port", "port")
+	s.StructField("Address", "address", address)
+	s.Make()
+	return s
+}
+
+func TestEnvRoundTrip(t *testing.T) {
+	var s = newEnvStruct()
+	s.SetField("Name", "say \"hi\" # not a comment\n\tbye\\")
+	s.SetField("Port", 8080)
+	s.FieldByName("Address").FieldByName("City").SetString("Zürich")
+
+	var buf bytes.Buffer
+	if err := s.SaveEnv(&buf, "APP"); err != nil {
+		t.Fatal(err)
+	}
+	var expected = "APP_ADDRESS_CITY=Zürich\nAPP_NAME=\"say \\\"hi\\\" # not a comment\\n\\tbye\\\\\"\nAPP_PORT=8080\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+	var loaded = newEnvStruct()
+	if err := loaded.LoadEnv(&buf, "APP_"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.Interface(), s.Interface()) {
+		t.Errorf("Expected %v, got %v", s.Interface(), loaded.Interface())
+	}
+
+	var src = "# comment\nexport APP_NAME='single # quoted'\nAPP_PORT = 9000 # inline\nOTHER_PORT=1\n"
+	if err := loaded.LoadEnv(strings.NewReader(src), "APP"); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.GetField("Name") != "single # quoted" || loaded.GetField("Port") != 9000 {
+		t.Errorf("Unexpected fields %v", loaded.Interface())
+	}
+	for _, src := range []string{"APP_NAME\n", "APP_NAME=\"open\n", "APP_NAME='open\n", "APP_PORT=eighty\n"} {
+		if err := loaded.LoadEnv(strings.NewReader(src), "APP"); err == nil {
+			t.Errorf("Expected error for %q", src)
+		}
+	}
+	if loaded.GetField("Port") != 9000 {
+		t.Errorf("Expected failed loads to leave the struct unchanged, got %v", loaded.Interface())
+	}
+}
+
+func TestPropertiesRoundTrip(t *testing.T) {
+	var s = newEnvStruct()
+	s.SetField("Name", " #lead: tab\tline\nsnow ☃ 🏔")
+	s.SetField("Port", 8080)
+	s.FieldByName("Address").FieldByName("City").SetString("a=b")
+
+	var buf bytes.Buffer
+	if err := s.SaveProperties(&buf, "app"); err != nil {
+		t.Fatal(err)
+	}
+	var expected = "app.address.city=a=b\napp.name=\\ #lead: tab\\tline\\nsnow \\u2603 \\ud83c\\udfd4\napp.port=8080\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+	var loaded = newEnvStruct()
+	if err := loaded.LoadProperties(&buf, "app."); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.Interface(), s.Interface()) {
+		t.Errorf("Expected %v, got %v", s.Interface(), loaded.Interface())
+	}
+
+	var src = "! comment\n# comment\napp.name : multi \\\n    line\napp.port 9000\napp.address.city=\n"
+	if err := loaded.LoadProperties(strings.NewReader(src), "app"); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.GetField("Name") != "multi line" || loaded.GetField("Port") != 9000 || loaded.FieldByName("Address").FieldByName("City").String() != "" {
+		t.Errorf("Unexpected fields %v", loaded.Interface())
+	}
+	for _, src := range []string{"app.name=\\u12\n", "app.name=\\uzzzz\n", "app.port=eighty\n"} {
+		if err := loaded.LoadProperties(strings.NewReader(src), "app"); err == nil {
+			t.Errorf("Expected error for %q", src)
+		}
+	}
+	if loaded.GetField("Port") != 9000 {
+		t.Errorf("Expected failed loads to leave the struct unchanged, got %v", loaded.Interface())
+	}
+}
+
+type formFile struct {
+	field, filename, contentType, content string
+}
+
+func readForm(t *testing.T, values map[string][]string, files ...formFile) *multipart.Form {
+	var buf bytes.Buffer
+	var w = multipart.NewWriter(&buf)
+	for name, vals := range values {
+		for _, v := range vals {
+			w.WriteField(name, v)
+		}
+	}
+	for _, f := range files {
+		var header = make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q; filename=%q`, f.field, f.filename))
+		header.Set("Content-Type", f.contentType)
+		var part, err = w.CreatePart(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(part, f.content)
+	}
+	w.Close()
+	var form, err = multipart.NewReader(&buf, w.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return form
+}
+
+func TestBindMultipart(t *testing.T) {
+	var s = structs.New("json")
+	s.StringField("Title", "title", true)
+	s.AddField("Tags", "tags", reflect.TypeOf([]int{}))
+	s.FileField("Avatar", "avatar", 4, []string{"image/*"}, true)
+	s.AddStructField(reflect.StructField{
+		Name: "Attachments",
+		Tag:  `json:"attachments" file:"types=text/plain|application/pdf"`,
+		Type: reflect.TypeOf([]*multipart.FileHeader{}),
+	})
+	s.Make()
+
+	var form = readForm(t, map[string][]string{"title": {"Hello"}, "tags": {"1", "2"}},
+		formFile{"avatar", "a.png", "image/png; charset=binary", "png"},
+		formFile{"attachments", "a.txt", "text/plain", "a"},
+		formFile{"attachments", "b.pdf", "application/pdf", "b"},
+	)
+	if err := s.BindMultipart(form); err != nil {
+		t.Fatal(err)
+	}
+	if s.GetField("Title") != "Hello" || !reflect.DeepEqual(s.GetField("Tags"), []int{1, 2}) {
+		t.Errorf("Unexpected values %v", s.Interface())
+	}
+	if avatar := s.GetField("Avatar").(*multipart.FileHeader); avatar.Filename != "a.png" || avatar.Size != 3 {
+		t.Errorf("Unexpected avatar %v", avatar)
+	}
+	if attachments := s.GetField("Attachments").([]*multipart.FileHeader); len(attachments) != 2 || attachments[1].Filename != "b.pdf" {
+		t.Errorf("Unexpected attachments %v", attachments)
+	}
+
+	var tests = map[string]*multipart.Form{
+		"file too large":           readForm(t, map[string][]string{"title": {"x"}}, formFile{"avatar", "big.png", "image/png", "12345"}),
+		"content type not allowed": readForm(t, map[string][]string{"title": {"x"}}, formFile{"avatar", "a.gif", "text/html", "gif"}),
+		"invalid content type":     readForm(t, map[string][]string{"title": {"x"}}, formFile{"avatar", "a.png", "image/", "png"}),
+		"one file not allowed": readForm(t, map[string][]string{"title": {"x"}},
+			formFile{"avatar", "a.png", "image/png", "png"},
+			formFile{"attachments", "a.txt", "text/plain", "a"},
+			formFile{"attachments", "b.exe", "application/octet-stream", "b"},
+		),
+		"missing file":  readForm(t, map[string][]string{"title": {"x"}}),
+		"missing value": readForm(t, nil, formFile{"avatar", "a.png", "image/png", "png"}),
+		"invalid value": readForm(t, map[string][]string{"title": {"x"}, "tags": {"one"}}, formFile{"avatar", "a.png", "image/png", "png"}),
+	}
+	for name, form := range tests {
+		if err := s.BindMultipart(form); err == nil {
+			t.Errorf("%s: Expected error", name)
+		}
+		if s.GetField("Title") != "Hello" || s.GetField("Avatar").(*multipart.FileHeader).Filename != "a.png" {
+			t.Errorf("%s: Expected struct to be left unchanged, got %v", name, s.Interface())
+		}
+	}
+}
+
+func TestChecksums(t *testing.T) {
+	var s = structs.New("json")
+	s.StringField("Name", "name")
+	s.IntField("Age", "age")
+	s.ChecksumField("Sum", "sum", crypto.SHA256, "Name", "Age")
+	s.Make()
+	s.SetField("Name", "John")
+	s.SetField("Age", 30)
+
+	var h = sha256.New()
+	h.Write([]byte("Name\x00John\x00Age\x0030\x00"))
+	var expected = fmt.Sprintf("%x", h.Sum(nil))
+	if sum, err := s.Checksum("Sum"); err != nil || sum != expected {
+		t.Errorf("Expected %s, got %s %v", expected, sum, err)
+	}
+	if s.GetField("Sum") != "" {
+		t.Errorf("Expected Checksum not to store the checksum, got %q", s.GetField("Sum"))
+	}
+	if ok, err := s.VerifyChecksums(); err != nil || ok {
+		t.Errorf("Expected checksums not to verify before they are updated, got %v %v", ok, err)
+	}
+	if err := s.UpdateChecksums(); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := s.VerifyChecksums(); err != nil || !ok || s.GetField("Sum") != expected {
+		t.Errorf("Expected checksums to verify, got %v %v", ok, err)
+	}
+
+	s.SetField("Age", 31)
+	if ok, _ := s.VerifyChecksums(); ok {
+		t.Error("Expected checksums not to verify after a field changed")
+	}
+	var data, err = json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ = s.Checksum("Sum")
+	if !strings.Contains(string(data), expected) {
+		t.Errorf("Expected MarshalJSON to encode the computed checksum, got %s", data)
+	}
+	if ok, _ := s.VerifyChecksums(); ok {
+		t.Error("Expected MarshalJSON not to store the checksum")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := json.Marshal(s); err != nil {
+				t.Error(err)
+			}
+			if _, err := structs.MarshalCompact(s); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if s.GetField("Sum") == expected {
+		t.Error("Expected concurrent marshaling not to store the checksum")
+	}
+	if _, err = s.Checksum("Name"); err == nil {
+		t.Error("Expected error for a field which is not a checksum field")
+	}
+
+	var unavailable = structs.New("json")
+	unavailable.StringField("Name", "name")
+	unavailable.ChecksumField("Sum", "sum", crypto.MD4, "Name")
+	unavailable.Make()
+	if err = unavailable.UpdateChecksums(); err == nil {
+		t.Error("Expected error for a hash function which is not linked")
+	}
+	if _, err = unavailable.VerifyChecksums(); err == nil {
+		t.Error("Expected error for a hash function which is not linked")
+	}
+
+	var missing = structs.New("json")
+	missing.StringField("Name", "name")
+	missing.ChecksumField("Sum", "sum", crypto.SHA256, "Name", "Age")
+	missing.Make()
+	if _, err = missing.Checksum("Sum"); err == nil {
+		t.Error("Expected error for a checksum over a field which does not exist")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic for a checksum over no fields")
+		}
+	}()
+	structs.New("json").ChecksumField("Sum", "sum", crypto.SHA256)
+}
+
+func TestIPFields(t *testing.T) {
+	var s = structs.New("json")
+	s.IPField("Host", "host")
+	s.CIDRField("Network", "network")
+	s.Make()
+	s.SetField("Host", "192.168.1.1")
+	s.SetField("Network", "10.0.0.0/8")
+	if s.GetField("Host") != netip.MustParseAddr("192.168.1.1") || s.GetField("Network") != netip.MustParsePrefix("10.0.0.0/8") {
+		t.Errorf("Unexpected fields %v", s.Interface())
+	}
+	if err := s.UnmarshalJSON([]byte(`{"host":"not an ip"}`)); err == nil {
+		t.Error("Expected error for an invalid IP address")
+	}
+
+	var addr = netip.MustParseAddr
+	var prefix = netip.MustParsePrefix
+	var tests = []struct {
+		name      string
+		validator func(interface{}) error
+		valid     []interface{}
+		invalid   []interface{}
+	}{
+		{"ValidateIPv4", structs.ValidateIPv4,
+			[]interface{}{addr("8.8.8.8"), prefix("10.0.0.0/8")},
+			[]interface{}{addr("::1"), addr("::ffff:8.8.8.8"), netip.Addr{}, netip.Prefix{}, "8.8.8.8"}},
+		{"ValidateIPv6", structs.ValidateIPv6,
+			[]interface{}{addr("2001:db8::1"), prefix("fd00::/8")},
+			[]interface{}{addr("8.8.8.8"), addr("::ffff:8.8.8.8"), netip.Addr{}}},
+		{"ValidatePrivateIP", structs.ValidatePrivateIP,
+			[]interface{}{addr("10.1.2.3"), addr("172.16.0.1"), addr("192.168.0.1"), addr("fd00::1"), addr("127.0.0.1"), addr("::1"), prefix("192.168.0.0/16")},
+			[]interface{}{addr("8.8.8.8"), addr("2001:4860::8888"), addr("169.254.0.1")}},
+		{"ValidatePublicIP", structs.ValidatePublicIP,
+			[]interface{}{addr("8.8.8.8"), addr("2001:4860::8888"), prefix("1.1.1.0/24")},
+			[]interface{}{addr("10.0.0.1"), addr("127.0.0.1"), addr("169.254.0.1"), addr("fe80::1"), addr("ff02::1"), addr("0.0.0.0"), addr("::"), netip.Addr{}}},
+	}
+	for _, test := range tests {
+		var validators = make(structs.ValidatorMap)
+		validators.Add("Host", test.validator)
+		for _, v := range test.valid {
+			if err := validators.Validate("Host", v); err != nil {
+				t.Errorf("%s: Expected %v to be valid, got %v", test.name, v, err)
+			}
+		}
+		for _, v := range test.invalid {
+			if err := validators.Validate("Host", v); err == nil {
+				t.Errorf("%s: Expected %v to be invalid", test.name, v)
+			}
+		}
+	}
+}
+
+func TestReplaceField(t *testing.T) {
+	var s = structs.New("json")
+	s.StringField("Name", "name")
+	s.IntField("Age", "age,omitempty")
+	s.IntField("Code", "code")
+	s.Make()
+	s.SetField("Name", "John")
+	s.SetField("Age", 30)
+	s.SetField("Code", 65)
+
+	s.ReplaceField("Age", reflect.TypeOf(int64(0)), `db:"age"`)
+	if field := s.Field(1); field.Name != "Age" || field.Tag != `json:"age,omitempty" db:"age"` {
+		t.Errorf("Expected field to keep its position and tag, got %s %s", field.Name, field.Tag)
+	}
+	if s.GetField("Age") != int64(30) || s.GetField("Name") != "John" || s.GetField("Code") != 65 {
+		t.Errorf("Expected values to be preserved, got %v", s.Interface())
+	}
+
+	// An int can be converted to a string by Go, but it would become "A".
+	s.ReplaceField("Code", reflect.TypeOf(""))
+	if s.GetField("Code") != "" {
+		t.Errorf("Expected integer not to be converted to a string, got %q", s.GetField("Code"))
+	}
+	s.ReplaceField("Name", reflect.TypeOf(time.Time{}))
+	if !s.GetField("Name").(time.Time).IsZero() || s.GetField("Age") != int64(30) {
+		t.Errorf("Expected a value which cannot be converted to be reset, got %v", s.Interface())
+	}
+
+	var unmade = structs.New("json")
+	unmade.IntField("Age", "age")
+	unmade.ReplaceField("Age", reflect.TypeOf(uint8(0)))
+	unmade.Make()
+	if unmade.GetField("Age") != uint8(0) {
+		t.Errorf("Expected field of type uint8, got %T", unmade.GetField("Age"))
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic for a field which does not exist")
+		}
+	}()
+	s.ReplaceField("Missing", reflect.TypeOf(0))
+}
+
+func TestReplaceNestedField(t *testing.T) {
+	var s = structs.FromRecursive(treeNode{}, "json", -1)
+	s.ReplaceField("Location", reflect.TypeOf(""))
+	s.Make()
+	if s.Nested("Location") != nil || s.GetField("Location") != "" {
+		t.Errorf("Expected the replaced field not to be rebuilt, got %T", s.GetField("Location"))
+	}
+}
@@ -0,0 +1,165 @@
+package structs
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Subject returns the messaging subject for the struct, I.E. a NATS subject.
+//
+// Placeholders in the template are replaced with the values of the fields of that name,
+// so "orders.{Region}.{Status}" may become "orders.eu.shipped".
+// Values which are empty, or contain '.', '*', '>' or whitespace, cannot be used as a subject token.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) Subject(template string) (string, error) {
+	s.checkMade("Cannot create subject if struct has not been made")
+	return s.expandTemplate(template, func(value string) (string, error) {
+		if value == "" || strings.ContainsAny(value, ".*> \t\r\n") {
+			return "", fmt.Errorf("Invalid subject token %q", value)
+		}
+		return value, nil
+	})
+}
+
+// ParseSubject returns the field values held by the subject, keyed by field name.
+//
+// Placeholders in the template must make up a whole token, I.E. "orders.{Region}.{Status}".
+// All other tokens must match the subject literally.
+func ParseSubject(template, subject string) (map[string]string, error) {
+	return parseTemplate(template, subject, ".")
+}
+
+// Topic returns the MQTT topic for the struct.
+//
+// Placeholders in the template are replaced with the values of the fields of that name,
+// so "devices/{Model}/{Serial}/telemetry" may become "devices/t100/42/telemetry".
+// Values which are empty, or contain '/', '+' or '#', cannot be used as a topic level.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) Topic(template string) (string, error) {
+	s.checkMade("Cannot create topic if struct has not been made")
+	return s.expandTemplate(template, func(value string) (string, error) {
+		if value == "" || strings.ContainsAny(value, "/+#") {
+			return "", fmt.Errorf("Invalid topic level %q", value)
+		}
+		return value, nil
+	})
+}
+
+// ParseTopic returns the field values held by the MQTT topic, keyed by field name.
+//
+// Placeholders in the template must make up a whole level, I.E. "devices/{Model}/{Serial}/telemetry".
+// All other levels must match the topic literally.
+func ParseTopic(template, topic string) (map[string]string, error) {
+	return parseTemplate(template, topic, "/")
+}
+
+func parseTemplate(template, value, sep string) (map[string]string, error) {
+	var patterns = strings.Split(template, sep)
+	var tokens = strings.Split(value, sep)
+	if len(patterns) != len(tokens) {
+		return nil, fmt.Errorf("%s does not match %s", value, template)
+	}
+	var values = make(map[string]string)
+	for i, pattern := range patterns {
+		if strings.HasPrefix(pattern, "{") && strings.HasSuffix(pattern, "}") {
+			values[pattern[1:len(pattern)-1]] = tokens[i]
+			continue
+		}
+		if pattern != tokens[i] {
+			return nil, fmt.Errorf("%s does not match %s", value, template)
+		}
+	}
+	return values, nil
+}
+
+// setTemplateValues parses the values into the fields of v, as returned by decodeTarget, of that name.
+func setTemplateValues(v reflect.Value, values map[string]string) error {
+	for name, value := range values {
+		var field = v.FieldByName(name)
+		if !field.IsValid() {
+			return fmt.Errorf("Field %s not found", name)
+		}
+		var parsed, err = parseValue(value, field.Type())
+		if err != nil {
+			return fmt.Errorf("%s: %s", name, err)
+		}
+		field.Set(parsed)
+	}
+	return nil
+}
+
+// EncodeMessage returns the subject and the JSON encoded body of the struct.
+//
+// It will panic if the struct has not been made.
+func EncodeMessage(s *Struct, template string) (subject string, data []byte, err error) {
+	if subject, err = s.Subject(template); err != nil {
+		return "", nil, err
+	}
+	if data, err = s.MarshalJSON(); err != nil {
+		return "", nil, err
+	}
+	return subject, data, nil
+}
+
+// DecodeMessage decodes the JSON body into the struct, and sets the fields held by the subject.
+//
+// Values from the subject take precedence over values in the body.
+// If the body or the subject cannot be decoded, an error is returned and the struct is left unchanged.
+//
+// It will panic if the struct has not been made.
+func DecodeMessage(s *Struct, template, subject string, data []byte) error {
+	s.checkMade("Cannot decode message if struct has not been made")
+	var values, err = ParseSubject(template, subject)
+	if err != nil {
+		return err
+	}
+	var v = s.decodeTarget()
+	if len(data) > 0 {
+		if err = s.decodeJSON(data, v); err != nil {
+			return err
+		}
+	}
+	if err = setTemplateValues(v, values); err != nil {
+		return err
+	}
+	return s.setDecoded(v)
+}
+
+// EncodeTelemetry returns the MQTT topic and the compact payload of the struct, as written by MarshalCompact.
+//
+// It will panic if the struct has not been made.
+func EncodeTelemetry(s *Struct, template string) (topic string, payload []byte, err error) {
+	if topic, err = s.Topic(template); err != nil {
+		return "", nil, err
+	}
+	if payload, err = MarshalCompact(s); err != nil {
+		return "", nil, err
+	}
+	return topic, payload, nil
+}
+
+// DecodeTelemetry decodes a compact payload written by MarshalCompact into the struct,
+// and sets the fields held by the MQTT topic.
+//
+// Values from the topic take precedence over values in the payload.
+// If the payload or the topic cannot be decoded, an error is returned and the struct is left unchanged.
+//
+// It will panic if the struct has not been made.
+func DecodeTelemetry(s *Struct, template, topic string, payload []byte) error {
+	s.checkMade("Cannot decode telemetry if struct has not been made")
+	var values, err = ParseTopic(template, topic)
+	if err != nil {
+		return err
+	}
+	var v = reflect.New(s.sstruct).Elem()
+	if err = decodeCompact(payload, v); err != nil {
+		return err
+	}
+	if err = setTemplateValues(v, values); err != nil {
+		return err
+	}
+	return s.setDecoded(v)
+}
@@ -0,0 +1,136 @@
+package structs
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type tagPair struct {
+	key   string
+	value string
+}
+
+// parseTag splits a struct tag into its key/value pairs, in order.
+//
+// It follows the conventions of reflect.StructTag.Get, malformed trailing content is dropped.
+func parseTag(tag reflect.StructTag) []tagPair {
+	var pairs = make([]tagPair, 0)
+	var s = string(tag)
+	for s != "" {
+		s = strings.TrimLeft(s, " ")
+		var i int
+		for i < len(s) && s[i] > ' ' && s[i] != ':' && s[i] != '"' && s[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(s) || s[i] != ':' || s[i+1] != '"' {
+			break
+		}
+		var key = s[:i]
+		s = s[i+1:]
+
+		i = 1
+		for i < len(s) && s[i] != '"' {
+			if s[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(s) {
+			break
+		}
+		var value, err = strconv.Unquote(s[:i+1])
+		if err != nil {
+			break
+		}
+		pairs = append(pairs, tagPair{key: key, value: value})
+		s = s[i+1:]
+	}
+	return pairs
+}
+
+func formatTag(pairs []tagPair) reflect.StructTag {
+	var parts = make([]string, len(pairs))
+	for i, p := range pairs {
+		parts[i] = p.key + ":" + strconv.Quote(p.value)
+	}
+	return reflect.StructTag(strings.Join(parts, " "))
+}
+
+// setTagKey returns the tag with the key set to the value, keeping the position of an existing key.
+func setTagKey(tag reflect.StructTag, key, value string) reflect.StructTag {
+	var pairs = parseTag(tag)
+	for i := range pairs {
+		if pairs[i].key == key {
+			pairs[i].value = value
+			return formatTag(pairs)
+		}
+	}
+	return formatTag(append(pairs, tagPair{key: key, value: value}))
+}
+
+// deleteTagKey returns the tag without the key.
+func deleteTagKey(tag reflect.StructTag, key string) reflect.StructTag {
+	var pairs = parseTag(tag)
+	var kept = pairs[:0]
+	for _, p := range pairs {
+		if p.key != key {
+			kept = append(kept, p)
+		}
+	}
+	return formatTag(kept)
+}
+
+// updateTag replaces the tag of the named field with the result of fn.
+//
+// The made flag is reset, so the struct has to be made again before it can be used.
+func (s *Struct) updateTag(name string, fn func(reflect.StructTag) reflect.StructTag) {
+	for i, field := range s.fieldsByName {
+		if field.Name != name {
+			continue
+		}
+		var fields = make([]reflect.StructField, len(s.fieldsByName))
+		copy(fields, s.fieldsByName)
+		fields[i].Tag = fn(field.Tag)
+		s.fieldsByName = fields
+		s.made = false
+		return
+	}
+	panic(fmt.Sprintf("Field %s does not exist", name))
+}
+
+// GetTag returns the value of the tag key on the named field, and whether the key is present.
+//
+// It will panic if the field does not exist.
+func (s *Struct) GetTag(name, key string) (string, bool) {
+	for _, field := range s.fieldsByName {
+		if field.Name == name {
+			return field.Tag.Lookup(key)
+		}
+	}
+	panic(fmt.Sprintf("Field %s does not exist", name))
+}
+
+// SetTag sets the tag key on the named field to the value, I.E. s.SetTag("Age", "db", "age_col").
+//
+// An existing key keeps its position in the tag.
+// The made flag is reset, so the struct has to be made again before it can be used.
+//
+// It will panic if the field does not exist.
+func (s *Struct) SetTag(name, key, value string) {
+	s.updateTag(name, func(tag reflect.StructTag) reflect.StructTag {
+		return setTagKey(tag, key, value)
+	})
+}
+
+// DeleteTag removes the tag key from the named field.
+//
+// The made flag is reset, so the struct has to be made again before it can be used.
+//
+// It will panic if the field does not exist.
+func (s *Struct) DeleteTag(name, key string) {
+	s.updateTag(name, func(tag reflect.StructTag) reflect.StructTag {
+		return deleteTagKey(tag, key)
+	})
+}
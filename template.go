@@ -0,0 +1,45 @@
+package structs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// expandTemplate replaces placeholders like {ID} in the template with the values of the fields of that name.
+//
+// The formatted values are passed through escape before they are written.
+// An error is returned for fields which the installed policy does not allow to be read.
+func (s *Struct) expandTemplate(template string, escape func(value string) (string, error)) (string, error) {
+	var b strings.Builder
+	for {
+		var start = strings.IndexByte(template, '{')
+		if start < 0 {
+			b.WriteString(template)
+			return b.String(), nil
+		}
+		var end = strings.IndexByte(template[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("Unterminated placeholder in %q", template)
+		}
+		end += start
+
+		var name = template[start+1 : end]
+		var field = s.structValue.FieldByName(name)
+		if !field.IsValid() {
+			return "", fmt.Errorf("Field %s not found", name)
+		}
+		if err := catch(func() { s.checkPolicy(ActionRead, name, field.Interface()) }); err != nil {
+			return "", err
+		}
+		var value, err = formatValue(field)
+		if err != nil {
+			return "", err
+		}
+		if value, err = escape(value); err != nil {
+			return "", fmt.Errorf("%s: %s", name, err)
+		}
+		b.WriteString(template[:start])
+		b.WriteString(value)
+		template = template[end+1:]
+	}
+}
@@ -0,0 +1,264 @@
+package structs
+
+import (
+	"fmt"
+	"reflect"
+)
+
+type mapOptions struct {
+	fieldNames bool
+	nested     bool
+}
+
+// MapOption configures Map.
+type MapOption func(*mapOptions)
+
+// MapFieldNames keys the map by the field names, instead of the encoding names in the struct's tag.
+func MapFieldNames() MapOption {
+	return func(o *mapOptions) {
+		o.fieldNames = true
+	}
+}
+
+// MapNested converts nested structs and pointers to structs to maps, instead of storing their values.
+func MapNested() MapOption {
+	return func(o *mapOptions) {
+		o.nested = true
+	}
+}
+
+// Map returns the values of the struct in a map, keyed by the encoding names in the struct's tag.
+//
+// Fields tagged "-" and fields the installed policy does not allow to be read are left out.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) Map(opts ...MapOption) map[string]interface{} {
+	s.checkMade("Cannot convert to map if struct has not been made")
+	var o mapOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return toMap(s.readable(), s.tag, o)
+}
+
+func toMap(v reflect.Value, tag string, o mapOptions) map[string]interface{} {
+	var m = make(map[string]interface{}, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		var field = v.Type().Field(i)
+		if !field.IsExported() || field.Tag.Get(tag) == "-" {
+			continue
+		}
+		var key = encName(field, tag)
+		if o.fieldNames {
+			key = field.Name
+		}
+		var value = v.Field(i)
+		if o.nested && value.Kind() == reflect.Ptr && !isTextType(value.Type().Elem()) {
+			if value.IsNil() {
+				m[key] = nil
+				continue
+			}
+			value = value.Elem()
+		}
+		if o.nested && !isTextType(value.Type()) {
+			m[key] = toMap(value, tag, o)
+			continue
+		}
+		m[key] = value.Interface()
+	}
+	return m
+}
+
+// SetFromMap sets the fields of the struct from a map keyed by encoding names or field names, I.E. a decoded JSON object.
+//
+// Values are converted to the type of the field: numbers are converted between types if this does not lose precision,
+// strings are parsed for fields which are not strings, and maps and slices are converted recursively,
+// maps becoming nested structs. Keys which do not match a field are ignored, as are keys of derived fields.
+//
+// If any value cannot be converted or set, or a field may not be written, an error is returned and the struct is left unchanged.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) SetFromMap(m map[string]interface{}) error {
+	s.checkMade("Cannot set from map if struct has not been made")
+	var names = mapFields(s.sstruct, s.tag)
+	var values = make(map[string]reflect.Value, len(m))
+	for key, value := range m {
+		var field, ok = names[key]
+		if !ok || s.isDerived(field.Name) {
+			continue
+		}
+		var converted, err = convertTo(value, field.Type, s.tag)
+		if err != nil {
+			return fmt.Errorf("%s: %s", key, err)
+		}
+		if err := catch(func() { s.checkPolicy(ActionWrite, field.Name, converted.Interface()) }); err != nil {
+			return err
+		}
+		if err := assign(reflect.New(field.Type).Elem(), field.Tag, converted.Interface()); err != nil {
+			return fmt.Errorf("%s: %s", key, err)
+		}
+		values[field.Name] = converted
+	}
+	for name, value := range values {
+		s.SetField(name, value.Interface())
+	}
+	return nil
+}
+
+// decodeTarget returns a deep copy of the struct value, which can be decoded into without affecting the struct.
+func (s *Struct) decodeTarget() reflect.Value {
+	var v = reflect.New(s.sstruct).Elem()
+	v.Set(deepCopyValue(s.structValue, make(map[reflect.Value]reflect.Value)))
+	return v
+}
+
+// DecodeWith calls decode with a copy of the struct value, and sets the fields it changed through SetField.
+//
+// This is meant for decoders outside of this package, the value passed to decode is a settable struct value
+// which shares no memory with the struct. If decode returns an error, it is returned as is and the struct is left unchanged.
+// If any changed field cannot be set, I.E. because the installed policy does not allow it to be written,
+// an error is returned and the struct is left unchanged.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) DecodeWith(decode func(v reflect.Value) error) error {
+	s.checkMade("Cannot decode if struct has not been made")
+	var v = s.decodeTarget()
+	if err := decode(v); err != nil {
+		return err
+	}
+	return s.setDecoded(v)
+}
+
+// setDecoded sets the fields whose value in v, as returned by decodeTarget, differs from the struct through SetField.
+// Derived fields are skipped, they are recomputed when their source is set.
+//
+// Every changed field is checked against the installed policy and assigned to a scratch value first,
+// so if any field may not be written or cannot be normalized, an error is returned and the struct is left unchanged.
+func (s *Struct) setDecoded(v reflect.Value) error {
+	var indices []int
+	for i := 0; i < s.sstruct.NumField(); i++ {
+		var field = s.sstruct.Field(i)
+		if s.isDerived(field.Name) {
+			continue
+		}
+		var value = v.Field(i).Interface()
+		if reflect.DeepEqual(value, s.structValue.Field(i).Interface()) {
+			continue
+		}
+		if err := catch(func() { s.checkPolicy(ActionWrite, field.Name, value) }); err != nil {
+			return err
+		}
+		if err := assign(reflect.New(field.Type).Elem(), field.Tag, value); err != nil {
+			return fmt.Errorf("Cannot set field %s: %s", field.Name, err)
+		}
+		indices = append(indices, i)
+	}
+	for _, i := range indices {
+		s.SetFieldByIndex(i, v.Field(i).Interface())
+	}
+	return nil
+}
+
+// mapFields returns the exported fields of the struct type by their encoding names and field names.
+func mapFields(typ reflect.Type, tag string) map[string]reflect.StructField {
+	var fields = make(map[string]reflect.StructField, typ.NumField()*2)
+	for i := 0; i < typ.NumField(); i++ {
+		var field = typ.Field(i)
+		if field.IsExported() && field.Tag.Get(tag) != "-" {
+			fields[field.Name] = field
+		}
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		var field = typ.Field(i)
+		if field.IsExported() && field.Tag.Get(tag) != "-" {
+			fields[encName(field, tag)] = field
+		}
+	}
+	return fields
+}
+
+// convertTo converts a value, I.E. decoded from JSON, to the type.
+func convertTo(value interface{}, typ reflect.Type, tag string) (reflect.Value, error) {
+	if value == nil {
+		return reflect.Zero(typ), nil
+	}
+	var v = reflect.ValueOf(value)
+	if v.Type().AssignableTo(typ) {
+		var out = reflect.New(typ).Elem()
+		out.Set(v)
+		return out, nil
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Zero(typ), nil
+		}
+		return convertTo(v.Elem().Interface(), typ, tag)
+	}
+	if typ.Kind() == reflect.Ptr {
+		var elem, err = convertTo(value, typ.Elem(), tag)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		var ptr = reflect.New(typ.Elem())
+		ptr.Elem().Set(elem)
+		return ptr, nil
+	}
+
+	switch {
+	case v.Kind() == reflect.String && typ.Kind() != reflect.String:
+		return parseValue(v.String(), typ)
+	case isNumericKind(v.Kind()) && isNumericKind(typ.Kind()):
+		var converted = v.Convert(typ)
+		if converted.Convert(v.Type()).Interface() != v.Interface() {
+			return reflect.Value{}, fmt.Errorf("Cannot convert %v to %s without losing precision", value, typ.String())
+		}
+		return converted, nil
+	case v.Kind() == reflect.Map && typ.Kind() == reflect.Struct && !isTextType(typ):
+		var m, ok = value.(map[string]interface{})
+		if !ok {
+			break
+		}
+		var out = reflect.New(typ).Elem()
+		var names = mapFields(typ, tag)
+		for key, elem := range m {
+			var field, ok = names[key]
+			if !ok {
+				continue
+			}
+			var converted, err = convertTo(elem, field.Type, tag)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("%s: %s", key, err)
+			}
+			out.FieldByIndex(field.Index).Set(converted)
+		}
+		return out, nil
+	case v.Kind() == reflect.Map && typ.Kind() == reflect.Map:
+		var out = reflect.MakeMapWithSize(typ, v.Len())
+		var iter = v.MapRange()
+		for iter.Next() {
+			var key, err = convertTo(iter.Key().Interface(), typ.Key(), tag)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			elem, err := convertTo(iter.Value().Interface(), typ.Elem(), tag)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("%v: %s", iter.Key().Interface(), err)
+			}
+			out.SetMapIndex(key, elem)
+		}
+		return out, nil
+	case (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && typ.Kind() == reflect.Slice:
+		var out = reflect.MakeSlice(typ, v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			var elem, err = convertTo(v.Index(i).Interface(), typ.Elem(), tag)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("[%d]: %s", i, err)
+			}
+			out.Index(i).Set(elem)
+		}
+		return out, nil
+	case v.Kind() == typ.Kind() && v.Type().ConvertibleTo(typ):
+		return v.Convert(typ), nil
+	}
+	return reflect.Value{}, fmt.Errorf("Cannot convert %s to %s", v.Type().String(), typ.String())
+}
@@ -0,0 +1,252 @@
+package structs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+var (
+	// ErrNotMade is returned by the Try methods when the struct has not been made.
+	ErrNotMade = errors.New("struct has not been made")
+
+	// ErrFieldNotFound is returned by the Try methods when a field does not exist.
+	ErrFieldNotFound = errors.New("field does not exist")
+)
+
+// catch calls fn, and returns any panic raised by it as an error.
+func catch(fn func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok {
+				err = e
+			} else {
+				err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+	fn()
+	return nil
+}
+
+func (s *Struct) tryField(op, name string) (reflect.Value, error) {
+	if !s.made {
+		return reflect.Value{}, fmt.Errorf("Cannot %s %s: %w", op, name, ErrNotMade)
+	}
+	var field = s.structValue.FieldByName(name)
+	if !field.IsValid() {
+		return reflect.Value{}, fmt.Errorf("Cannot %s %s: %w", op, name, ErrFieldNotFound)
+	}
+	return field, nil
+}
+
+// tryDeclared returns an error wrapping ErrFieldNotFound if no field with the name has been added,
+// the struct does not have to be made.
+func (s *Struct) tryDeclared(op, name string) error {
+	for _, field := range s.fieldsByName {
+		if field.Name == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("Cannot %s %s: %w", op, name, ErrFieldNotFound)
+}
+
+// TryMake is like Make, but returns an error instead of panicking when the struct type cannot be created,
+// I.E. when a field name is not exported.
+func (s *Struct) TryMake() error {
+	return catch(s.Make)
+}
+
+// TrySetField is like SetField, but returns an error instead of panicking.
+func (s *Struct) TrySetField(name string, value interface{}) error {
+	if _, err := s.tryField("set field", name); err != nil {
+		return err
+	}
+	return catch(func() { s.SetField(name, value) })
+}
+
+// TrySetFieldAt is like SetFieldAt, but returns an error instead of panicking.
+func (s *Struct) TrySetFieldAt(name string, value interface{}, at time.Time) error {
+	if _, err := s.tryField("set field", name); err != nil {
+		return err
+	}
+	return catch(func() { s.SetFieldAt(name, value, at) })
+}
+
+// TrySetFieldByIndex is like SetFieldByIndex, but returns an error instead of panicking.
+func (s *Struct) TrySetFieldByIndex(index int, value interface{}) error {
+	if !s.made {
+		return fmt.Errorf("Cannot set field %d: %w", index, ErrNotMade)
+	}
+	if index < 0 || index >= s.sstruct.NumField() {
+		return fmt.Errorf("Cannot set field %d: %w", index, ErrFieldNotFound)
+	}
+	return catch(func() { s.SetFieldByIndex(index, value) })
+}
+
+// TryGetField is like GetField, but returns an error instead of panicking.
+func (s *Struct) TryGetField(name string) (interface{}, error) {
+	if _, err := s.tryField("get field", name); err != nil {
+		return nil, err
+	}
+	var value interface{}
+	var err = catch(func() { value = s.GetField(name) })
+	return value, err
+}
+
+// TryFieldByName is like FieldByName, but returns an error instead of panicking,
+// or returning an invalid value when the field does not exist.
+func (s *Struct) TryFieldByName(name string) (reflect.Value, error) {
+	return s.tryField("get field", name)
+}
+
+// TryField is like Field, but returns an error instead of panicking.
+func (s *Struct) TryField(index int) (reflect.StructField, error) {
+	if !s.made {
+		return reflect.StructField{}, fmt.Errorf("Cannot get field %d: %w", index, ErrNotMade)
+	}
+	if index < 0 || index >= s.sstruct.NumField() {
+		return reflect.StructField{}, fmt.Errorf("Cannot get field %d: %w", index, ErrFieldNotFound)
+	}
+	return s.sstruct.Field(index), nil
+}
+
+// TryInterface is like Interface, but returns an error instead of panicking.
+func (s *Struct) TryInterface() (interface{}, error) {
+	if !s.made {
+		return nil, fmt.Errorf("Cannot get interface: %w", ErrNotMade)
+	}
+	return s.structValue.Interface(), nil
+}
+
+// TryPtrTo is like PtrTo, but returns an error instead of panicking.
+func (s *Struct) TryPtrTo() (interface{}, error) {
+	if !s.made {
+		return nil, fmt.Errorf("Cannot get pointer to: %w", ErrNotMade)
+	}
+	return s.structValue.Addr().Interface(), nil
+}
+
+// TryNewPointer is like NewPointer, but returns an error instead of panicking.
+func (s *Struct) TryNewPointer() (interface{}, error) {
+	if !s.made {
+		return nil, fmt.Errorf("Cannot get new pointer: %w", ErrNotMade)
+	}
+	return reflect.New(s.sstruct).Interface(), nil
+}
+
+// TryDeepCopy is like DeepCopy, but returns an error instead of panicking.
+func (s *Struct) TryDeepCopy(recursive ...bool) (*Struct, error) {
+	if !s.made {
+		return nil, fmt.Errorf("Cannot deep copy: %w", ErrNotMade)
+	}
+	var newStruct *Struct
+	var err = catch(func() { newStruct = s.DeepCopy(recursive...) })
+	return newStruct, err
+}
+
+// TryAddField is like AddField, but returns an error instead of panicking.
+func (s *Struct) TryAddField(absolute_name, enc_name string, typeOf reflect.Type, required ...bool) error {
+	if typeOf == nil {
+		return fmt.Errorf("Field %s must have a type", absolute_name)
+	}
+	return catch(func() { s.AddField(absolute_name, enc_name, typeOf, required...) })
+}
+
+// TryAddStructField is like AddStructField, but returns an error instead of panicking.
+func (s *Struct) TryAddStructField(field reflect.StructField) error {
+	if field.Type == nil {
+		return fmt.Errorf("Field %s must have a type", field.Name)
+	}
+	return catch(func() { s.AddStructField(field) })
+}
+
+// TryEmbed is like Embed, but returns an error instead of panicking.
+func (s *Struct) TryEmbed(typeOf reflect.Type) error {
+	if typeOf == nil {
+		return fmt.Errorf("Embedded field must have a type")
+	}
+	return catch(func() { s.Embed(typeOf) })
+}
+
+// TryRemoveField is like RemoveField, but returns an error instead of panicking.
+func (s *Struct) TryRemoveField(name string) error {
+	if err := s.tryDeclared("remove field", name); err != nil {
+		return err
+	}
+	return catch(func() { s.RemoveField(name) })
+}
+
+// TryRenameField is like RenameField, but returns an error instead of panicking.
+func (s *Struct) TryRenameField(oldName, newName string, enc_name ...string) error {
+	if err := s.tryDeclared("rename field", oldName); err != nil {
+		return err
+	}
+	return catch(func() { s.RenameField(oldName, newName, enc_name...) })
+}
+
+// TryReplaceField is like ReplaceField, but returns an error instead of panicking.
+func (s *Struct) TryReplaceField(name string, newType reflect.Type, tags ...string) error {
+	if err := s.tryDeclared("replace field", name); err != nil {
+		return err
+	}
+	return catch(func() { s.ReplaceField(name, newType, tags...) })
+}
+
+// TryMoveField is like MoveField, but returns an error instead of panicking.
+func (s *Struct) TryMoveField(name string, index int) error {
+	if err := s.tryDeclared("move field", name); err != nil {
+		return err
+	}
+	return catch(func() { s.MoveField(name, index) })
+}
+
+// TrySetTag is like SetTag, but returns an error instead of panicking.
+func (s *Struct) TrySetTag(name, key, value string) error {
+	if err := s.tryDeclared("set tag of field", name); err != nil {
+		return err
+	}
+	return catch(func() { s.SetTag(name, key, value) })
+}
+
+// TryDeleteTag is like DeleteTag, but returns an error instead of panicking.
+func (s *Struct) TryDeleteTag(name, key string) error {
+	if err := s.tryDeclared("delete tag of field", name); err != nil {
+		return err
+	}
+	return catch(func() { s.DeleteTag(name, key) })
+}
+
+// TryGetPath is like GetPath, but returns an error instead of panicking.
+func (s *Struct) TryGetPath(path string) (interface{}, error) {
+	if !s.made {
+		return nil, fmt.Errorf("Cannot get path %s: %w", path, ErrNotMade)
+	}
+	var value interface{}
+	var err error
+	if panicErr := catch(func() { value, err = s.GetPath(path) }); panicErr != nil {
+		return nil, panicErr
+	}
+	return value, err
+}
+
+// TrySetPath is like SetPath, but returns an error instead of panicking.
+func (s *Struct) TrySetPath(path string, value interface{}) error {
+	if !s.made {
+		return fmt.Errorf("Cannot set path %s: %w", path, ErrNotMade)
+	}
+	var err error
+	if panicErr := catch(func() { err = s.SetPath(path, value) }); panicErr != nil {
+		return panicErr
+	}
+	return err
+}
+
+// TryRetention is like Retention, but returns an error instead of panicking.
+func (s *Struct) TryRetention(name string) (time.Duration, bool, error) {
+	if _, err := s.tryField("get retention of field", name); err != nil {
+		return 0, false, err
+	}
+	return s.Retention(name)
+}
@@ -0,0 +1,150 @@
+package structs
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// TypeScript returns a TypeScript interface declaration for the fields of the struct
+//
+// Properties are named after the encoding names in the struct's tag, fields tagged "-" are skipped,
+// and fields tagged with omitempty are optional. Fields of embedded structs are inlined.
+//
+// Numbers map to number, slices to arrays, maps to Record, pointers allow null, and nested structs become object types.
+// Money, Quantity, GeoPoint, BoundingBox and Blob are typed as they are marshaled, flags fields added with asStrings
+// are arrays of their names. Other types implementing json.Marshaler and interface{} are unknown.
+// time.Time, []byte and types implementing encoding.TextMarshaler are strings.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) TypeScript(name string) string {
+	s.checkMade("Cannot create TypeScript interface if struct has not been made")
+	var b strings.Builder
+	fmt.Fprintf(&b, "export interface %s ", name)
+	writeTSObject(&b, s.sstruct, s.tag, "", make(map[reflect.Type]bool))
+	b.WriteByte('\n')
+	return b.String()
+}
+
+func writeTSObject(b *strings.Builder, typ reflect.Type, tag, indent string, seen map[reflect.Type]bool) {
+	seen[typ] = true
+	defer delete(seen, typ)
+	b.WriteString("{\n")
+	writeTSProperties(b, typ, tag, indent+"  ", seen)
+	b.WriteString(indent + "}")
+}
+
+func writeTSProperties(b *strings.Builder, typ reflect.Type, tag, indent string, seen map[reflect.Type]bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		var field = typ.Field(i)
+		var value = field.Tag.Get(tag)
+		if value == "-" {
+			continue
+		}
+		var name, options, _ = strings.Cut(value, ",")
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && name == "" {
+			writeTSProperties(b, field.Type, tag, indent, seen)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		var optional string
+		if strings.Contains(options, "omitempty") {
+			optional = "?"
+		}
+		var typeName = tsType(field.Type, tag, indent, seen)
+		if names, asStrings := flagNames(field); field.Type == flagsType && asStrings {
+			typeName = tsFlagsType(names)
+		}
+		fmt.Fprintf(b, "%s%s%s: %s;\n", indent, tsPropertyName(encName(field, tag)), optional, typeName)
+	}
+}
+
+func tsType(typ reflect.Type, tag, indent string, seen map[reflect.Type]bool) string {
+	switch {
+	case typ == timeType:
+		return "string"
+	case typ.Kind() != reflect.Ptr && reflect.PtrTo(typ).Implements(jsonMarshalerType):
+		return tsMarshalerType(typ)
+	case typ.Implements(textMarshalerType) || reflect.PtrTo(typ).Implements(textMarshalerType):
+		return "string"
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Ptr:
+		return tsType(typ.Elem(), tag, indent, seen) + " | null"
+	case reflect.Slice, reflect.Array:
+		if typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8 {
+			return "string"
+		}
+		var elem = tsType(typ.Elem(), tag, indent, seen)
+		if strings.Contains(elem, "|") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case reflect.Map:
+		var key = "string"
+		if tsType(typ.Key(), tag, indent, seen) == "number" {
+			key = "number"
+		}
+		return fmt.Sprintf("Record<%s, %s>", key, tsType(typ.Elem(), tag, indent, seen))
+	case reflect.Struct:
+		if seen[typ] {
+			return "unknown"
+		}
+		var b strings.Builder
+		writeTSObject(&b, typ, tag, indent, seen)
+		return b.String()
+	}
+	return "unknown"
+}
+
+// tsMarshalerType returns the type of the JSON written by a type implementing json.Marshaler.
+func tsMarshalerType(typ reflect.Type) string {
+	switch typ {
+	case moneyType:
+		return "{ amount: string; currency: string }"
+	case quantityType:
+		return "{ value: number; unit: string }"
+	case geoPointType:
+		return `{ type: "Point"; coordinates: number[] }`
+	case boundingBoxType:
+		return "[number, number, number, number]"
+	case blobType.Elem():
+		return "{ ref?: string; data?: string }"
+	}
+	return "unknown"
+}
+
+// tsFlagsType returns the type of a flags field which is marshaled as an array of names.
+func tsFlagsType(names []string) string {
+	var quoted = make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = strconv.Quote(name)
+	}
+	return "(" + strings.Join(quoted, " | ") + ")[]"
+}
+
+// tsPropertyName quotes the name if it is not a valid identifier.
+func tsPropertyName(name string) string {
+	for i, r := range name {
+		if r == '_' || r == '$' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r)) {
+			continue
+		}
+		return strconv.Quote(name)
+	}
+	if name == "" {
+		return `""`
+	}
+	return name
+}
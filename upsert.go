@@ -0,0 +1,150 @@
+package structs
+
+import (
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+// UpsertSQL returns an INSERT statement for the postgres, mysql or sqlite dialect which updates the existing row
+// if it conflicts on the conflict keys, along with the arguments for its placeholders.
+//
+// Columns are named as with DDL, the conflict keys may be given as field names or column names.
+// Only the fields which were written through SetField are updated, the conflict keys excepted.
+// If no such fields exist, the conflicting row is left as it is.
+//
+// Values implementing driver.Valuer are passed as they are, nil pointers are NULL, encoding.TextMarshaler types are
+// passed as text, and slices, maps, structs and interface{} are passed as JSON, except for []byte and time.Time.
+//
+// An error wrapping ErrDenied is returned if the installed policy does not allow a column to be read.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) UpsertSQL(table string, conflictKeys []string, dialect string) (string, []interface{}, error) {
+	s.checkMade("Cannot create upsert statement if struct has not been made")
+	var d, ok = sqlDialects[dialect]
+	if !ok {
+		return "", nil, fmt.Errorf("Unknown SQL dialect %s", dialect)
+	}
+	if len(conflictKeys) == 0 {
+		return "", nil, fmt.Errorf("Upsert needs at least one conflict key")
+	}
+
+	var keys = make(map[string]bool, len(conflictKeys))
+	var keyColumns = make([]string, 0, len(conflictKeys))
+	for _, key := range conflictKeys {
+		var column = key
+		if field, ok := s.sstruct.FieldByName(key); ok {
+			column, ok = sqlColumn(field)
+			if !ok {
+				return "", nil, fmt.Errorf("Conflict key %s is not stored in a column", key)
+			}
+		}
+		keys[column] = true
+		keyColumns = append(keyColumns, d.quote(column))
+	}
+
+	var columns, placeholders, updates []string
+	var args []interface{}
+	for i := 0; i < s.sstruct.NumField(); i++ {
+		var field = s.sstruct.Field(i)
+		var column, ok = sqlColumn(field)
+		if !ok {
+			continue
+		}
+		var value, err = s.ReadField(field.Name)
+		if err != nil {
+			return "", nil, err
+		}
+		arg, err := sqlArg(value)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %s", field.Name, err)
+		}
+		args = append(args, arg)
+		columns = append(columns, d.quote(column))
+		if dialect == "postgres" {
+			placeholders = append(placeholders, "$"+strconv.Itoa(len(args)))
+		} else {
+			placeholders = append(placeholders, "?")
+		}
+		delete(keys, column)
+		if _, dirty := s.stamps[field.Name]; dirty && !containsString(keyColumns, d.quote(column)) {
+			if dialect == "mysql" {
+				updates = append(updates, fmt.Sprintf("%s = VALUES(%s)", d.quote(column), d.quote(column)))
+			} else {
+				updates = append(updates, fmt.Sprintf("%s = excluded.%s", d.quote(column), d.quote(column)))
+			}
+		}
+	}
+	for column := range keys {
+		return "", nil, fmt.Errorf("Conflict key %s is not a column", column)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "INSERT INTO %s (%s) VALUES (%s)", d.quote(table), strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+	switch {
+	case dialect == "mysql" && len(updates) == 0:
+		fmt.Fprintf(&b, " ON DUPLICATE KEY UPDATE %s = %s", keyColumns[0], keyColumns[0])
+	case dialect == "mysql":
+		fmt.Fprintf(&b, " ON DUPLICATE KEY UPDATE %s", strings.Join(updates, ", "))
+	case len(updates) == 0:
+		fmt.Fprintf(&b, " ON CONFLICT (%s) DO NOTHING", strings.Join(keyColumns, ", "))
+	default:
+		fmt.Fprintf(&b, " ON CONFLICT (%s) DO UPDATE SET %s", strings.Join(keyColumns, ", "), strings.Join(updates, ", "))
+	}
+	return b.String() + ";", args, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// sqlArg converts a field value to a value which can be passed to database/sql.
+func sqlArg(v reflect.Value) (interface{}, error) {
+	if v.Type().Implements(valuerType) {
+		return v.Interface(), nil
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		return sqlArg(v.Elem())
+	}
+	switch {
+	case v.Type() == timeType:
+		return v.Interface(), nil
+	case v.Type() == durationType:
+		return v.Int(), nil
+	case v.Type().Implements(textMarshalerType):
+		var text, err = v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Bytes(), nil
+		}
+	}
+	var data, err = json.Marshal(v.Interface())
+	return string(data), err
+}
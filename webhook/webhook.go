@@ -0,0 +1,149 @@
+// Package webhook delivers a structs.Struct as a signed JSON payload to an HTTP endpoint.
+//
+// The body is signed with an HMAC over the exact bytes sent,
+// so receivers can verify the payload before decoding it.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Nigel2392/go-structs"
+)
+
+// Webhook is the configuration of a single endpoint.
+type Webhook struct {
+	// The URL the payload is posted to.
+	URL string
+
+	// The secret used to compute the HMAC signature.
+	Secret []byte
+
+	// The header holding the signature, defaults to "X-Signature".
+	Header string
+
+	// The hash used for the HMAC, defaults to sha256.New.
+	Hash func() hash.Hash
+
+	// Extra headers which are sent with every request.
+	Headers http.Header
+
+	// The amount of retries after the first attempt has failed.
+	Retries int
+
+	// The delay before the first retry, it doubles after every retry.
+	//
+	// Defaults to one second.
+	Backoff time.Duration
+
+	// The client used to deliver the payload, defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// Body returns the canonical JSON body for the struct.
+//
+// It will panic if the struct has not been made.
+func (w *Webhook) Body(s *structs.Struct) ([]byte, error) {
+	var body, err = s.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err = json.Compact(&buf, body); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Sign returns the hex encoded HMAC of the body.
+func (w *Webhook) Sign(body []byte) string {
+	var newHash = w.Hash
+	if newHash == nil {
+		newHash = sha256.New
+	}
+	var mac = hmac.New(newHash, w.Secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether the signature matches the body.
+func (w *Webhook) Verify(body []byte, signature string) bool {
+	return hmac.Equal([]byte(w.Sign(body)), []byte(signature))
+}
+
+// Deliver posts the struct to the endpoint.
+//
+// Requests which fail, or are answered with a 429 or 5xx status, are retried with exponential backoff.
+// Other non-2xx statuses are returned as an error without retrying.
+//
+// It will panic if the struct has not been made.
+func (w *Webhook) Deliver(ctx context.Context, s *structs.Struct) error {
+	var body, err = w.Body(s)
+	if err != nil {
+		return err
+	}
+	var signature = w.Sign(body)
+	var backoff = w.Backoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		retry, err = w.send(ctx, body, signature)
+		if err == nil || !retry || attempt >= w.Retries {
+			return err
+		}
+		var timer = time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// send performs a single delivery attempt, and reports whether a failure may be retried.
+func (w *Webhook) send(ctx context.Context, body []byte, signature string) (bool, error) {
+	var req, err = http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	for key, values := range w.Headers {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	var header = w.Header
+	if header == "" {
+		header = "X-Signature"
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(header, signature)
+
+	var client = w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	var retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("Webhook %s responded with %s", w.URL, resp.Status)
+}
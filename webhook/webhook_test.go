@@ -0,0 +1,55 @@
+package webhook_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Nigel2392/go-structs"
+	"github.com/Nigel2392/go-structs/webhook"
+)
+
+func TestDeliver(t *testing.T) {
+	var s = structs.New("json")
+	s.StringField("Event", "event")
+	s.Make()
+	s.SetField("Event", "created")
+
+	var w = &webhook.Webhook{
+		Secret:  []byte("secret"),
+		Retries: 2,
+		Backoff: time.Millisecond,
+	}
+
+	var attempts int
+	var server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		attempts++
+		var body, _ = io.ReadAll(r.Body)
+		if string(body) != `{"event":"created"}` {
+			t.Errorf("Unexpected body %s", body)
+		}
+		if !w.Verify(body, r.Header.Get("X-Signature")) {
+			t.Errorf("Invalid signature %s", r.Header.Get("X-Signature"))
+		}
+		if attempts < 3 {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	w.URL = server.URL
+
+	if err := w.Deliver(context.Background(), s); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected %d attempts, got %d", 3, attempts)
+	}
+
+	attempts = -10
+	if err := w.Deliver(context.Background(), s); err == nil {
+		t.Error("Expected error after retries are exhausted")
+	}
+}
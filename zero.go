@@ -0,0 +1,58 @@
+package structs
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Zero resets every field to its zero value, without rebuilding the type
+//
+// The value is reset in place, so pointers returned by PtrTo stay valid. Write times are cleared as with Make.
+//
+// This is useful when re-using instances, I.E. from a sync.Pool.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) Zero() {
+	s.checkMade("Cannot zero if struct has not been made")
+	s.structValue.Set(reflect.Zero(s.sstruct))
+	s.stamps = nil
+}
+
+// ZeroField resets the field to its zero value, and records the write like SetField.
+//
+// It will panic if the struct has not been made, or the field does not exist.
+func (s *Struct) ZeroField(name string) {
+	s.checkMade("Cannot zero field if struct has not been made")
+	var field, ok = s.sstruct.FieldByName(name)
+	if !ok {
+		panic(fmt.Sprintf("Field %s does not exist", name))
+	}
+	var zero = reflect.Zero(field.Type)
+	s.checkPolicy(ActionWrite, name, zero.Interface())
+	s.structValue.FieldByIndex(field.Index).Set(zero)
+	s.touch(name, time.Now())
+	s.updateDerived(name)
+}
+
+// IsZero reports whether every field holds its zero value, as reported by reflect.Value.IsZero.
+//
+// It will panic if the struct has not been made.
+func (s *Struct) IsZero() bool {
+	s.checkMade("Cannot check zero value if struct has not been made")
+	return s.structValue.IsZero()
+}
+
+// FieldIsZero reports whether the field holds its zero value, as reported by reflect.Value.IsZero.
+//
+// Empty but non-nil slices and maps are not zero.
+//
+// It will panic if the struct has not been made, or the field does not exist.
+func (s *Struct) FieldIsZero(name string) bool {
+	s.checkMade("Cannot check zero value if struct has not been made")
+	var field = s.structValue.FieldByName(name)
+	if !field.IsValid() {
+		panic(fmt.Sprintf("Field %s does not exist", name))
+	}
+	return field.IsZero()
+}